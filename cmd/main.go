@@ -1,88 +1,118 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
+	"library-management-system/internal/clock"
 	"library-management-system/internal/config"
-	"library-management-system/internal/handlers"
-	"library-management-system/internal/middleware"
-	"library-management-system/internal/models"
-
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"library-management-system/internal/health"
+	"library-management-system/internal/loginguard"
+	"library-management-system/internal/metrics"
+	"library-management-system/internal/notification"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/scheduler"
+	"library-management-system/internal/server"
+	"library-management-system/migrations"
 )
 
 func main() {
-	if err := godotenv.Load("config.env"); err != nil {
-		log.Fatal("Error loading .env file")
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	if cfg.EnvFile != "" {
+		log.Printf("Loaded settings from %s", cfg.EnvFile)
 	}
 
-	db, err := config.InitDB()
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(cfg, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+
+	db, err := config.InitDB(cfg.Database)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	if err := db.AutoMigrate(&models.User{}, &models.Book{}, &models.Member{}, &models.Loan{}); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	pending, err := migrator.Pending()
+	if err != nil {
+		log.Fatal("Failed to check migrations:", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("Database has %d pending migrations; run `migrate up` first", len(pending))
+	}
+
+	sender, err := notification.NewSender(cfg.Notification)
+	if err != nil {
+		log.Fatal("Failed to set up notifications:", err)
 	}
 
-	r := gin.Default()
-	r.Use(middleware.CORS())
+	stores := repository.NewStores(db, cfg.Circulation)
 
-	api := r.Group("/api")
-	{
-		auth := api.Group("/auth")
-		{
-			auth.POST("/register", handlers.Register)
-			auth.POST("/login", handlers.Login)
-		}
+	guardStore, err := loginguard.NewStore(cfg.LoginGuard.Store, db)
+	if err != nil {
+		log.Fatal("Failed to set up login guard:", err)
+	}
+	guard := loginguard.New(guardStore, cfg.LoginGuard, stores.Audit)
 
-		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware())
-		{
-			books := protected.Group("/books")
-			{
-				books.GET("/", handlers.GetAllBooks)
-				books.GET("/:id", handlers.GetBookByID)
-				books.POST("/", handlers.CreateBook)
-				books.PUT("/:id", handlers.UpdateBook)
-				books.DELETE("/:id", handlers.DeleteBook)
-			}
+	// SIGINT or SIGTERM cancels ctx: the server drains its requests and
+	// the scheduler stops, cancelling any job it is in the middle of.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-			members := protected.Group("/members")
-			{
-				members.GET("/", handlers.GetAllMembers)
-				members.GET("/:id", handlers.GetMemberByID)
-				members.POST("/", handlers.CreateMember)
-				members.PUT("/:id", handlers.UpdateMember)
-				members.DELETE("/:id", handlers.DeleteMember)
-			}
+	jobs := scheduler.New(db, cfg.Scheduler.Interval)
+	dispatcher := notification.NewDispatcher(stores, sender, cfg.Notification.MaxAttempts)
+	scheduler.RegisterLibraryJobs(jobs, stores, dispatcher, cfg)
+	var workers sync.WaitGroup
+	if cfg.Scheduler.Enabled {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			jobs.Run(ctx)
+		}()
+	}
 
-			loans := protected.Group("/loans")
-			{
-				loans.GET("/", handlers.GetAllLoans)
-				loans.GET("/:id", handlers.GetLoanByID)
-				loans.POST("/", handlers.CreateLoan)
-				loans.PUT("/:id/return", handlers.ReturnBook)
-			}
-		}
+	serverMetrics, err := metrics.New(db, stores)
+	if err != nil {
+		log.Fatal("Failed to set up metrics:", err)
 	}
 
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "success",
-			"message": "Library Management System is running",
-		})
+	r, err := server.NewRouter(server.Dependencies{
+		Config:  cfg,
+		Stores:  stores,
+		Guard:   guard,
+		Sender:  sender,
+		Jobs:    jobs,
+		Health:  health.NewChecker(db, migrator, jobs),
+		Metrics: serverMetrics,
+		Clock:   clock.System,
 	})
+	if err != nil {
+		log.Fatal("Failed to set up routes:", err)
+	}
 
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		port = "8080"
+	if err := server.Serve(ctx, cfg.Server, r); err != nil {
+		log.Fatal("Server failed:", err)
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	stop()
+	workers.Wait()
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
 	}
+	log.Println("Server stopped")
 }
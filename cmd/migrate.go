@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"library-management-system/internal/config"
+	"library-management-system/migrations"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up [n]         apply pending migrations, or only the next n
+  down [n]       revert the newest migration, or the newest n
+  status         list migrations and when they were applied
+  seed           load the sample data for development
+  create <name>  add an empty migration for every database to ` + migrations.Dir
+
+// runMigrate runs the `migrate` subcommand with the arguments after it.
+// Only the database settings need to be valid.
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	command, args := args[0], args[1:]
+
+	if command == "create" {
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+		created, err := migrations.Create(migrations.Dir, args[0])
+		for _, file := range created {
+			fmt.Printf("Created %s\n", file)
+		}
+		return err
+	}
+
+	if err := cfg.Database.Validate(); err != nil {
+		return fmt.Errorf("invalid database configuration:\n%w", err)
+	}
+	db, err := config.InitDB(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		limit, err := migrateCount(args, 0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(limit)
+		for _, migration := range applied {
+			fmt.Printf("Applied %s\n", migration)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+		return err
+	case "down":
+		steps, err := migrateCount(args, 1)
+		if err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %s\n", migration)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("No migrations to revert")
+		}
+		return err
+	case "status":
+		return printMigrationStatus(migrator)
+	case "seed":
+		if len(args) != 0 {
+			return errors.New(migrateUsage)
+		}
+		if err := migrator.Seed(); err != nil {
+			return err
+		}
+		fmt.Println("Loaded the sample data")
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// migrateCount reads the optional count argument of up and down.
+func migrateCount(args []string, fallback int) (int, error) {
+	switch len(args) {
+	case 0:
+		return fallback, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("count must be a positive number, got %q", args[0])
+		}
+		return n, nil
+	default:
+		return 0, errors.New(migrateUsage)
+	}
+}
+
+func printMigrationStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if status.Unknown {
+			applied += " (not in this build)"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/e2e"
+	"library-management-system/migrations"
+)
+
+// TestMigrate applies every migration, reverts the newest, then the rest,
+// and applies them all again, checking status along the way.
+func TestMigrate(t *testing.T) {
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			d := e2e.NewTestDatabase(t, driver)
+			cfg := config.Default()
+			cfg.Database = d.Config
+			migrate := func(args ...string) string {
+				t.Helper()
+				out, err := captureStdout(func() error { return runMigrate(cfg, args) })
+				if err != nil {
+					t.Fatalf("migrate %s: %v", strings.Join(args, " "), err)
+				}
+				return out
+			}
+			pending := func() int {
+				t.Helper()
+				return strings.Count(migrate("status"), "pending")
+			}
+			migrator, err := migrations.New(d.DB)
+			if err != nil {
+				t.Fatal(err)
+			}
+			latest := int(migrator.Latest())
+
+			for round := 1; round <= 2; round++ {
+				if got := pending(); got != latest {
+					t.Fatalf("round %d: %d migrations are pending before up, want %d", round, got, latest)
+				}
+				if out := migrate("up"); strings.Count(out, "Applied") != latest {
+					t.Fatalf("round %d: up printed\n%s\nwant all %d migrations applied", round, out, latest)
+				}
+				if got := pending(); got != 0 {
+					t.Errorf("round %d: %d migrations are pending after up", round, got)
+				}
+				if out := migrate("up"); !strings.Contains(out, "Database is up to date") {
+					t.Errorf("round %d: up on an up to date database printed %q", round, out)
+				}
+				if !d.DB.Migrator().HasTable("loans") {
+					t.Fatalf("round %d: loans table is missing after up", round)
+				}
+
+				if out := migrate("down"); strings.Count(out, "Reverted") != 1 {
+					t.Errorf("round %d: down printed\n%s\nwant one migration reverted", round, out)
+				}
+				if version, err := migrator.Version(); err != nil || version != int64(latest-1) {
+					t.Errorf("round %d: after down the version is %d (%v), want %d", round, version, err, latest-1)
+				}
+				if out := migrate("down", strconv.Itoa(latest)); strings.Count(out, "Reverted") != latest-1 {
+					t.Errorf("round %d: down %d printed\n%s\nwant the other %d migrations reverted", round, latest, out, latest-1)
+				}
+				if out := migrate("down"); !strings.Contains(out, "No migrations to revert") {
+					t.Errorf("round %d: down on an empty database printed %q", round, out)
+				}
+				if d.DB.Migrator().HasTable("loans") {
+					t.Errorf("round %d: loans table is left after reverting everything", round)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateCreate(t *testing.T) {
+	dir := t.TempDir()
+	for _, dialect := range migrations.Dialects {
+		if err := os.MkdirAll(filepath.Join(dir, migrations.Dir, dialect), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	cfg := config.Default()
+	for _, want := range []string{"0001_add_shelf_notes", "0002_add_shelf_notes"} {
+		out, err := captureStdout(func() error { return runMigrate(cfg, []string{"create", "Add shelf-notes"}) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dialect := range migrations.Dialects {
+			for _, direction := range []string{"up", "down"} {
+				file := filepath.Join(migrations.Dir, dialect, want+"."+direction+".sql")
+				if _, err := os.Stat(file); err != nil || !strings.Contains(out, "Created "+file) {
+					t.Errorf("create did not make %s (%v); it printed\n%s", file, err, out)
+				}
+			}
+		}
+	}
+
+	if _, err := captureStdout(func() error { return runMigrate(cfg, []string{"create", "shelf notes!"}) }); err == nil {
+		t.Error("create accepted a name with punctuation")
+	}
+	if _, err := captureStdout(func() error { return runMigrate(cfg, []string{"create"}) }); err == nil {
+		t.Error("create accepted no name")
+	}
+}
+
+// captureStdout returns what fn prints, as the commands print their
+// results rather than return them.
+func captureStdout(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&out, r)
+		close(done)
+	}()
+	err = fn()
+	os.Stdout = stdout
+	w.Close()
+	<-done
+	return out.String(), err
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// minJWTSecretLength is the shortest JWT_SECRET accepted: 32 bytes, the
+// size of the HMAC-SHA256 key that signs the tokens.
+const minJWTSecretLength = 32
+
+// AuthConfig sets how users sign in and how long their tokens last.
+type AuthConfig struct {
+	JWTSecret string
+	// AccessTokenTTL is how long an access token is accepted. Keep it
+	// short: a revoked session only ends for certain once its access token
+	// expires.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a session lasts without being refreshed.
+	RefreshTokenTTL time.Duration
+	// PasswordResetTTL is how long a password reset link stays valid.
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the page of the client app that takes a reset
+	// token. The token is appended as the "token" query parameter. When it
+	// is empty, the email carries the bare token.
+	PasswordResetURL  string
+	PasswordMinLength int
+	// MFATokenTTL is how long a user has between the password and the
+	// second step of a login.
+	MFATokenTTL time.Duration
+	// MFAEncryptionKey encrypts TOTP secrets in the database: 64 hex
+	// characters, a 32 byte AES-256 key. Changing it locks out everyone
+	// enrolled in two-factor authentication.
+	MFAEncryptionKey string
+	// MFAIssuer is the name authenticator apps show next to the code.
+	MFAIssuer string
+	// MFARequiredRoles lists the roles that must use two-factor
+	// authentication. Users with these roles have to enroll before they
+	// can finish logging in.
+	MFARequiredRoles []string
+}
+
+func loadAuthConfig(e *env) AuthConfig {
+	return AuthConfig{
+		JWTSecret:         e.string("JWT_SECRET", ""),
+		AccessTokenTTL:    e.duration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:   e.duration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		PasswordResetTTL:  e.duration("PASSWORD_RESET_TTL", 30*time.Minute),
+		PasswordResetURL:  e.string("PASSWORD_RESET_URL", ""),
+		PasswordMinLength: e.int("PASSWORD_MIN_LENGTH", 10),
+		MFATokenTTL:       e.duration("MFA_TOKEN_TTL", 5*time.Minute),
+		MFAEncryptionKey:  e.string("MFA_ENCRYPTION_KEY", ""),
+		MFAIssuer:         e.string("MFA_ISSUER", "Perpustakaan"),
+		MFARequiredRoles:  e.list("MFA_REQUIRED_ROLES"),
+	}
+}
+
+func (c AuthConfig) validate() []error {
+	var errs []error
+	if len(c.JWTSecret) < minJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d characters; generate one with `openssl rand -hex 32`", minJWTSecretLength))
+	}
+	if key, err := hex.DecodeString(c.MFAEncryptionKey); err != nil || len(key) != 32 {
+		errs = append(errs, fmt.Errorf("MFA_ENCRYPTION_KEY must be 64 hex characters; generate one with `openssl rand -hex 32`"))
+	}
+	for key, ttl := range map[string]time.Duration{
+		"ACCESS_TOKEN_TTL":   c.AccessTokenTTL,
+		"REFRESH_TOKEN_TTL":  c.RefreshTokenTTL,
+		"PASSWORD_RESET_TTL": c.PasswordResetTTL,
+		"MFA_TOKEN_TTL":      c.MFATokenTTL,
+	} {
+		if ttl <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", key))
+		}
+	}
+	if c.PasswordMinLength < 8 {
+		errs = append(errs, fmt.Errorf("PASSWORD_MIN_LENGTH must be at least 8, got %d", c.PasswordMinLength))
+	}
+	if c.PasswordResetURL != "" {
+		if u, err := url.Parse(c.PasswordResetURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("PASSWORD_RESET_URL must be an absolute URL, got %q", c.PasswordResetURL))
+		}
+	}
+	return errs
+}
+
+// MFARequired reports whether users with the role must use two-factor
+// authentication.
+func (c AuthConfig) MFARequired(role string) bool {
+	for _, required := range c.MFARequiredRoles {
+		if required == role {
+			return true
+		}
+	}
+	return false
+}
+
+// LoginGuardConfig sets how failed logins are throttled. Failures are
+// counted per username and per client IP; a count resets once no failure
+// happened for Window.
+type LoginGuardConfig struct {
+	Store         string
+	MaxFailures   int
+	IPMaxFailures int
+	Window        time.Duration
+	Lockout       time.Duration
+	MaxLockout    time.Duration
+}
+
+func loadLoginGuardConfig(e *env) LoginGuardConfig {
+	return LoginGuardConfig{
+		Store:         e.string("LOGIN_GUARD_STORE", "memory"),
+		MaxFailures:   e.int("LOGIN_MAX_FAILURES", 5),
+		IPMaxFailures: e.int("LOGIN_IP_MAX_FAILURES", 20),
+		Window:        e.duration("LOGIN_FAILURE_WINDOW", time.Hour),
+		Lockout:       e.duration("LOGIN_LOCKOUT", 15*time.Minute),
+		MaxLockout:    e.duration("LOGIN_MAX_LOCKOUT", 24*time.Hour),
+	}
+}
+
+func (c LoginGuardConfig) validate() []error {
+	var errs []error
+	switch c.Store {
+	case "memory", "database", "postgres":
+	default:
+		errs = append(errs, fmt.Errorf("LOGIN_GUARD_STORE must be memory or database, got %q", c.Store))
+	}
+	if c.MaxFailures < 1 || c.IPMaxFailures < 1 {
+		errs = append(errs, fmt.Errorf("LOGIN_MAX_FAILURES and LOGIN_IP_MAX_FAILURES must be at least 1"))
+	}
+	if c.Window <= 0 || c.Lockout <= 0 {
+		errs = append(errs, fmt.Errorf("LOGIN_FAILURE_WINDOW and LOGIN_LOCKOUT must be positive"))
+	}
+	if c.MaxLockout < c.Lockout {
+		errs = append(errs, fmt.Errorf("LOGIN_MAX_LOCKOUT must be at least LOGIN_LOCKOUT"))
+	}
+	return errs
+}
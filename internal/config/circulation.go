@@ -0,0 +1,32 @@
+package config
+
+import "fmt"
+
+// CirculationConfig holds the lending rules that are not part of a
+// circulation policy.
+type CirculationConfig struct {
+	// HoldPickupDays is how long a copy set aside for a hold waits on the
+	// pickup shelf before the hold expires.
+	HoldPickupDays int
+	// FineBlockThreshold is the outstanding fine balance above which a
+	// member may not borrow.
+	FineBlockThreshold int64
+}
+
+func loadCirculationConfig(e *env) CirculationConfig {
+	return CirculationConfig{
+		HoldPickupDays:     e.int("HOLD_PICKUP_DAYS", 3),
+		FineBlockThreshold: int64(e.int("FINE_BLOCK_THRESHOLD", 10000)),
+	}
+}
+
+func (c CirculationConfig) validate() []error {
+	var errs []error
+	if c.HoldPickupDays < 1 {
+		errs = append(errs, fmt.Errorf("HOLD_PICKUP_DAYS must be at least 1, got %d", c.HoldPickupDays))
+	}
+	if c.FineBlockThreshold < 0 {
+		errs = append(errs, fmt.Errorf("FINE_BLOCK_THRESHOLD must not be negative, got %d", c.FineBlockThreshold))
+	}
+	return errs
+}
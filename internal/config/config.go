@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// DefaultEnvFile is the file Load reads settings from unless -config names
+// another.
+const DefaultEnvFile = "config.env"
+
+// Config is every setting the server reads, loaded once at startup and
+// handed to the parts that need it.
+type Config struct {
+	// EnvFile is the file the settings were read from, or empty when there
+	// was none and everything came from the environment.
+	EnvFile string
+
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Auth         AuthConfig
+	LoginGuard   LoginGuardConfig
+	Circulation  CirculationConfig
+	Notification NotificationConfig
+	Scheduler    SchedulerConfig
+}
+
+// Load reads the configuration. Later sources override earlier ones: the
+// defaults, the env file, the process environment and last the flags in
+// args. A missing config.env is not an error, so containers can set
+// everything in the environment; a file named with -config must exist.
+// Load only rejects values it cannot parse; call Validate before serving.
+// It returns the arguments that follow the flags.
+func Load(args []string) (*Config, []string, error) {
+	flags := flag.NewFlagSet("library", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	envFile := flags.String("config", DefaultEnvFile, "env file to read settings from")
+	port := flags.String("port", "", "port to listen on (SERVER_PORT)")
+	driver := flags.String("db-driver", "", "database driver, postgres or sqlite (DB_DRIVER)")
+	path := flags.String("db-path", "", "SQLite database file (DB_PATH)")
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, fmt.Errorf("%w\nflags:\n%s", err, flagUsage(flags))
+	}
+
+	cfg := &Config{}
+	if err := godotenv.Load(*envFile); err == nil {
+		cfg.EnvFile = *envFile
+	} else if !errors.Is(err, fs.ErrNotExist) || isSet(flags, "config") {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", *envFile, err)
+	}
+
+	e := &env{lookup: os.Getenv}
+	cfg.read(e)
+	if len(e.errs) > 0 {
+		return nil, nil, errors.Join(e.errs...)
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Server.Port = *port
+		case "db-driver":
+			cfg.Database.Driver = *driver
+		case "db-path":
+			cfg.Database.Path = *path
+		}
+	})
+
+	return cfg, flags.Args(), nil
+}
+
+// Default is the configuration with every setting at its default, as if
+// the environment were empty. There is no default JWT secret, so it does
+// not pass Validate until one is set.
+func Default() *Config {
+	cfg := &Config{}
+	cfg.read(&env{lookup: func(string) string { return "" }})
+	return cfg
+}
+
+func (c *Config) read(e *env) {
+	c.Server = loadServerConfig(e)
+	c.Database = loadDatabaseConfig(e)
+	c.Auth = loadAuthConfig(e)
+	c.LoginGuard = loadLoginGuardConfig(e)
+	c.Circulation = loadCirculationConfig(e)
+	c.Notification = loadNotificationConfig(e)
+	c.Scheduler = loadSchedulerConfig(e)
+}
+
+// Validate reports every setting that is missing or out of range.
+func (c *Config) Validate() error {
+	errs := c.Server.validate()
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, c.Auth.validate()...)
+	errs = append(errs, c.LoginGuard.validate()...)
+	errs = append(errs, c.Circulation.validate()...)
+	errs = append(errs, c.Notification.validate()...)
+	errs = append(errs, c.Scheduler.validate()...)
+	return errors.Join(errs...)
+}
+
+func isSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+func flagUsage(flags *flag.FlagSet) string {
+	var usage strings.Builder
+	flags.SetOutput(&usage)
+	flags.PrintDefaults()
+	flags.SetOutput(io.Discard)
+	return usage.String()
+}
+
+// env reads settings from the environment. A value that cannot be parsed
+// is recorded rather than replaced by the default, so a typo stops the
+// server instead of being silently ignored.
+type env struct {
+	lookup func(key string) string
+	errs   []error
+}
+
+func (e *env) string(key, fallback string) string {
+	if value := e.lookup(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (e *env) int(key string, fallback int) int {
+	value := e.lookup(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be a whole number, got %q", key, value))
+		return fallback
+	}
+	return n
+}
+
+func (e *env) duration(key string, fallback time.Duration) time.Duration {
+	value := e.lookup(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be a duration such as 15m or 24h, got %q", key, value))
+		return fallback
+	}
+	return d
+}
+
+func (e *env) bool(key string, fallback bool) bool {
+	value := e.lookup(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be true or false, got %q", key, value))
+		return fallback
+	}
+	return b
+}
+
+// list splits a comma-separated value, dropping empty items.
+func (e *env) list(key string) []string {
+	var items []string
+	for _, item := range strings.Split(e.lookup(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
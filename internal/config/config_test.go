@@ -0,0 +1,204 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"library-management-system/internal/config"
+)
+
+// setenv sets the environment for the rest of the test. An empty value
+// unsets the variable, so neither the runner's environment nor an empty
+// value hides the env file.
+func setenv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for key, value := range env {
+		t.Setenv(key, value)
+		if value == "" {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadPrecedence sets each setting in a different set of sources:
+// the flags win over the environment, which wins over the env file, which
+// wins over the defaults.
+func TestLoadPrecedence(t *testing.T) {
+	file := writeEnvFile(t, strings.Join([]string{
+		"SERVER_PORT=9001",
+		"DB_DRIVER=postgres",
+		"DB_PATH=file.db",
+		"DB_NAME=file_db",
+		"SCHEDULER_INTERVAL=30m",
+	}, "\n"))
+	setenv(t, map[string]string{
+		"SERVER_PORT":        "9002",
+		"DB_DRIVER":          "sqlite",
+		"DB_PATH":            "env.db",
+		"DB_NAME":            "",
+		"DB_HOST":            "",
+		"SCHEDULER_INTERVAL": "",
+		"HOLD_PICKUP_DAYS":   "5",
+	})
+
+	cfg, rest, err := config.Load([]string{"-config", file, "-port", "9003", "-db-path", "flag.db", "up", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		setting   string
+		got, want interface{}
+	}{
+		{"SERVER_PORT from the flag", cfg.Server.Port, "9003"},
+		{"DB_PATH from the flag", cfg.Database.Path, "flag.db"},
+		{"DB_DRIVER from the environment", cfg.Database.Driver, "sqlite"},
+		{"HOLD_PICKUP_DAYS from the environment", cfg.Circulation.HoldPickupDays, 5},
+		{"DB_NAME from the file", cfg.Database.Name, "file_db"},
+		{"SCHEDULER_INTERVAL from the file", cfg.Scheduler.Interval, 30 * time.Minute},
+		{"DB_HOST by default", cfg.Database.Host, "localhost"},
+		{"NOTIFY_MAX_ATTEMPTS by default", cfg.Notification.MaxAttempts, 5},
+		{"env file", cfg.EnvFile, file},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s is %v, want %v", c.setting, c.got, c.want)
+		}
+	}
+	if strings.Join(rest, " ") != "up 2" {
+		t.Errorf("arguments after the flags are %q, want up 2", rest)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	setenv(t, map[string]string{"SERVER_READ_TIMEOUT": "", "SCHEDULER_ENABLED": ""})
+
+	t.Run("missing default env file", func(t *testing.T) {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chdir(wd) })
+
+		cfg, _, err := config.Load(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.EnvFile != "" {
+			t.Errorf("env file is %q without one", cfg.EnvFile)
+		}
+	})
+
+	for _, c := range []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"missing named env file", []string{"-config", filepath.Join(t.TempDir(), "none.env")}, nil, "failed to read"},
+		{"unknown flag", []string{"-verbose"}, nil, "flag provided but not defined"},
+		{"bad duration", nil, map[string]string{"SERVER_READ_TIMEOUT": "soon"}, "SERVER_READ_TIMEOUT must be a duration"},
+		{"bad number", nil, map[string]string{"HOLD_PICKUP_DAYS": "three"}, "HOLD_PICKUP_DAYS must be a whole number"},
+		{"bad bool", nil, map[string]string{"SCHEDULER_ENABLED": "sometimes"}, "SCHEDULER_ENABLED must be true or false"},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			setenv(t, c.env)
+			args := c.args
+			if args == nil {
+				args = []string{"-config", writeEnvFile(t, "")}
+			}
+			if _, _, err := config.Load(args); err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("Load returned %v, want an error containing %q", err, c.want)
+			}
+		})
+	}
+}
+
+// valid is a configuration that passes Validate.
+func valid() *config.Config {
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = strings.Repeat("s", 32)
+	cfg.Auth.MFAEncryptionKey = strings.Repeat("ab", 32)
+	cfg.Database.User = "library"
+	cfg.Database.Name = "library_db"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("valid configuration fails with %v", err)
+	}
+	if err := config.Default().Validate(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET") {
+		t.Errorf("defaults without a JWT secret fail with %v", err)
+	}
+
+	for _, c := range []struct {
+		name   string
+		change func(cfg *config.Config)
+		want   string
+	}{
+		{"port", func(cfg *config.Config) { cfg.Server.Port = "80800" }, "SERVER_PORT must be a port number"},
+		{"timeout", func(cfg *config.Config) { cfg.Server.IdleTimeout = 0 }, "SERVER_IDLE_TIMEOUT must be positive"},
+		{"header bytes", func(cfg *config.Config) { cfg.Server.MaxHeaderBytes = 100 }, "SERVER_MAX_HEADER_BYTES must be at least 1024"},
+		{"body bytes", func(cfg *config.Config) { cfg.Server.MaxBodyBytes = 100 }, "SERVER_MAX_BODY_BYTES must be at least 1024"},
+		{"half TLS", func(cfg *config.Config) { cfg.Server.TLSCertFile = "cert.pem" }, "TLS_CERT_FILE and TLS_KEY_FILE must be set together"},
+		{"proxy", func(cfg *config.Config) { cfg.Server.TrustedProxies = []string{"proxy.local"} }, "TRUSTED_PROXIES must list IP addresses"},
+		{"metrics token", func(cfg *config.Config) { cfg.Server.MetricsToken = "short" }, "METRICS_TOKEN must be at least 32 characters"},
+		{"postgres", func(cfg *config.Config) { cfg.Database.Name = "" }, "DB_USER and DB_NAME are required"},
+		{"sqlite", func(cfg *config.Config) { cfg.Database.Driver, cfg.Database.Path = config.DriverSQLite, "" }, "DB_PATH is required"},
+		{"driver", func(cfg *config.Config) { cfg.Database.Driver = "mysql" }, "DB_DRIVER must be postgres or sqlite"},
+		{"pool", func(cfg *config.Config) { cfg.Database.MaxOpenConns = -1 }, "DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative"},
+		{"idle pool", func(cfg *config.Config) { cfg.Database.MaxIdleConns = 30 }, "DB_MAX_IDLE_CONNS (30) must not be more than DB_MAX_OPEN_CONNS (25)"},
+		{"lifetime", func(cfg *config.Config) { cfg.Database.ConnMaxIdleTime = -time.Second }, "DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME must not be negative"},
+		{"JWT secret", func(cfg *config.Config) { cfg.Auth.JWTSecret = "secret" }, "JWT_SECRET must be at least 32 characters"},
+		{"MFA key", func(cfg *config.Config) { cfg.Auth.MFAEncryptionKey = strings.Repeat("s", 64) }, "MFA_ENCRYPTION_KEY must be 64 hex characters"},
+		{"token TTL", func(cfg *config.Config) { cfg.Auth.MFATokenTTL = 0 }, "MFA_TOKEN_TTL must be positive"},
+		{"password length", func(cfg *config.Config) { cfg.Auth.PasswordMinLength = 6 }, "PASSWORD_MIN_LENGTH must be at least 8"},
+		{"reset URL", func(cfg *config.Config) { cfg.Auth.PasswordResetURL = "/reset" }, "PASSWORD_RESET_URL must be an absolute URL"},
+		{"guard store", func(cfg *config.Config) { cfg.LoginGuard.Store = "redis" }, "LOGIN_GUARD_STORE must be memory or database"},
+		{"guard failures", func(cfg *config.Config) { cfg.LoginGuard.IPMaxFailures = 0 }, "LOGIN_MAX_FAILURES and LOGIN_IP_MAX_FAILURES must be at least 1"},
+		{"guard window", func(cfg *config.Config) { cfg.LoginGuard.Window = 0 }, "LOGIN_FAILURE_WINDOW and LOGIN_LOCKOUT must be positive"},
+		{"guard lockout", func(cfg *config.Config) { cfg.LoginGuard.MaxLockout = time.Minute }, "LOGIN_MAX_LOCKOUT must be at least LOGIN_LOCKOUT"},
+		{"pickup days", func(cfg *config.Config) { cfg.Circulation.HoldPickupDays = 0 }, "HOLD_PICKUP_DAYS must be at least 1"},
+		{"fine threshold", func(cfg *config.Config) { cfg.Circulation.FineBlockThreshold = -1 }, "FINE_BLOCK_THRESHOLD must not be negative"},
+		{"smtp", func(cfg *config.Config) { cfg.Notification.Sender = "smtp" }, "SMTP_HOST and SMTP_FROM are required"},
+		{"sender", func(cfg *config.Config) { cfg.Notification.Sender = "sms" }, "NOTIFY_SENDER must be log or smtp"},
+		{"due soon", func(cfg *config.Config) { cfg.Notification.DueSoonDays = -1 }, "NOTIFY_DUE_SOON_DAYS must not be negative"},
+		{"attempts", func(cfg *config.Config) { cfg.Notification.MaxAttempts = 0 }, "NOTIFY_MAX_ATTEMPTS must be at least 1"},
+		{"interval", func(cfg *config.Config) { cfg.Scheduler.Interval = 0 }, "SCHEDULER_INTERVAL must be positive"},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			cfg := valid()
+			c.change(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("Validate returned %v, want an error containing %q", err, c.want)
+			}
+			if strings.Contains(err.Error(), "\n") {
+				t.Errorf("one bad setting gave more than one error: %v", err)
+			}
+		})
+	}
+
+	cfg := valid()
+	cfg.Server.Port = "0"
+	cfg.Scheduler.Interval = 0
+	if err := cfg.Validate(); err == nil || strings.Count(err.Error(), "\n") != 1 {
+		t.Errorf("two bad settings gave %v, want both reported", err)
+	}
+}
@@ -1,33 +1,149 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"net/url"
+	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+// Database drivers DB_DRIVER accepts.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// DatabaseConfig says which database to use and how many connections to
+// keep to it. Postgres is reached through Host, Port, User, Password and
+// Name; SQLite keeps everything in the file at Path.
+type DatabaseConfig struct {
+	Driver   string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+	TimeZone string
+	Path     string
+
+	// MaxOpenConns caps the connections open at once; 0 means no cap.
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime and ConnMaxIdleTime retire connections that are too
+	// old or unused for too long; 0 keeps them forever.
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+func loadDatabaseConfig(e *env) DatabaseConfig {
+	return DatabaseConfig{
+		Driver:          e.string("DB_DRIVER", DriverPostgres),
+		Host:            e.string("DB_HOST", "localhost"),
+		Port:            e.string("DB_PORT", "5432"),
+		User:            e.string("DB_USER", ""),
+		Password:        e.string("DB_PASSWORD", ""),
+		Name:            e.string("DB_NAME", ""),
+		SSLMode:         e.string("DB_SSLMODE", "disable"),
+		TimeZone:        e.string("DB_TIMEZONE", "Asia/Jakarta"),
+		Path:            e.string("DB_PATH", "library.db"),
+		MaxOpenConns:    e.int("DB_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    e.int("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: e.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: e.duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+	}
+}
+
+// Validate reports the database settings that are missing or out of
+// range. It is all `migrate` needs checked.
+func (c DatabaseConfig) Validate() error {
+	var errs []error
+	switch c.Driver {
+	case DriverPostgres:
+		if c.User == "" || c.Name == "" {
+			errs = append(errs, errors.New("DB_USER and DB_NAME are required for postgres"))
+		}
+	case DriverSQLite:
+		if c.Path == "" {
+			errs = append(errs, errors.New("DB_PATH is required for sqlite"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("DB_DRIVER must be %s or %s, got %q", DriverPostgres, DriverSQLite, c.Driver))
+	}
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative"))
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS (%d) must not be more than DB_MAX_OPEN_CONNS (%d)", c.MaxIdleConns, c.MaxOpenConns))
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// DSN is the connection string for the configured driver.
+func (c DatabaseConfig) DSN() string {
+	if c.Driver == DriverSQLite {
+		return SQLiteDSN(c.Path)
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+		c.Host, c.User, c.Password, c.Name, c.Port, c.SSLMode, c.TimeZone)
+}
+
+// InitDB connects to the configured database and sizes its connection
+// pool.
+func InitDB(cfg DatabaseConfig) (*gorm.DB, error) {
+	db, err := OpenDB(cfg.Driver, cfg.DSN(), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-func InitDB() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Jakarta",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
+	return db, nil
+}
+
+// OpenDB connects to a database with the given driver and DSN.
+func OpenDB(driver, dsn string, cfg *gorm.Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverPostgres:
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(dialector, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	DB = db
 	return db, nil
 }
 
-func GetDB() *gorm.DB {
-	return DB
+// SQLiteDSN opens the database file at path with foreign keys enforced.
+// Transactions take the write lock when they begin and wait up to five
+// seconds for it, so concurrent requests queue up instead of failing with
+// "database is locked"; WAL lets reads go on meanwhile.
+func SQLiteDSN(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Set("_txlock", "immediate")
+	return "file:" + path + "?" + params.Encode()
 }
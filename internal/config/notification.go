@@ -0,0 +1,64 @@
+package config
+
+import "fmt"
+
+// NotificationConfig sets how member notifications are delivered.
+type NotificationConfig struct {
+	// Sender is "smtp" or "log" (the default), which writes messages to
+	// LogFile or, when that is empty, the server log.
+	Sender  string
+	LogFile string
+	// DueSoonDays is how many days before the due date members are
+	// reminded.
+	DueSoonDays int
+	// MaxAttempts is how often a message is tried before it is marked
+	// failed.
+	MaxAttempts int
+	SMTP        SMTPConfig
+}
+
+// SMTPConfig is read from SMTP_HOST, SMTP_PORT, SMTP_USERNAME,
+// SMTP_PASSWORD and SMTP_FROM.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func loadNotificationConfig(e *env) NotificationConfig {
+	return NotificationConfig{
+		Sender:      e.string("NOTIFY_SENDER", "log"),
+		LogFile:     e.string("NOTIFY_LOG_FILE", ""),
+		DueSoonDays: e.int("NOTIFY_DUE_SOON_DAYS", 2),
+		MaxAttempts: e.int("NOTIFY_MAX_ATTEMPTS", 5),
+		SMTP: SMTPConfig{
+			Host:     e.string("SMTP_HOST", ""),
+			Port:     e.string("SMTP_PORT", "587"),
+			Username: e.string("SMTP_USERNAME", ""),
+			Password: e.string("SMTP_PASSWORD", ""),
+			From:     e.string("SMTP_FROM", ""),
+		},
+	}
+}
+
+func (c NotificationConfig) validate() []error {
+	var errs []error
+	switch c.Sender {
+	case "log":
+	case "smtp":
+		if c.SMTP.Host == "" || c.SMTP.From == "" {
+			errs = append(errs, fmt.Errorf("SMTP_HOST and SMTP_FROM are required for the smtp sender"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("NOTIFY_SENDER must be log or smtp, got %q", c.Sender))
+	}
+	if c.DueSoonDays < 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_DUE_SOON_DAYS must not be negative, got %d", c.DueSoonDays))
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("NOTIFY_MAX_ATTEMPTS must be at least 1, got %d", c.MaxAttempts))
+	}
+	return errs
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// SchedulerConfig controls the background jobs.
+type SchedulerConfig struct {
+	// Enabled is false when SCHEDULER_ENABLED=false, for replicas that
+	// should only serve requests.
+	Enabled bool
+	// Interval is how often the background jobs run.
+	Interval time.Duration
+}
+
+func loadSchedulerConfig(e *env) SchedulerConfig {
+	return SchedulerConfig{
+		Enabled:  e.bool("SCHEDULER_ENABLED", true),
+		Interval: e.duration("SCHEDULER_INTERVAL", 15*time.Minute),
+	}
+}
+
+func (c SchedulerConfig) validate() []error {
+	if c.Interval <= 0 {
+		return []error{errors.New("SCHEDULER_INTERVAL must be positive")}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// minMetricsTokenLength is the shortest METRICS_TOKEN accepted, as long
+// as the shortest JWT_SECRET.
+const minMetricsTokenLength = minJWTSecretLength
+
+// ServerConfig sets up the HTTP listener.
+type ServerConfig struct {
+	Port string
+
+	// ReadHeaderTimeout and ReadTimeout bound how long a client may take to
+	// send the headers and the whole request, so slow clients cannot hold
+	// connections open.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	// IdleTimeout closes keep-alive connections that sit unused.
+	IdleTimeout time.Duration
+	// ShutdownTimeout is how long in-flight requests get to finish once the
+	// server is told to stop.
+	ShutdownTimeout time.Duration
+	MaxHeaderBytes  int
+	// MaxBodyBytes caps the size of request bodies.
+	MaxBodyBytes int64
+
+	// TLSCertFile and TLSKeyFile turn on HTTPS when both are set. The files
+	// are read again when they change, so renewed certificates are picked
+	// up without a restart.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// TrustedProxies lists the addresses or CIDR ranges of the reverse
+	// proxies in front of the server. Only requests from them may name the
+	// client's IP in X-Forwarded-For or X-Real-IP; by default no proxy is
+	// trusted and the client IP is the address of the connection.
+	TrustedProxies []string
+
+	// MetricsToken is the bearer token a scraper must send to read
+	// /metrics. Without one the endpoint is not served at all.
+	MetricsToken string
+}
+
+func loadServerConfig(e *env) ServerConfig {
+	return ServerConfig{
+		Port:              e.string("SERVER_PORT", "8080"),
+		ReadHeaderTimeout: e.duration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       e.duration("SERVER_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:      e.duration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       e.duration("SERVER_IDLE_TIMEOUT", 2*time.Minute),
+		ShutdownTimeout:   e.duration("SERVER_SHUTDOWN_TIMEOUT", 20*time.Second),
+		MaxHeaderBytes:    e.int("SERVER_MAX_HEADER_BYTES", 1<<20),
+		MaxBodyBytes:      int64(e.int("SERVER_MAX_BODY_BYTES", 1<<20)),
+		TLSCertFile:       e.string("TLS_CERT_FILE", ""),
+		TLSKeyFile:        e.string("TLS_KEY_FILE", ""),
+		TrustedProxies:    e.list("TRUSTED_PROXIES"),
+		MetricsToken:      e.string("METRICS_TOKEN", ""),
+	}
+}
+
+// TLS reports whether the server should serve HTTPS.
+func (c ServerConfig) TLS() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+func (c ServerConfig) validate() []error {
+	var errs []error
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("SERVER_PORT must be a port number, got %q", c.Port))
+	}
+	for _, timeout := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"SERVER_READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"SERVER_READ_TIMEOUT", c.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+	} {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", timeout.key))
+		}
+	}
+	if c.MaxHeaderBytes < 1024 {
+		errs = append(errs, fmt.Errorf("SERVER_MAX_HEADER_BYTES must be at least 1024, got %d", c.MaxHeaderBytes))
+	}
+	if c.MaxBodyBytes < 1024 {
+		errs = append(errs, fmt.Errorf("SERVER_MAX_BODY_BYTES must be at least 1024, got %d", c.MaxBodyBytes))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs = append(errs, fmt.Errorf("TRUSTED_PROXIES must list IP addresses or CIDR ranges, got %q", proxy))
+		}
+	}
+	if c.MetricsToken != "" && len(c.MetricsToken) < minMetricsTokenLength {
+		errs = append(errs, fmt.Errorf("METRICS_TOKEN must be at least %d characters; generate one with `openssl rand -hex 32`", minMetricsTokenLength))
+	}
+	return errs
+}
@@ -0,0 +1,101 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"library-management-system/internal/models"
+)
+
+// testAuditTrail changes a book as two staff members and looks the
+// changes up by actor, action, entity and time. The update keeps the book
+// as it was before and after.
+func testAuditTrail(t *testing.T, a *api) {
+	librarian := a.login(LibrarianUsername, StaffPassword)
+	admin := a.login(AdminUsername, StaffPassword)
+	staff := map[string]uint{}
+	for _, username := range []string{LibrarianUsername, AdminUsername} {
+		user, err := a.Stores.Users.GetByUsername(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		staff[username] = user.ID
+	}
+
+	// Audit events are stamped by the database's clock, not the fake one.
+	before := time.Now().Add(-time.Second).UTC()
+	book := a.createBook(librarian, "Siti Nurbaya", "Marah Rusli", "978-979-407-167-4", 1)
+	path := fmt.Sprintf("/api/books/%d", book.ID)
+	a.call(http.MethodPut, path, librarian, map[string]interface{}{"title": "Siti Nurbaya: Kasih Tak Sampai"}, http.StatusOK, nil)
+	a.call(http.MethodDelete, path, admin, nil, http.StatusOK, nil)
+	after := time.Now().Add(time.Second).UTC()
+
+	events := func(filter url.Values) []models.AuditEvent {
+		t.Helper()
+		filter.Set("entity_type", models.AuditEntityBook)
+		filter.Set("entity_id", fmt.Sprint(book.ID))
+		var events []models.AuditEvent
+		a.call(http.MethodGet, "/api/audit-events?"+filter.Encode(), admin, nil, http.StatusOK, &events)
+		return events
+	}
+	actions := func(events []models.AuditEvent) []string {
+		var actions []string
+		for _, event := range events {
+			actions = append(actions, event.Action)
+		}
+		return actions
+	}
+	expect := func(name string, filter url.Values, want ...string) {
+		t.Helper()
+		got := actions(events(filter))
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: found %v, want %v", name, got, want)
+		}
+	}
+
+	expect("entity", url.Values{}, models.AuditBookDelete, models.AuditBookUpdate, models.AuditBookCreate)
+	expect("librarian", url.Values{"actor_id": {fmt.Sprint(staff[LibrarianUsername])}}, models.AuditBookUpdate, models.AuditBookCreate)
+	expect("admin", url.Values{"actor_id": {fmt.Sprint(staff[AdminUsername])}}, models.AuditBookDelete)
+	expect("action", url.Values{"action": {models.AuditBookUpdate}}, models.AuditBookUpdate)
+	expect("time range", url.Values{"from": {before.Format(time.RFC3339)}, "to": {after.Format(time.RFC3339)}},
+		models.AuditBookDelete, models.AuditBookUpdate, models.AuditBookCreate)
+	expect("before the changes", url.Values{"to": {before.Format(time.RFC3339)}})
+	expect("after the changes", url.Values{"from": {after.Format(time.RFC3339)}})
+	a.call(http.MethodGet, "/api/audit-events?from=yesterday", admin, nil, http.StatusBadRequest, nil)
+	a.call(http.MethodGet, "/api/audit-events", librarian, nil, http.StatusForbidden, nil)
+
+	update := events(url.Values{"action": {models.AuditBookUpdate}})[0]
+	var was, is models.Book
+	var changes map[string]struct{ From, To interface{} }
+	for target, data := range map[interface{}]string{&was: update.Before, &is: update.After, &changes: update.Changes} {
+		if err := json.Unmarshal([]byte(data), target); err != nil {
+			t.Fatalf("update event holds %q: %v", data, err)
+		}
+	}
+	if was.Title != "Siti Nurbaya" || is.Title != "Siti Nurbaya: Kasih Tak Sampai" || is.ISBN != book.ISBN {
+		t.Errorf("update event has the book as %q before and %q after", was.Title, is.Title)
+	}
+	if len(changes) != 1 || changes["title"].From != "Siti Nurbaya" {
+		t.Errorf("update event has changes %+v, want only the title", changes)
+	}
+	if update.RequestID == "" || update.ActorID == nil || *update.ActorID != staff[LibrarianUsername] {
+		t.Errorf("update event was made by %v in request %q", update.ActorID, update.RequestID)
+	}
+
+	for _, event := range events(url.Values{}) {
+		switch event.Action {
+		case models.AuditBookCreate:
+			if event.Before != "" || event.After == "" {
+				t.Errorf("create event has before %q and after %q", event.Before, event.After)
+			}
+		case models.AuditBookDelete:
+			if event.Before == "" || event.After != "" {
+				t.Errorf("delete event has before %q and after %q", event.Before, event.After)
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package e2e
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"library-management-system/internal/handlers"
+	"library-management-system/internal/models"
+	"library-management-system/internal/utils"
+)
+
+func testRegisterAndLogin(t *testing.T, a *api) {
+	user := a.register("reader_one", "BorrowMore42")
+	if user.Role != models.RoleMember {
+		t.Errorf("registered user has role %q, want %q", user.Role, models.RoleMember)
+	}
+	register := map[string]string{"username": "reader_one", "email": "reader_one@e2e.test", "password": "BorrowMore42"}
+	a.call(http.MethodPost, "/api/auth/register", "", register, http.StatusConflict, nil)
+
+	wrong := map[string]string{"username": "reader_one", "password": "NotMyPassword1"}
+	a.call(http.MethodPost, "/api/auth/login", "", wrong, http.StatusUnauthorized, nil)
+	token := a.login("reader_one", "BorrowMore42")
+
+	a.call(http.MethodGet, "/api/books/", "", nil, http.StatusUnauthorized, nil)
+	a.call(http.MethodGet, "/api/books/", token, nil, http.StatusOK, nil)
+	book := map[string]interface{}{"title": "Forbidden", "author": "Nobody", "isbn": "978-0-00-000000-0"}
+	a.call(http.MethodPost, "/api/books/", token, book, http.StatusForbidden, nil)
+}
+
+// testRefreshRotation refreshes a session twice, then presents the first
+// refresh token again. Its reuse means someone else holds a copy, so the
+// whole session ends, including the newest token.
+func testRefreshRotation(t *testing.T, a *api) {
+	a.register("reader_refresh", "BorrowMore42")
+	var login handlers.LoginResponse
+	a.call(http.MethodPost, "/api/auth/login", "", map[string]string{"username": "reader_refresh", "password": "BorrowMore42"}, http.StatusOK, &login)
+
+	refresh := func(token string, code int) handlers.TokenResponse {
+		t.Helper()
+		var tokens handlers.TokenResponse
+		a.call(http.MethodPost, "/api/auth/refresh", "", map[string]string{"refresh_token": token}, code, dataOf(code, &tokens))
+		return tokens
+	}
+
+	first := refresh(login.RefreshToken, http.StatusOK)
+	if first.Token == "" || first.RefreshToken == "" || first.RefreshToken == login.RefreshToken {
+		t.Fatalf("refresh did not return a new pair: %+v", first)
+	}
+	a.call(http.MethodGet, "/api/books/", first.Token, nil, http.StatusOK, nil)
+	second := refresh(first.RefreshToken, http.StatusOK)
+
+	refresh(login.RefreshToken, http.StatusUnauthorized)
+	refresh(second.RefreshToken, http.StatusUnauthorized)
+	refresh("not-a-refresh-token", http.StatusUnauthorized)
+
+	a.login("reader_refresh", "BorrowMore42")
+}
+
+// testMFA enrolls a reader in two-factor authentication, then logs in
+// with a code from the authenticator and with a recovery code. Neither
+// can be used twice. The secret is only stored encrypted, and one stored
+// in the clear before that still works and is encrypted at its next use.
+func testMFA(t *testing.T, a *api) {
+	a.register("reader_mfa", "BorrowMore42")
+	token := a.login("reader_mfa", "BorrowMore42")
+
+	a.call(http.MethodPost, "/api/auth/2fa/confirm", token, map[string]string{"code": "000000"}, http.StatusBadRequest, nil)
+	var enroll handlers.MFAEnrollResponse
+	a.call(http.MethodPost, "/api/auth/2fa/enroll", token, nil, http.StatusOK, &enroll)
+	if enroll.Secret == "" || !strings.HasPrefix(enroll.OTPAuthURI, "otpauth://totp/") {
+		t.Fatalf("enrollment returned secret %q and URI %q", enroll.Secret, enroll.OTPAuthURI)
+	}
+	expectSealed := func() {
+		t.Helper()
+		user, err := a.Stores.Users.GetByUsername("reader_mfa")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !utils.IsSealed(user.TOTPSecret) || strings.Contains(user.TOTPSecret, enroll.Secret) {
+			t.Errorf("TOTP secret is stored as %q", user.TOTPSecret)
+		}
+	}
+	expectSealed()
+
+	// Each code uses up its time step and every step before it, so the
+	// confirmation takes the previous step, still inside the allowed
+	// drift, and leaves the current one for the login.
+	now := time.Now()
+	var confirm handlers.MFAConfirmResponse
+	a.call(http.MethodPost, "/api/auth/2fa/confirm", token, map[string]string{"code": totp(t, enroll.Secret, now.Add(-30*time.Second))}, http.StatusOK, &confirm)
+	if confirm.Token == "" || len(confirm.RecoveryCodes) == 0 {
+		t.Fatalf("confirmation returned %d recovery codes and token %q", len(confirm.RecoveryCodes), confirm.Token)
+	}
+	a.call(http.MethodGet, "/api/books/", confirm.Token, nil, http.StatusOK, nil)
+
+	challenge := func() string {
+		t.Helper()
+		var challenge handlers.MFAChallengeResponse
+		a.call(http.MethodPost, "/api/auth/login", "", map[string]string{"username": "reader_mfa", "password": "BorrowMore42"}, http.StatusOK, &challenge)
+		if !challenge.MFARequired || challenge.EnrollmentRequired || challenge.MFAToken == "" {
+			t.Fatalf("login with two-factor enabled returned %+v", challenge)
+		}
+		return challenge.MFAToken
+	}
+	verify := func(mfaToken string, body map[string]string, code int) string {
+		t.Helper()
+		body["mfa_token"] = mfaToken
+		var login handlers.LoginResponse
+		a.call(http.MethodPost, "/api/auth/2fa/verify", "", body, code, dataOf(code, &login))
+		return login.Token
+	}
+
+	mfaToken := challenge()
+	a.call(http.MethodGet, "/api/books/", mfaToken, nil, http.StatusUnauthorized, nil)
+	code := totp(t, enroll.Secret, now)
+	access := verify(mfaToken, map[string]string{"code": code}, http.StatusOK)
+	a.call(http.MethodGet, "/api/books/", access, nil, http.StatusOK, nil)
+	verify(challenge(), map[string]string{"code": code}, http.StatusUnauthorized)
+
+	recovery := confirm.RecoveryCodes[0]
+	verify(challenge(), map[string]string{"recovery_code": recovery}, http.StatusOK)
+	verify(challenge(), map[string]string{"recovery_code": recovery}, http.StatusUnauthorized)
+
+	err := a.DB.Model(&models.User{}).Where("username = ?", "reader_mfa").Update("totp_secret", enroll.Secret).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify(challenge(), map[string]string{"code": totp(t, enroll.Secret, now.Add(30*time.Second))}, http.StatusOK)
+	expectSealed()
+}
+
+// totp computes the RFC 6238 code for secret at at, the way an
+// authenticator app would.
+func totp(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// testLoginUnlock has an admin lift the throttling of a reader's failed
+// logins: unlocking the account is not enough while the reader's address
+// is throttled too, and unlocking both lets the reader straight back in.
+func testLoginUnlock(t *testing.T, a *api) {
+	user := a.register("reader_locked", "BorrowMore42")
+	admin := a.login(AdminUsername, StaffPassword)
+	ip, _, err := net.SplitHostPort(a.RemoteAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := map[string]string{"username": "reader_locked", "password": "NotMyPassword1"}
+	for i := 0; i < 3; i++ {
+		a.call(http.MethodPost, "/api/auth/login", "", wrong, http.StatusUnauthorized, nil)
+	}
+	right := map[string]string{"username": "reader_locked", "password": "BorrowMore42"}
+	a.call(http.MethodPost, "/api/auth/login", "", right, http.StatusTooManyRequests, nil)
+
+	a.call(http.MethodPost, fmt.Sprintf("/api/users/%d/unlock", user.ID), admin, nil, http.StatusOK, nil)
+	a.call(http.MethodPost, "/api/auth/login", "", right, http.StatusTooManyRequests, nil)
+	a.call(http.MethodPost, "/api/users/unlock-ip", admin, map[string]string{"ip": "not-an-ip"}, http.StatusBadRequest, nil)
+	a.call(http.MethodPost, "/api/users/unlock-ip", admin, map[string]string{"ip": ip}, http.StatusOK, nil)
+	a.login("reader_locked", "BorrowMore42")
+
+	reader := a.login("reader_locked", "BorrowMore42")
+	a.call(http.MethodPost, fmt.Sprintf("/api/users/%d/unlock", user.ID), reader, nil, http.StatusForbidden, nil)
+}
@@ -0,0 +1,87 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"library-management-system/internal/models"
+)
+
+func testBookCRUD(t *testing.T, a *api) {
+	librarian := a.login(LibrarianUsername, StaffPassword)
+	admin := a.login(AdminUsername, StaffPassword)
+
+	create := map[string]interface{}{
+		"title":    "Laskar Pelangi",
+		"author":   "Andrea Hirata",
+		"isbn":     "978-979-3062-79-1",
+		"year":     2005,
+		"category": "Fiction",
+		"stock":    2,
+	}
+	var book models.Book
+	a.call(http.MethodPost, "/api/books/", librarian, create, http.StatusOK, &book)
+	if book.Stock != 2 || book.Available != 2 {
+		t.Errorf("new book has stock %d and available %d, want 2 and 2", book.Stock, book.Available)
+	}
+	a.call(http.MethodPost, "/api/books/", librarian, create, http.StatusConflict, nil)
+
+	path := fmt.Sprintf("/api/books/%d", book.ID)
+	a.call(http.MethodPut, path, librarian, map[string]interface{}{"title": "Laskar Pelangi (Edisi Baru)"}, http.StatusOK, nil)
+	var fetched models.Book
+	a.call(http.MethodGet, path, librarian, nil, http.StatusOK, &fetched)
+	if fetched.Title != "Laskar Pelangi (Edisi Baru)" || fetched.Stock != 2 {
+		t.Errorf("updated book is %q with stock %d", fetched.Title, fetched.Stock)
+	}
+
+	var listed []models.Book
+	a.call(http.MethodGet, "/api/books/?author=hirata", librarian, nil, http.StatusOK, &listed)
+	if !containsBook(listed, book.ID) {
+		t.Errorf("book %d is missing from the author filter", book.ID)
+	}
+
+	var copies []models.BookCopy
+	a.call(http.MethodGet, path+"/copies", librarian, nil, http.StatusOK, &copies)
+	if len(copies) != 2 {
+		t.Errorf("book has %d copies, want 2", len(copies))
+	}
+
+	a.call(http.MethodDelete, path, librarian, nil, http.StatusForbidden, nil)
+	a.call(http.MethodDelete, path, admin, nil, http.StatusOK, nil)
+	a.call(http.MethodGet, path, librarian, nil, http.StatusNotFound, nil)
+}
+
+func testMemberCRUD(t *testing.T, a *api) {
+	librarian := a.login(LibrarianUsername, StaffPassword)
+	admin := a.login(AdminUsername, StaffPassword)
+
+	member := a.createMember(librarian, "Siti Rahma", "siti.rahma@e2e.test")
+	if member.MemberCode == "" || member.Status != "active" {
+		t.Errorf("new member has code %q and status %q", member.MemberCode, member.Status)
+	}
+	create := map[string]interface{}{"name": "Siti Rahma", "email": "siti.rahma@e2e.test"}
+	a.call(http.MethodPost, "/api/members/", librarian, create, http.StatusConflict, nil)
+
+	path := fmt.Sprintf("/api/members/%d", member.ID)
+	a.call(http.MethodPut, path, librarian, map[string]interface{}{"phone": "081234567890"}, http.StatusOK, nil)
+	var fetched models.Member
+	a.call(http.MethodGet, path, librarian, nil, http.StatusOK, &fetched)
+	if fetched.Phone != "081234567890" || fetched.Name != "Siti Rahma" {
+		t.Errorf("updated member is %q with phone %q", fetched.Name, fetched.Phone)
+	}
+
+	var listed []models.Member
+	a.call(http.MethodGet, "/api/members/?status=active&per_page=100", librarian, nil, http.StatusOK, &listed)
+	found := false
+	for _, m := range listed {
+		found = found || m.ID == member.ID
+	}
+	if !found {
+		t.Errorf("member %d is missing from the active members", member.ID)
+	}
+
+	a.call(http.MethodDelete, path, librarian, nil, http.StatusForbidden, nil)
+	a.call(http.MethodDelete, path, admin, nil, http.StatusOK, nil)
+	a.call(http.MethodGet, path, librarian, nil, http.StatusNotFound, nil)
+}
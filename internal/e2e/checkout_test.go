@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"library-management-system/internal/models"
+)
+
+// TestConcurrentCheckout has librarians at several desks lend the only
+// copy of a book at the same moment, each to a different member. Exactly
+// one checkout may succeed; the others must find the book unavailable.
+func TestConcurrentCheckout(t *testing.T) {
+	const desks = 10
+
+	for _, driver := range Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			h := NewTestHarness(t, driver)
+			if err := h.Seed(); err != nil {
+				t.Fatalf("failed to seed fixtures: %v", err)
+			}
+			a := &api{Harness: h, t: t}
+			librarian := a.login(LibrarianUsername, StaffPassword)
+			book := a.createBook(librarian, "Negeri 5 Menara", "Ahmad Fuadi", "978-979-22-4861-6", 1)
+			members := make([]models.Member, desks)
+			for i := range members {
+				members[i] = a.createMember(librarian, fmt.Sprintf("Desk Member %d", i+1), fmt.Sprintf("desk.member.%d@e2e.test", i+1))
+			}
+
+			codes := make([]int, desks)
+			errs := make([]error, desks)
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := range members {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					checkout := map[string]interface{}{"book_id": book.ID, "member_id": members[i].ID}
+					res, err := h.Do(http.MethodPost, "/api/loans/", librarian, checkout)
+					if err != nil {
+						errs[i] = err
+						return
+					}
+					codes[i] = res.Code
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+
+			lent := 0
+			for i, code := range codes {
+				if errs[i] != nil {
+					t.Fatal(errs[i])
+				}
+				switch code {
+				case http.StatusOK:
+					lent++
+				case http.StatusBadRequest:
+				default:
+					t.Errorf("checkout for member %d: got %d, want %d or %d", members[i].ID, code, http.StatusOK, http.StatusBadRequest)
+				}
+			}
+			if lent != 1 {
+				t.Errorf("%d checkouts of the only copy succeeded, want 1", lent)
+			}
+			a.expectAvailable(librarian, book.ID, 0)
+
+			var copies []models.BookCopy
+			a.call(http.MethodGet, fmt.Sprintf("/api/books/%d/copies", book.ID), librarian, nil, http.StatusOK, &copies)
+			if len(copies) != 1 || copies[0].Status != models.CopyStatusOnLoan {
+				t.Errorf("copies after the checkouts: %+v", copies)
+			}
+		})
+	}
+}
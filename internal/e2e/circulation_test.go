@@ -0,0 +1,177 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/scheduler"
+)
+
+func (a *api) runJob(job func(stores *repository.Stores) scheduler.JobFunc) {
+	a.t.Helper()
+	if _, err := a.RunJob(job); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+// testLoanCheckoutAndReturn lends the only copy of a book under the
+// default policy (14 days, 1000 a day), lets the nightly job charge two
+// days, returns the book a day later for a total fine of 3000 and pays it.
+func testLoanCheckoutAndReturn(t *testing.T, a *api) {
+	librarian := a.login(LibrarianUsername, StaffPassword)
+	book := a.createBook(librarian, "Bumi Manusia", "Pramoedya Ananta Toer", "978-979-97312-3-4", 1)
+	member := a.createMember(librarian, "Budi Santoso", "budi.santoso@e2e.test")
+
+	loan := a.checkout(librarian, book.ID, member.ID)
+	if want := Start.Add(14 * day); !loan.DueDate.Equal(want) {
+		t.Errorf("loan is due %s, want %s", loan.DueDate, want)
+	}
+	a.expectAvailable(librarian, book.ID, 0)
+	checkout := map[string]interface{}{"book_id": book.ID, "member_id": member.ID}
+	a.call(http.MethodPost, "/api/loans/", librarian, checkout, http.StatusBadRequest, nil)
+
+	a.Clock.Advance(16 * day)
+	a.runJob(scheduler.MarkOverdueLoans)
+	a.runJob(scheduler.AccrueFines)
+	loanPath := fmt.Sprintf("/api/loans/%d", loan.ID)
+	a.call(http.MethodGet, loanPath, librarian, nil, http.StatusOK, &loan)
+	if loan.Status != models.LoanStatusOverdue || loan.Fine != 2000 {
+		t.Errorf("after two days overdue the loan is %s with fine %d, want overdue with 2000", loan.Status, loan.Fine)
+	}
+	admin := a.login(AdminUsername, StaffPassword)
+	if got := len(a.notifications(admin, models.NotificationFineAssessed, member.ID)); got != 1 {
+		t.Errorf("member has %d fine_assessed notifications after the nightly job, want 1", got)
+	}
+
+	a.Clock.Advance(day)
+	a.call(http.MethodPut, loanPath+"/return", librarian, nil, http.StatusOK, &loan)
+	if loan.Status != models.LoanStatusReturned || loan.Fine != 3000 {
+		t.Errorf("returned loan is %s with fine %d, want returned with 3000", loan.Status, loan.Fine)
+	}
+	if loan.ReturnDate == nil || !loan.ReturnDate.Equal(a.Clock.Now()) {
+		t.Errorf("loan was returned at %v, want %s", loan.ReturnDate, a.Clock.Now())
+	}
+	a.call(http.MethodPut, loanPath+"/return", librarian, nil, http.StatusBadRequest, nil)
+	a.expectAvailable(librarian, book.ID, 1)
+
+	balancePath := fmt.Sprintf("/api/members/%d/balance", member.ID)
+	var balance models.FineBalance
+	a.call(http.MethodGet, balancePath, librarian, nil, http.StatusOK, &balance)
+	if balance.Charged != 3000 || balance.Balance != 3000 {
+		t.Fatalf("member was charged %d with %d outstanding, want 3000 and 3000", balance.Charged, balance.Balance)
+	}
+
+	payment := map[string]interface{}{"member_id": member.ID, "amount": 3001, "method": models.PaymentMethodCash}
+	a.call(http.MethodPost, "/api/fines/payments", librarian, payment, http.StatusBadRequest, nil)
+	payment["amount"] = 3000
+	var paid struct {
+		Payment models.FineEntry `json:"payment"`
+	}
+	a.call(http.MethodPost, "/api/fines/payments", librarian, payment, http.StatusOK, &paid)
+	if paid.Payment.ReceiptNumber == nil {
+		t.Fatal("payment has no receipt number")
+	}
+	a.call(http.MethodGet, "/api/fines/receipts/"+*paid.Payment.ReceiptNumber, librarian, nil, http.StatusOK, nil)
+	a.call(http.MethodGet, balancePath, librarian, nil, http.StatusOK, &balance)
+	if balance.Paid != 3000 || balance.Balance != 0 {
+		t.Errorf("after paying, member has paid %d with %d outstanding, want 3000 and 0", balance.Paid, balance.Balance)
+	}
+}
+
+// testRenewals renews a loan up to the default limit of two, each time
+// from the due date, and refuses to renew once a loan is past due.
+func testRenewals(t *testing.T, a *api) {
+	librarian := a.login(LibrarianUsername, StaffPassword)
+	member := a.createMember(librarian, "Dewi Lestari", "dewi.lestari@e2e.test")
+	book := a.createBook(librarian, "Supernova", "Dewi Lestari", "978-979-96257-0-2", 1)
+	loan := a.checkout(librarian, book.ID, member.ID)
+	renewPath := fmt.Sprintf("/api/loans/%d/renew", loan.ID)
+
+	a.Clock.Advance(10 * day)
+	for i, want := range []int{28, 42} {
+		a.call(http.MethodPut, renewPath, librarian, nil, http.StatusOK, &loan)
+		if due := Start.Add(time.Duration(want) * day); !loan.DueDate.Equal(due) || loan.RenewalCount != i+1 {
+			t.Errorf("after renewal %d the loan is due %s with %d renewals, want %s and %d", i+1, loan.DueDate, loan.RenewalCount, due, i+1)
+		}
+	}
+	a.call(http.MethodPut, renewPath, librarian, nil, http.StatusBadRequest, nil)
+
+	other := a.createBook(librarian, "Perahu Kertas", "Dewi Lestari", "978-979-1227-78-0", 1)
+	late := a.checkout(librarian, other.ID, member.ID)
+	a.Clock.Set(late.DueDate.Add(day))
+	a.call(http.MethodPut, fmt.Sprintf("/api/loans/%d/renew", late.ID), librarian, nil, http.StatusBadRequest, nil)
+
+	a.call(http.MethodPut, fmt.Sprintf("/api/loans/%d/return", late.ID), librarian, nil, http.StatusOK, nil)
+	a.call(http.MethodPut, fmt.Sprintf("/api/loans/%d/renew", late.ID), librarian, nil, http.StatusBadRequest, nil)
+}
+
+// testHolds has a reader queue for a book through /api/me/holds behind
+// the member who has it. The copy is set aside for the reader when it
+// comes back, so nobody else can borrow it and its loan cannot be renewed
+// while the reader waits.
+func testHolds(t *testing.T, a *api) {
+	librarian := a.login(LibrarianUsername, StaffPassword)
+	admin := a.login(AdminUsername, StaffPassword)
+	book := a.createBook(librarian, "Ronggeng Dukuh Paruk", "Ahmad Tohari", "978-979-22-0196-8", 1)
+	borrower := a.createMember(librarian, "Rina Wulandari", "rina.wulandari@e2e.test")
+	other := a.createMember(librarian, "Agus Salim", "agus.salim@e2e.test")
+
+	user := a.register("reader_holds", "BorrowMore42")
+	reader := a.login("reader_holds", "BorrowMore42")
+	a.call(http.MethodGet, "/api/me/holds", reader, nil, http.StatusForbidden, nil)
+	member := a.createMember(librarian, "Reader Holds", "reader_holds@e2e.test")
+	a.call(http.MethodPut, fmt.Sprintf("/api/users/%d/member", user.ID), admin, map[string]interface{}{"member_id": member.ID}, http.StatusOK, nil)
+
+	own := map[string]interface{}{"book_id": book.ID}
+	a.call(http.MethodPost, "/api/me/holds", reader, own, http.StatusBadRequest, nil)
+	loan := a.checkout(librarian, book.ID, borrower.ID)
+
+	var hold models.Hold
+	a.call(http.MethodPost, "/api/me/holds", reader, own, http.StatusOK, &hold)
+	if hold.MemberID != member.ID || hold.Status != models.HoldStatusWaiting || hold.Position != 1 {
+		t.Errorf("new hold is for member %d, %s at position %d, want member %d, waiting at 1", hold.MemberID, hold.Status, hold.Position, member.ID)
+	}
+	a.call(http.MethodPost, "/api/me/holds", reader, own, http.StatusConflict, nil)
+	var behind models.Hold
+	a.call(http.MethodPost, "/api/holds/", librarian, map[string]interface{}{"book_id": book.ID, "member_id": other.ID}, http.StatusOK, &behind)
+	if behind.Position != 2 {
+		t.Errorf("second hold is at position %d, want 2", behind.Position)
+	}
+	a.call(http.MethodPut, fmt.Sprintf("/api/me/holds/%d/cancel", behind.ID), reader, nil, http.StatusNotFound, nil)
+
+	a.call(http.MethodPut, fmt.Sprintf("/api/loans/%d/renew", loan.ID), librarian, nil, http.StatusConflict, nil)
+	a.Clock.Advance(3 * day)
+	a.call(http.MethodPut, fmt.Sprintf("/api/loans/%d/return", loan.ID), librarian, nil, http.StatusOK, nil)
+
+	var holds []models.Hold
+	a.call(http.MethodGet, "/api/me/holds", reader, nil, http.StatusOK, &holds)
+	if len(holds) != 1 || holds[0].Status != models.HoldStatusReady || holds[0].CopyID == nil || *holds[0].CopyID != *loan.CopyID {
+		t.Fatalf("reader's holds after the return: %+v", holds)
+	}
+	if got := len(a.notifications(admin, models.NotificationHoldReady, member.ID)); got != 1 {
+		t.Errorf("reader has %d hold_ready notifications, want 1", got)
+	}
+	a.expectAvailable(librarian, book.ID, 0)
+
+	a.call(http.MethodPost, "/api/loans/", librarian, map[string]interface{}{"book_id": book.ID, "member_id": other.ID}, http.StatusBadRequest, nil)
+	collected := a.checkout(librarian, book.ID, member.ID)
+	if collected.CopyID == nil || *collected.CopyID != *loan.CopyID {
+		t.Errorf("reader collected copy %v, want the one set aside, %d", collected.CopyID, *loan.CopyID)
+	}
+	a.call(http.MethodGet, fmt.Sprintf("/api/holds/%d", hold.ID), librarian, nil, http.StatusOK, &hold)
+	if hold.Status != models.HoldStatusFulfilled {
+		t.Errorf("collected hold is %s, want %s", hold.Status, models.HoldStatusFulfilled)
+	}
+
+	second := a.createBook(librarian, "Cantik Itu Luka", "Eka Kurniawan", "978-602-03-1258-3", 1)
+	a.checkout(librarian, second.ID, borrower.ID)
+	a.call(http.MethodPost, "/api/me/holds", reader, map[string]interface{}{"book_id": second.ID}, http.StatusOK, &hold)
+	a.call(http.MethodPut, fmt.Sprintf("/api/me/holds/%d/cancel", hold.ID), reader, nil, http.StatusOK, &hold)
+	if hold.Status != models.HoldStatusCancelled {
+		t.Errorf("cancelled hold is %s, want %s", hold.Status, models.HoldStatusCancelled)
+	}
+}
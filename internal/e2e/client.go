@@ -0,0 +1,97 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"library-management-system/internal/utils"
+)
+
+// Response is an API response with its envelope decoded and its data
+// left for the caller.
+type Response struct {
+	Request string
+	Code    int
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    json.RawMessage   `json:"data"`
+	Meta    *utils.Pagination `json:"meta"`
+}
+
+// Do sends a request through the router. token may be empty, and body is
+// sent as JSON unless it is nil.
+func (h *Harness) Do(method, path, token string, body interface{}) (*Response, error) {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if h.RemoteAddr != "" {
+		req.RemoteAddr = h.RemoteAddr
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	h.Router.ServeHTTP(recorder, req)
+
+	res := &Response{Request: method + " " + path, Code: recorder.Code}
+	if err := json.Unmarshal(recorder.Body.Bytes(), res); err != nil {
+		return nil, fmt.Errorf("%s: %d with a body that is not JSON: %q", res.Request, res.Code, recorder.Body.String())
+	}
+	return res, nil
+}
+
+// Expect returns an error unless the response has the given status code.
+func (r *Response) Expect(code int) error {
+	if r.Code != code {
+		return fmt.Errorf("%s: got %d %q, want %d", r.Request, r.Code, r.Message, code)
+	}
+	return nil
+}
+
+// Decode reads the response data into v.
+func (r *Response) Decode(v interface{}) error {
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		return fmt.Errorf("%s: %w", r.Request, err)
+	}
+	return nil
+}
+
+// call sends a request, checks the status code and decodes the data into
+// v when it is not nil.
+func (h *Harness) call(method, path, token string, body interface{}, code int, v interface{}) error {
+	res, err := h.Do(method, path, token, body)
+	if err != nil {
+		return err
+	}
+	if err := res.Expect(code); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	return res.Decode(v)
+}
+
+// Login returns an access token for the user.
+func (h *Harness) Login(username, password string) (string, error) {
+	var login struct {
+		Token string `json:"token"`
+	}
+	err := h.call(http.MethodPost, "/api/auth/login", "", map[string]string{
+		"username": username,
+		"password": password,
+	}, http.StatusOK, &login)
+	if err == nil && login.Token == "" {
+		err = fmt.Errorf("login of %s returned no token", username)
+	}
+	return login.Token, err
+}
@@ -0,0 +1,152 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"library-management-system/internal/models"
+)
+
+const day = 24 * time.Hour
+
+// scenarios share one database per driver, so each makes its own users,
+// books and members rather than relying on what others left behind.
+var scenarios = []struct {
+	name string
+	run  func(t *testing.T, a *api)
+}{
+	{"register and login", testRegisterAndLogin},
+	{"refresh rotation", testRefreshRotation},
+	{"login unlock", testLoginUnlock},
+	{"two-factor authentication", testMFA},
+	{"password change", testPasswordChange},
+	{"password reset", testPasswordReset},
+	{"book CRUD", testBookCRUD},
+	{"member CRUD", testMemberCRUD},
+	{"loan checkout and return with fines", testLoanCheckoutAndReturn},
+	{"fine waivers", testFineWaivers},
+	{"renewals", testRenewals},
+	{"circulation policies", testCirculationPolicies},
+	{"holds", testHolds},
+	{"audit trail", testAuditTrail},
+}
+
+// TestAPI runs every scenario against a fresh database of each driver.
+// See NewTestHarness for when Postgres is skipped.
+func TestAPI(t *testing.T) {
+	for _, driver := range Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			h := NewTestHarness(t, driver)
+			if err := h.Seed(); err != nil {
+				t.Fatalf("failed to seed fixtures: %v", err)
+			}
+			for i, s := range scenarios {
+				i, s := i, s
+				t.Run(s.name, func(t *testing.T) {
+					// Each scenario comes from an address of its own, so
+					// the failed logins one makes on purpose do not slow
+					// down the next.
+					h.RemoteAddr = fmt.Sprintf("192.0.2.%d:1234", i+1)
+					h.Clock.Set(Start)
+					s.run(t, &api{Harness: h, t: t})
+				})
+			}
+		})
+	}
+}
+
+// api is the harness seen from one test: a request that gets a status
+// other than the one expected fails the test.
+type api struct {
+	*Harness
+	t *testing.T
+}
+
+func (a *api) call(method, path, token string, body interface{}, code int, v interface{}) {
+	a.t.Helper()
+	if err := a.Harness.call(method, path, token, body, code, v); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+// dataOf returns v when a response with the code carries data, and nil
+// for errors, which have none.
+func dataOf(code int, v interface{}) interface{} {
+	if code != http.StatusOK {
+		return nil
+	}
+	return v
+}
+
+func (a *api) login(username, password string) string {
+	a.t.Helper()
+	token, err := a.Login(username, password)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return token
+}
+
+// register signs up a reader and returns the new account.
+func (a *api) register(username, password string) models.User {
+	a.t.Helper()
+	var user models.User
+	a.call(http.MethodPost, "/api/auth/register", "", map[string]string{
+		"username": username,
+		"email":    username + "@e2e.test",
+		"password": password,
+	}, http.StatusOK, &user)
+	return user
+}
+
+func (a *api) createBook(token, title, author, isbn string, stock int) models.Book {
+	a.t.Helper()
+	var book models.Book
+	create := map[string]interface{}{"title": title, "author": author, "isbn": isbn, "stock": stock}
+	a.call(http.MethodPost, "/api/books/", token, create, http.StatusOK, &book)
+	return book
+}
+
+func (a *api) createMember(token, name, email string) models.Member {
+	a.t.Helper()
+	var member models.Member
+	a.call(http.MethodPost, "/api/members/", token, map[string]interface{}{"name": name, "email": email}, http.StatusOK, &member)
+	return member
+}
+
+func (a *api) checkout(token string, bookID, memberID uint) models.Loan {
+	a.t.Helper()
+	var loan models.Loan
+	a.call(http.MethodPost, "/api/loans/", token, map[string]interface{}{"book_id": bookID, "member_id": memberID}, http.StatusOK, &loan)
+	return loan
+}
+
+func (a *api) expectAvailable(token string, bookID uint, want int) {
+	a.t.Helper()
+	var book models.Book
+	a.call(http.MethodGet, fmt.Sprintf("/api/books/%d", bookID), token, nil, http.StatusOK, &book)
+	if book.Available != want {
+		a.t.Errorf("book %d has %d available, want %d", bookID, book.Available, want)
+	}
+}
+
+// notifications lists the queued notifications of one event for a
+// member.
+func (a *api) notifications(token, event string, memberID uint) []models.Notification {
+	a.t.Helper()
+	var notifications []models.Notification
+	a.call(http.MethodGet, fmt.Sprintf("/api/notifications?event=%s&member_id=%d", event, memberID), token, nil, http.StatusOK, &notifications)
+	return notifications
+}
+
+func containsBook(books []models.Book, id uint) bool {
+	for _, book := range books {
+		if book.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"library-management-system/internal/models"
+)
+
+// testFineWaivers charges a member for a lost book and waives part of it.
+// Only an admin may waive, never more than the member owes, and the
+// waiver goes in the ledger as approved by the admin.
+func testFineWaivers(t *testing.T, a *api) {
+	librarian := a.login(LibrarianUsername, StaffPassword)
+	admin := a.login(AdminUsername, StaffPassword)
+	member := a.createMember(librarian, "Ratna Sari", "ratna.sari@e2e.test")
+	other := a.createMember(librarian, "Hendra Gunawan", "hendra.gunawan@e2e.test")
+	book := a.createBook(librarian, "Saman", "Ayu Utami", "978-979-9023-17-9", 1)
+	loan := a.checkout(librarian, book.ID, other.ID)
+
+	charge := map[string]interface{}{"member_id": member.ID, "amount": 5000, "reason": "Lost book"}
+	a.call(http.MethodPost, "/api/fines/charges", librarian, charge, http.StatusOK, nil)
+	balancePath := fmt.Sprintf("/api/members/%d/balance", member.ID)
+	balance := func() models.FineBalance {
+		t.Helper()
+		var balance models.FineBalance
+		a.call(http.MethodGet, balancePath, librarian, nil, http.StatusOK, &balance)
+		return balance
+	}
+
+	waiver := map[string]interface{}{"member_id": member.ID, "amount": 2000, "reason": "Found on the returns trolley"}
+	a.call(http.MethodPost, "/api/fines/waivers", librarian, waiver, http.StatusForbidden, nil)
+	a.call(http.MethodPost, "/api/fines/waivers", "", waiver, http.StatusUnauthorized, nil)
+	if got := balance(); got.Waived != 0 || got.Balance != 5000 {
+		t.Fatalf("refused waivers left the balance at %+v", got)
+	}
+
+	for field, value := range map[string]interface{}{"amount": 5001, "loan_id": loan.ID} {
+		invalid := map[string]interface{}{"member_id": member.ID, "amount": 2000, "reason": "Goodwill"}
+		invalid[field] = value
+		a.call(http.MethodPost, "/api/fines/waivers", admin, invalid, http.StatusBadRequest, nil)
+	}
+	a.call(http.MethodPost, "/api/fines/waivers", admin, map[string]interface{}{"member_id": member.ID, "amount": 2000}, http.StatusBadRequest, nil)
+
+	var waived struct {
+		Waiver  models.FineEntry   `json:"waiver"`
+		Balance models.FineBalance `json:"balance"`
+	}
+	a.call(http.MethodPost, "/api/fines/waivers", admin, waiver, http.StatusOK, &waived)
+	if waived.Balance.Waived != 2000 || waived.Balance.Balance != 3000 {
+		t.Errorf("after the waiver the balance is %+v, want 2000 waived and 3000 owed", waived.Balance)
+	}
+	if got := balance(); got != waived.Balance {
+		t.Errorf("member's balance is %+v, the waiver reported %+v", got, waived.Balance)
+	}
+
+	var entries []models.FineEntry
+	a.call(http.MethodGet, fmt.Sprintf("/api/fines/?member_id=%d&type=%s", member.ID, models.FineEntryWaiver), librarian, nil, http.StatusOK, &entries)
+	adminUser, err := a.Stores.Users.GetByUsername(AdminUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("ledger has %d waivers, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.ID != waived.Waiver.ID || entry.Amount != 2000 || entry.Reason != "Found on the returns trolley" ||
+		entry.ApprovedByID == nil || *entry.ApprovedByID != adminUser.ID {
+		t.Errorf("ledger waiver is %+v, want 2000 approved by the admin", entry)
+	}
+
+	waiver["amount"] = 3001
+	a.call(http.MethodPost, "/api/fines/waivers", admin, waiver, http.StatusBadRequest, nil)
+	waiver["amount"] = 3000
+	a.call(http.MethodPost, "/api/fines/waivers", admin, waiver, http.StatusOK, nil)
+	if got := balance(); got.Waived != 5000 || got.Balance != 0 {
+		t.Errorf("after waiving the rest the balance is %+v", got)
+	}
+	waiver["amount"] = 1
+	a.call(http.MethodPost, "/api/fines/waivers", admin, waiver, http.StatusBadRequest, nil)
+}
@@ -0,0 +1,26 @@
+package e2e
+
+import (
+	"library-management-system/internal/models"
+)
+
+// Staff accounts created by Seed.
+const (
+	AdminUsername     = "e2e_admin"
+	LibrarianUsername = "e2e_librarian"
+	StaffPassword     = "Circulation2024"
+)
+
+// Seed adds the staff accounts the cases log in with.
+func (h *Harness) Seed() error {
+	users := []*models.User{
+		{Username: AdminUsername, Email: "admin@e2e.test", Password: StaffPassword, Role: models.RoleAdmin},
+		{Username: LibrarianUsername, Email: "librarian@e2e.test", Password: StaffPassword, Role: models.RoleLibrarian},
+	}
+	for _, user := range users {
+		if err := h.Stores.Users.Create(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Package e2e runs the HTTP API end to end: the real router and handlers
+// over a throwaway database made for the run, Postgres or SQLite, with a
+// fake clock so due dates and fines can be tested by moving time forward.
+// The scenarios are the package's tests; run them with
+// `go test ./internal/e2e`.
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"library-management-system/internal/clock"
+	"library-management-system/internal/config"
+	"library-management-system/internal/health"
+	"library-management-system/internal/loginguard"
+	"library-management-system/internal/metrics"
+	"library-management-system/internal/notification"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/scheduler"
+	"library-management-system/internal/server"
+	"library-management-system/migrations"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Start is where the fake clock begins. It is a fixed date so that runs
+// are repeatable.
+var Start = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+// MetricsToken is the bearer token the harness's router wants for
+// /metrics.
+const MetricsToken = "e2e-metrics-token-that-is-long-enough"
+
+// MFAEncryptionKey is the key the harness's router encrypts TOTP secrets
+// with.
+const MFAEncryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+// ErrNoPostgres is returned by NewHarness when the embedded Postgres
+// server cannot be started, e.g. because its binaries cannot be
+// downloaded.
+var ErrNoPostgres = errors.New("failed to start postgres")
+
+// Database is an empty database of its own in a temporary directory.
+// Config holds the settings that reach it, for code that connects by
+// itself.
+type Database struct {
+	DB     *gorm.DB
+	Config config.DatabaseConfig
+
+	postgres *embeddedpostgres.EmbeddedPostgres
+	dir      string
+}
+
+// NewDatabase makes a database with the given driver in a temporary
+// directory. For Postgres it starts a server of its own. Close must be
+// called to stop it and remove the directory.
+func NewDatabase(driver string) (*Database, error) {
+	dir, err := os.MkdirTemp("", "library-e2e-")
+	if err != nil {
+		return nil, err
+	}
+	d := &Database{Config: config.Default().Database, dir: dir}
+	d.Config.Driver = driver
+
+	switch driver {
+	case config.DriverPostgres:
+		err = d.startPostgres()
+	case config.DriverSQLite:
+		d.Config.Path = filepath.Join(dir, "library.db")
+	default:
+		err = fmt.Errorf("unknown database driver %q", driver)
+	}
+	if err == nil {
+		d.DB, err = config.OpenDB(driver, d.Config.DSN(), &gorm.Config{Logger: logger.Discard})
+	}
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Database) startPostgres() error {
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	postgres := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Version(embeddedpostgres.V16).
+		Port(port).
+		Database("library_e2e").
+		RuntimePath(filepath.Join(d.dir, "runtime")).
+		DataPath(filepath.Join(d.dir, "data")).
+		Logger(io.Discard))
+	if err := postgres.Start(); err != nil {
+		return fmt.Errorf("%w: %v", ErrNoPostgres, err)
+	}
+	d.postgres = postgres
+
+	d.Config.Host = "localhost"
+	d.Config.Port = strconv.Itoa(int(port))
+	d.Config.User = "postgres"
+	d.Config.Password = "postgres"
+	d.Config.Name = "library_e2e"
+	d.Config.TimeZone = "UTC"
+	return nil
+}
+
+func (d *Database) Close() error {
+	if d.DB != nil {
+		if sqlDB, err := d.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	var err error
+	if d.postgres != nil {
+		err = d.postgres.Stop()
+	}
+	os.RemoveAll(d.dir)
+	return err
+}
+
+// Harness is one booted API over a fresh database.
+type Harness struct {
+	*Database
+	Config *config.Config
+	Stores *repository.Stores
+	Clock  *clock.Fake
+	Outbox *Outbox
+	Router *gin.Engine
+
+	// RemoteAddr is the client address requests come from, when set.
+	// Failed logins are throttled per address.
+	RemoteAddr string
+}
+
+// NewHarness makes a database with the given driver, as NewDatabase does,
+// migrates it and builds the router the way cmd/main.go does. Close must
+// be called to stop the database.
+func NewHarness(driver string) (*Harness, error) {
+	// The suite runs on the defaults, whatever the environment says, so
+	// results do not depend on who runs it. Any secret will do.
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "e2e-secret-that-is-long-enough-to-sign-with"
+	cfg.Auth.MFAEncryptionKey = MFAEncryptionKey
+	cfg.Server.MetricsToken = MetricsToken
+	cfg.Database.Driver = driver
+
+	database, err := NewDatabase(driver)
+	if err != nil {
+		return nil, err
+	}
+	h := &Harness{Database: database, Config: cfg}
+
+	migrator, err := migrations.New(h.DB)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(0); err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	h.Stores = repository.NewStores(h.DB, h.Config.Circulation)
+	h.Clock = clock.NewFake(Start)
+	h.Outbox = &Outbox{}
+
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	guard := loginguard.New(loginguard.NewMemoryStore(), h.Config.LoginGuard, h.Stores.Audit)
+	jobs := scheduler.New(h.DB, h.Config.Scheduler.Interval)
+	dispatcher := notification.NewDispatcher(h.Stores, h.Outbox, h.Config.Notification.MaxAttempts)
+	scheduler.RegisterLibraryJobs(jobs, h.Stores, dispatcher, h.Config)
+	routerMetrics, err := metrics.New(h.DB, h.Stores)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.Router, err = server.NewRouter(server.Dependencies{
+		Config:  h.Config,
+		Stores:  h.Stores,
+		Guard:   guard,
+		Sender:  h.Outbox,
+		Jobs:    jobs,
+		Health:  health.NewChecker(h.DB, migrator, jobs),
+		Metrics: routerMetrics,
+		Clock:   h.Clock,
+	})
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+// RunJob runs a scheduler job once at the fake clock's time.
+func (h *Harness) RunJob(job func(stores *repository.Stores) scheduler.JobFunc) (string, error) {
+	return job(h.Stores)(context.Background(), h.Clock.Now())
+}
+
+func freePort() (uint32, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return uint32(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+// Outbox is a notification sender that keeps what it is given.
+type Outbox struct {
+	mu       sync.Mutex
+	messages []notification.Message
+}
+
+func (o *Outbox) Send(ctx context.Context, msg notification.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+func (o *Outbox) Messages() []notification.Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]notification.Message(nil), o.messages...)
+}
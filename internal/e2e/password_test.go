@@ -0,0 +1,107 @@
+package e2e
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"library-management-system/internal/handlers"
+	"library-management-system/internal/models"
+)
+
+// testPasswordChange changes a reader's password. The current one must be
+// given and the new one must pass the policy; afterwards only the new one
+// logs in, and only the session that made the change carries on.
+func testPasswordChange(t *testing.T, a *api) {
+	a.register("reader_change", "BorrowMore42")
+	var login handlers.LoginResponse
+	a.call(http.MethodPost, "/api/auth/login", "", map[string]string{"username": "reader_change", "password": "BorrowMore42"}, http.StatusOK, &login)
+
+	change := func(current, next string, code int) handlers.TokenResponse {
+		t.Helper()
+		var tokens handlers.TokenResponse
+		body := map[string]string{"current_password": current, "new_password": next}
+		a.call(http.MethodPost, "/api/auth/change-password", login.Token, body, code, dataOf(code, &tokens))
+		return tokens
+	}
+	change("NotMyPassword1", "ShelveIt2025", http.StatusBadRequest)
+	change("BorrowMore42", "BorrowMore42", http.StatusBadRequest)
+	for _, weak := range []string{"Short1", "OnlyLettersHere", "reader_change99", "password123"} {
+		change("BorrowMore42", weak, http.StatusBadRequest)
+	}
+	a.login("reader_change", "BorrowMore42")
+
+	tokens := change("BorrowMore42", "ShelveIt2025", http.StatusOK)
+	a.call(http.MethodGet, "/api/books/", tokens.Token, nil, http.StatusOK, nil)
+	a.call(http.MethodPost, "/api/auth/refresh", "", map[string]string{"refresh_token": login.RefreshToken}, http.StatusUnauthorized, nil)
+	a.call(http.MethodPost, "/api/auth/login", "", map[string]string{"username": "reader_change", "password": "BorrowMore42"}, http.StatusUnauthorized, nil)
+	a.login("reader_change", "ShelveIt2025")
+}
+
+// testPasswordReset resets a forgotten password with the emailed token. A
+// token works once, not after it expires, and the reset ends every
+// session of the account.
+func testPasswordReset(t *testing.T, a *api) {
+	user := a.register("reader_reset", "BorrowMore42")
+	var login handlers.LoginResponse
+	a.call(http.MethodPost, "/api/auth/login", "", map[string]string{"username": "reader_reset", "password": "BorrowMore42"}, http.StatusOK, &login)
+
+	a.call(http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "nobody@e2e.test"}, http.StatusOK, nil)
+	token := a.resetToken(user.Email, 0)
+	reset := func(token, password string, code int) {
+		t.Helper()
+		a.call(http.MethodPost, "/api/auth/reset-password", "", map[string]string{"token": token, "new_password": password}, code, nil)
+	}
+
+	reset("0123456789abcdef", "ShelveIt2025", http.StatusBadRequest)
+	reset(token, "Short1", http.StatusBadRequest)
+	reset(token, "reader_reset99", http.StatusBadRequest)
+
+	// Sessions are revoked to the second, so the reset waits for the
+	// second after the login to be sure the login's token falls before.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	reset(token, "ShelveIt2025", http.StatusOK)
+	reset(token, "ShelveIt2026", http.StatusBadRequest)
+
+	a.call(http.MethodGet, "/api/books/", login.Token, nil, http.StatusUnauthorized, nil)
+	a.call(http.MethodPost, "/api/auth/refresh", "", map[string]string{"refresh_token": login.RefreshToken}, http.StatusUnauthorized, nil)
+	a.login("reader_reset", "ShelveIt2025")
+
+	expired := a.resetToken(user.Email, 1)
+	err := a.DB.Model(&models.PasswordResetToken{}).
+		Where("user_id = ?", user.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	reset(expired, "ShelveIt2026", http.StatusBadRequest)
+	a.login("reader_reset", "ShelveIt2025")
+}
+
+var resetTokenPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// resetToken requests a password reset for email and returns the token
+// from the email, which is sent in the background. sent is how many reset
+// emails went to the address before.
+func (a *api) resetToken(email string, sent int) string {
+	a.t.Helper()
+	a.call(http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": email}, http.StatusOK, nil)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		var bodies []string
+		for _, msg := range a.Outbox.Messages() {
+			if msg.To == email {
+				bodies = append(bodies, msg.Body)
+			}
+		}
+		if len(bodies) > sent {
+			token := resetTokenPattern.FindString(bodies[sent])
+			if token == "" {
+				a.t.Fatalf("reset email has no token:\n%s", bodies[sent])
+			}
+			return token
+		}
+	}
+	a.t.Fatalf("no reset email went to %s", email)
+	return ""
+}
@@ -0,0 +1,111 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"library-management-system/internal/models"
+)
+
+// testCirculationPolicies sets up a policy for students and checks that
+// checkouts, renewals and returns follow it: its loan period, loan and
+// renewal limits, and fines with grace days and a cap.
+func testCirculationPolicies(t *testing.T, a *api) {
+	librarian := a.login(LibrarianUsername, StaffPassword)
+	admin := a.login(AdminUsername, StaffPassword)
+
+	students := map[string]interface{}{
+		"name": "Students", "member_type": "student", "loan_period_days": 7, "max_loans": 2,
+		"max_renewals": 1, "daily_fine": 500, "grace_days": 1, "max_fine": 2000,
+	}
+	a.call(http.MethodPost, "/api/policies/", librarian, students, http.StatusForbidden, nil)
+	var policy models.CirculationPolicy
+	a.call(http.MethodPost, "/api/policies/", admin, students, http.StatusOK, &policy)
+	a.call(http.MethodPost, "/api/policies/", admin, students, http.StatusConflict, nil)
+	a.call(http.MethodPost, "/api/policies/", admin, map[string]interface{}{"name": "Nothing", "member_type": "staff", "max_loans": 1}, http.StatusBadRequest, nil)
+	var reference models.CirculationPolicy
+	a.call(http.MethodPost, "/api/policies/", admin, map[string]interface{}{
+		"name": "Student reference", "member_type": "student", "category": "reference", "loan_period_days": 1, "max_loans": 1,
+	}, http.StatusOK, &reference)
+
+	policyPath := fmt.Sprintf("/api/policies/%d", policy.ID)
+	students["loan_period_days"] = 10
+	a.call(http.MethodPut, policyPath, librarian, students, http.StatusForbidden, nil)
+	a.call(http.MethodPut, policyPath, admin, students, http.StatusOK, nil)
+	a.call(http.MethodGet, policyPath, librarian, nil, http.StatusOK, &policy)
+	if policy.LoanPeriodDays != 10 || policy.MaxFine != 2000 {
+		t.Errorf("updated policy is %+v, want a 10 day loan period", policy)
+	}
+	var policies []models.CirculationPolicy
+	a.call(http.MethodGet, "/api/policies/", librarian, nil, http.StatusOK, &policies)
+	found := 0
+	for _, p := range policies {
+		if p.ID == policy.ID || p.ID == reference.ID {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("policy list %+v lacks the student policies", policies)
+	}
+
+	resolve := func(memberType, category string) models.CirculationPolicy {
+		t.Helper()
+		var resolved models.CirculationPolicy
+		a.call(http.MethodGet, fmt.Sprintf("/api/policies/resolve?member_type=%s&category=%s", memberType, category), librarian, nil, http.StatusOK, &resolved)
+		return resolved
+	}
+	for _, c := range []struct{ memberType, category, want string }{
+		{"student", "reference", "Student reference"},
+		{"Student", "fiction", "Students"},
+		{"general", "reference", models.DefaultCirculationPolicy().Name},
+	} {
+		if got := resolve(c.memberType, c.category); got.Name != c.want {
+			t.Errorf("policy for %s and %s is %q, want %q", c.memberType, c.category, got.Name, c.want)
+		}
+	}
+	referencePath := fmt.Sprintf("/api/policies/%d", reference.ID)
+	a.call(http.MethodDelete, referencePath, admin, nil, http.StatusOK, nil)
+	a.call(http.MethodGet, referencePath, librarian, nil, http.StatusNotFound, nil)
+	if got := resolve("student", "reference"); got.Name != "Students" {
+		t.Errorf("after deleting the reference policy students get %q", got.Name)
+	}
+
+	var member models.Member
+	a.call(http.MethodPost, "/api/members/", librarian, map[string]interface{}{
+		"name": "Putri Ayu", "email": "putri.ayu@e2e.test", "member_type": "student",
+	}, http.StatusOK, &member)
+	var books []models.Book
+	for i, title := range []string{"Negeri 5 Menara", "Ayat-Ayat Cinta", "Perahu Kertas"} {
+		books = append(books, a.createBook(librarian, title, "Various", fmt.Sprintf("978-0-00-000%03d-1", i), 1))
+	}
+	first := a.checkout(librarian, books[0].ID, member.ID)
+	if want := Start.Add(10 * day); !first.DueDate.Equal(want) {
+		t.Errorf("student loan is due %s, want %s", first.DueDate, want)
+	}
+	second := a.checkout(librarian, books[1].ID, member.ID)
+	checkout := map[string]interface{}{"book_id": books[2].ID, "member_id": member.ID}
+	a.call(http.MethodPost, "/api/loans/", librarian, checkout, http.StatusBadRequest, nil)
+
+	a.Clock.Advance(9 * day)
+	renewPath := fmt.Sprintf("/api/loans/%d/renew", first.ID)
+	a.call(http.MethodPut, renewPath, librarian, nil, http.StatusOK, &first)
+	if want := Start.Add(20 * day); !first.DueDate.Equal(want) {
+		t.Errorf("renewed student loan is due %s, want %s", first.DueDate, want)
+	}
+	a.call(http.MethodPut, renewPath, librarian, nil, http.StatusBadRequest, nil)
+
+	// Four days past the renewed due date, one of them grace, is 1500; the
+	// second loan's 13 chargeable days come to 6500, capped at 2000.
+	a.Clock.Set(Start.Add(24 * day))
+	for _, c := range []struct {
+		loan models.Loan
+		fine int64
+	}{{first, 1500}, {second, 2000}} {
+		var returned models.Loan
+		a.call(http.MethodPut, fmt.Sprintf("/api/loans/%d/return", c.loan.ID), librarian, nil, http.StatusOK, &returned)
+		if returned.Fine != c.fine {
+			t.Errorf("loan %d was returned with fine %d, want %d", c.loan.ID, returned.Fine, c.fine)
+		}
+	}
+}
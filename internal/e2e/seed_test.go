@@ -0,0 +1,53 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"library-management-system/internal/models"
+	"library-management-system/migrations"
+)
+
+// TestSeed loads the sample data twice on each driver and checks that the
+// sample accounts can log in and see it all, each book with its copies.
+func TestSeed(t *testing.T) {
+	for _, driver := range Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			h := NewTestHarness(t, driver)
+			migrator, err := migrations.New(h.DB)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				if err := migrator.Seed(); err != nil {
+					t.Fatalf("seed run %d: %v", i+1, err)
+				}
+			}
+
+			a := &api{Harness: h, t: t}
+			a.login("librarian", "password")
+			admin := a.login("admin", "password")
+
+			var books []models.Book
+			a.call(http.MethodGet, "/api/books/?per_page=100", admin, nil, http.StatusOK, &books)
+			if len(books) != 4 {
+				t.Fatalf("got %d sample books, want 4", len(books))
+			}
+			for _, book := range books {
+				var copies []models.BookCopy
+				a.call(http.MethodGet, fmt.Sprintf("/api/books/%d/copies", book.ID), admin, nil, http.StatusOK, &copies)
+				if len(copies) != book.Stock || book.Available != book.Stock {
+					t.Errorf("%s has %d copies and %d available, want %d of each", book.Title, len(copies), book.Available, book.Stock)
+				}
+			}
+
+			var members []models.Member
+			a.call(http.MethodGet, "/api/members/?per_page=100", admin, nil, http.StatusOK, &members)
+			if len(members) != 3 {
+				t.Errorf("got %d sample members, want 3", len(members))
+			}
+		})
+	}
+}
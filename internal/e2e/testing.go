@@ -0,0 +1,63 @@
+package e2e
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"library-management-system/internal/config"
+)
+
+// Drivers are the databases tests run against.
+var Drivers = []string{config.DriverPostgres, config.DriverSQLite}
+
+// RequirePostgresEnv names the environment variable that makes a Postgres
+// that cannot start fail tests instead of skipping them. CI sets it, so a
+// broken Postgres setup cannot pass with only SQLite tested.
+const RequirePostgresEnv = "E2E_REQUIRE_POSTGRES"
+
+// NewTestHarness starts a harness for t and closes it when t ends. Postgres
+// is skipped in -short mode, and when it cannot start unless
+// E2E_REQUIRE_POSTGRES is set.
+func NewTestHarness(t testing.TB, driver string) *Harness {
+	t.Helper()
+	skipShort(t, driver)
+	h, err := NewHarness(driver)
+	closeWith(t, h, err)
+	return h
+}
+
+// NewTestDatabase is NewTestHarness for an empty database, one that was
+// never migrated.
+func NewTestDatabase(t testing.TB, driver string) *Database {
+	t.Helper()
+	skipShort(t, driver)
+	d, err := NewDatabase(driver)
+	closeWith(t, d, err)
+	return d
+}
+
+func skipShort(t testing.TB, driver string) {
+	t.Helper()
+	if driver == config.DriverPostgres && testing.Short() {
+		t.Skip("skipping postgres in short mode")
+	}
+}
+
+// closeWith fails or skips t when starting c failed with err, and closes c
+// when t ends otherwise.
+func closeWith(t testing.TB, c io.Closer, err error) {
+	t.Helper()
+	if err != nil {
+		if errors.Is(err, ErrNoPostgres) && os.Getenv(RequirePostgresEnv) == "" {
+			t.Skipf("postgres is not available (set %s=1 to fail instead): %v", RequirePostgresEnv, err)
+		}
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Errorf("failed to stop the test database: %v", err)
+		}
+	})
+}
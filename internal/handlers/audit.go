@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AuditHandler struct {
+	auditRepo repository.AuditStore
+}
+
+func NewAuditHandler(stores *repository.Stores) *AuditHandler {
+	return &AuditHandler{
+		auditRepo: stores.Audit,
+	}
+}
+
+type AuditListQuery struct {
+	ActorID    uint      `form:"actor_id"`
+	Action     string    `form:"action"`
+	EntityType string    `form:"entity_type"`
+	EntityID   string    `form:"entity_id"`
+	RequestID  string    `form:"request_id"`
+	From       time.Time `form:"from"`
+	To         time.Time `form:"to"`
+}
+
+var auditSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"action":     "action",
+}
+
+// auditor is embedded by the handlers whose changes are audited.
+type auditor struct {
+	auditRepo repository.AuditStore
+}
+
+// audit records a change made by the caller in tx, which may be nil when
+// the change was not made in a transaction. before is nil for creations
+// and after is nil for deletions; details holds anything else worth
+// keeping, such as a reason.
+func (a auditor) audit(tx *repository.Tx, c *gin.Context, action, entityType string, entityID interface{}, before, after, details interface{}) error {
+	return a.auditAs(tx, c, currentUserID(c), action, entityType, entityID, before, after, details)
+}
+
+// auditAs is audit for requests made before the caller is authenticated,
+// such as a login, where the actor comes from the request body.
+func (a auditor) auditAs(tx *repository.Tx, c *gin.Context, actorID uint, action, entityType string, entityID interface{}, before, after, details interface{}) error {
+	event := &models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		IPAddress:  c.ClientIP(),
+		RequestID:  c.GetString("request_id"),
+	}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+	return a.auditRepo.WithTx(tx).Record(event, before, after, details)
+}
+
+// GetAuditEvents lists audit events, newest first unless sorted otherwise.
+// from is inclusive and to exclusive; both are RFC 3339 timestamps.
+func (h *AuditHandler) GetAuditEvents(c *gin.Context) {
+	var query AuditListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	page, err := parsePage(c, auditSortFields)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+	if len(page.Sort) == 0 {
+		page.Sort = []repository.SortField{{Column: "id", Desc: true}}
+	}
+
+	filter := repository.AuditFilter{
+		ActorID:    query.ActorID,
+		Action:     query.Action,
+		EntityType: query.EntityType,
+		EntityID:   query.EntityID,
+		RequestID:  query.RequestID,
+		From:       query.From,
+		To:         query.To,
+	}
+	events, total, err := h.auditRepo.List(filter, page)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch audit events")
+		return
+	}
+
+	utils.PaginatedResponse(c, "Audit events retrieved successfully", events, utils.NewPagination(page.Number, page.PerPage, total))
+}
+
+func (h *AuditHandler) GetAuditEventByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid audit event ID")
+		return
+	}
+
+	event, err := h.auditRepo.GetByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.NotFoundResponse(c, "Audit event not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch audit event")
+		return
+	}
+
+	utils.SuccessResponse(c, "Audit event retrieved successfully", event)
+}
@@ -1,30 +1,56 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"library-management-system/internal/config"
+	"library-management-system/internal/loginguard"
 	"library-management-system/internal/models"
+	"library-management-system/internal/notification"
 	"library-management-system/internal/repository"
 	"library-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+var errInvalidCredentials = errors.New("invalid credentials")
+
 type AuthHandler struct {
-	userRepo *repository.UserRepository
+	auditor
+	db        repository.Transactor
+	userRepo  repository.UserStore
+	tokenRepo repository.TokenStore
+	auth      config.AuthConfig
+	issuer    *utils.TokenIssuer
+	guard     *loginguard.Guard
+	sender    notification.Sender
 }
 
-func NewAuthHandler() *AuthHandler {
+func NewAuthHandler(stores *repository.Stores, auth config.AuthConfig, issuer *utils.TokenIssuer, guard *loginguard.Guard, sender notification.Sender) *AuthHandler {
 	return &AuthHandler{
-		userRepo: repository.NewUserRepository(),
+		db:        stores.DB,
+		auditor:   auditor{auditRepo: stores.Audit},
+		userRepo:  stores.Users,
+		tokenRepo: stores.Tokens,
+		auth:      auth,
+		issuer:    issuer,
+		guard:     guard,
+		sender:    sender,
 	}
 }
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role"`
+	Password string `json:"password" binding:"required"`
 }
 
 type LoginRequest struct {
@@ -32,44 +58,127 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Language string `json:"language"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
 type LoginResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	TokenResponse
+	User models.User `json:"user"`
+}
+
+// issueTokens starts a session for the user, or continues one when
+// familyID is set, and returns the new refresh token record with the
+// tokens to hand to the client.
+func issueTokens(c *gin.Context, tx *repository.Tx, issuer *utils.TokenIssuer, tokens repository.TokenStore, user *models.User, familyID string) (*models.RefreshToken, *TokenResponse, error) {
+	accessToken, claims, err := issuer.GenerateToken(user.ID, user.Username, user.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	if familyID == "" {
+		if familyID, err = utils.RandomToken(16); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	record := &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(issuer.RefreshTTL()),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if err := tokens.WithTx(tx).CreateRefreshToken(record); err != nil {
+		return nil, nil, err
+	}
+
+	return record, &TokenResponse{
+		Token:            accessToken,
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// revokeSessions logs a user out everywhere: their refresh tokens stop
+// working and every access token issued so far is rejected.
+func revokeSessions(tx *repository.Tx, users repository.UserStore, tokens repository.TokenStore, userID uint, now time.Time) error {
+	if err := tokens.WithTx(tx).RevokeAllForUser(userID, now); err != nil {
+		return err
+	}
+	return users.WithTx(tx).RevokeSessions(userID, now)
 }
 
-func Register(c *gin.Context) {
-	handler := NewAuthHandler()
-	
+func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, err.Error())
 		return
 	}
 
-	existingUser, _ := handler.userRepo.GetByUsername(req.Username)
+	if err := utils.ValidatePassword(req.Password, h.auth.PasswordMinLength, req.Username, req.Email); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	existingUser, _ := h.userRepo.GetByUsername(req.Username)
 	if existingUser != nil {
 		utils.ErrorResponse(c, http.StatusConflict, "Username already exists")
 		return
 	}
 
-	existingEmail, _ := handler.userRepo.GetByEmail(req.Email)
+	existingEmail, _ := h.userRepo.GetByEmail(req.Email)
 	if existingEmail != nil {
 		utils.ErrorResponse(c, http.StatusConflict, "Email already exists")
 		return
 	}
 
-	if req.Role == "" {
-		req.Role = "user"
-	}
-
 	user := &models.User{
 		Username: req.Username,
 		Email:    req.Email,
 		Password: req.Password,
-		Role:     req.Role,
+		Role:     models.RoleMember,
 	}
 
-	if err := handler.userRepo.Create(user); err != nil {
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.userRepo.WithTx(tx).Create(user); err != nil {
+			return err
+		}
+		return h.auditAs(tx, c, user.ID, models.AuditRegister, models.AuditEntityUser, user.ID, nil, user, nil)
+	})
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create user")
 		return
 	}
@@ -77,36 +186,412 @@ func Register(c *gin.Context) {
 	utils.SuccessResponse(c, "User registered successfully", user)
 }
 
-func Login(c *gin.Context) {
-	handler := NewAuthHandler()
-	
+// tooManyAttempts answers a login attempt the guard is holding back.
+func tooManyAttempts(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	utils.ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("Too many failed login attempts; try again in %d seconds", seconds))
+}
+
+// Login checks a username and password. Failed attempts are counted per
+// username and per client IP by the guard, which answers 429 with a
+// Retry-After header while either is throttled.
+func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, err.Error())
 		return
 	}
 
-	user, err := handler.userRepo.GetByUsername(req.Username)
+	ip := c.ClientIP()
+	now := time.Now()
+	wait, err := h.guard.Check(req.Username, ip, now)
 	if err != nil {
-		utils.UnauthorizedResponse(c, "Invalid credentials")
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check login attempts")
+		return
+	}
+	if wait > 0 {
+		tooManyAttempts(c, wait)
 		return
 	}
 
-	if !user.CheckPassword(req.Password) {
+	user, err := h.userRepo.GetByUsername(req.Username)
+	if err == nil && !user.CheckPassword(req.Password) {
+		err = errInvalidCredentials
+	}
+	if err != nil {
+		if err := h.guard.Fail(req.Username, ip, c.GetString("request_id"), now); err != nil {
+			log.Printf("failed to record login failure: %v", err)
+		}
+		if err := h.auditAs(nil, c, 0, models.AuditLoginFailed, loginguard.EntityAccount, req.Username, nil, nil, nil); err != nil {
+			log.Printf("failed to audit login failure: %v", err)
+		}
 		utils.UnauthorizedResponse(c, "Invalid credentials")
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	if !user.IsActive {
+		utils.ErrorResponse(c, http.StatusForbidden, "Account is deactivated")
+		return
+	}
+
+	if needsSecondFactor(h.auth, user) {
+		challenge, err := mfaChallenge(h.issuer, h.auth, user)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+			return
+		}
+		utils.SuccessResponse(c, "Two-factor authentication required", challenge)
+		return
+	}
+
+	var tokens *TokenResponse
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if _, tokens, err = issueTokens(c, tx, h.issuer, h.tokenRepo, user, ""); err != nil {
+			return err
+		}
+		return h.auditAs(tx, c, user.ID, models.AuditLogin, models.AuditEntityUser, user.ID, nil, nil, nil)
+	})
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
+	// The account's failures are only cleared once tokens are issued. A
+	// correct password alone must not reset them, or whoever knows it
+	// could log in between guesses at the second factor and never be
+	// locked out.
+	if err := h.guard.Succeed(req.Username); err != nil {
+		log.Printf("failed to clear login failures: %v", err)
+	}
+
 	response := LoginResponse{
-		Token: token,
-		User:  *user,
+		TokenResponse: *tokens,
+		User:          *user,
 	}
 
 	utils.SuccessResponse(c, "Login successful", response)
 }
+
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	var tokens *TokenResponse
+	reused := false
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		tokenRepo := h.tokenRepo.WithTx(tx)
+		now := time.Now()
+
+		current, err := tokenRepo.GetRefreshTokenForUpdate(utils.HashToken(req.RefreshToken))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repository.ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			// A rotated-out token came back: someone else holds a copy.
+			// End the whole session and let the transaction commit.
+			reused = true
+			return tokenRepo.RevokeFamily(current.FamilyID, now)
+		}
+		if !current.IsActive(now) {
+			return repository.ErrInvalidRefreshToken
+		}
+
+		user, err := h.userRepo.WithTx(tx).GetByID(current.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repository.ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		if !user.IsActive || (h.auth.MFARequired(user.Role) && !user.TOTPEnabled) {
+			return repository.ErrInvalidRefreshToken
+		}
+
+		next, issued, err := issueTokens(c, tx, h.issuer, h.tokenRepo, user, current.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		current.RevokedAt = &now
+		current.ReplacedByID = &next.ID
+		if err := tokenRepo.UpdateRefreshToken(current); err != nil {
+			return err
+		}
+		tokens = issued
+		return nil
+	})
+	if reused {
+		utils.UnauthorizedResponse(c, "Refresh token was already used; please log in again")
+		return
+	}
+	if errors.Is(err, repository.ErrInvalidRefreshToken) {
+		utils.UnauthorizedResponse(c, "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+
+	utils.SuccessResponse(c, "Token refreshed successfully", tokens)
+}
+
+// Logout ends the caller's current session: the access token used for the
+// request is revoked, and so is the session of the refresh token, if given.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+	}
+
+	userID := currentUserID(c)
+	tokenID := c.GetString("token_id")
+	expiresAt := c.GetTime("token_expires_at")
+
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		tokenRepo := h.tokenRepo.WithTx(tx)
+		if err := tokenRepo.RevokeAccessToken(tokenID, userID, expiresAt); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditLogout, models.AuditEntityUser, userID, nil, nil, nil); err != nil {
+			return err
+		}
+		if req.RefreshToken == "" {
+			return nil
+		}
+
+		refresh, err := tokenRepo.GetRefreshTokenForUpdate(utils.HashToken(req.RefreshToken))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if refresh.UserID != userID {
+			return nil
+		}
+		return tokenRepo.RevokeFamily(refresh.FamilyID, time.Now())
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	utils.SuccessResponse(c, "Logged out successfully", nil)
+}
+
+// LogoutAll ends every session of the caller.
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		if err := revokeSessions(tx, h.userRepo, h.tokenRepo, currentUserID(c), time.Now()); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditLogoutAll, models.AuditEntityUser, currentUserID(c), nil, nil, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	utils.SuccessResponse(c, "Logged out of all sessions successfully", nil)
+}
+
+// ChangePassword sets a new password for the caller. Every other session
+// ends; the response carries fresh tokens for the current client.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetByID(currentUserID(c))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	if !user.CheckPassword(req.CurrentPassword) {
+		utils.ValidationErrorResponse(c, "Current password is incorrect")
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		utils.ValidationErrorResponse(c, "New password must differ from the current one")
+		return
+	}
+	if err := utils.ValidatePassword(req.NewPassword, h.auth.PasswordMinLength, user.Username, user.Email); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	var tokens *TokenResponse
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		now := time.Now()
+		if err := h.userRepo.WithTx(tx).UpdatePassword(user, req.NewPassword); err != nil {
+			return err
+		}
+		if err := h.tokenRepo.WithTx(tx).UsePasswordResetTokens(user.ID, now); err != nil {
+			return err
+		}
+		if err := revokeSessions(tx, h.userRepo, h.tokenRepo, user.ID, now); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditPasswordChange, models.AuditEntityUser, user.ID, nil, nil, nil); err != nil {
+			return err
+		}
+		_, tokens, err = issueTokens(c, tx, h.issuer, h.tokenRepo, user, "")
+		return err
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	utils.SuccessResponse(c, "Password changed successfully", tokens)
+}
+
+// ForgotPassword emails a single-use reset link to the account with the
+// given email. The response is the same whether or not such an account
+// exists, so it cannot be used to find out who is registered.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	const message = "If the email is registered, a password reset link has been sent"
+
+	user, err := h.userRepo.GetByEmail(req.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !user.IsActive) {
+		utils.SuccessResponse(c, message, nil)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+	ttl := h.auth.PasswordResetTTL
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		now := time.Now()
+		tokenRepo := h.tokenRepo.WithTx(tx)
+		if err := tokenRepo.UsePasswordResetTokens(user.ID, now); err != nil {
+			return err
+		}
+		err := tokenRepo.CreatePasswordResetToken(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(ttl),
+			IPAddress: c.ClientIP(),
+		})
+		if err != nil {
+			return err
+		}
+		return h.auditAs(tx, c, 0, models.AuditPasswordResetRequest, models.AuditEntityUser, user.ID, nil, nil, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	msg, err := notification.PasswordResetMessage(user, token, h.auth.PasswordResetURL, ttl, req.Language)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	// Sending in the background keeps the response time the same for
+	// known and unknown addresses.
+	go func() {
+		if err := h.sender.Send(context.Background(), msg); err != nil {
+			log.Printf("password reset email for user %d failed: %v", user.ID, err)
+		}
+	}()
+
+	utils.SuccessResponse(c, message, nil)
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. The
+// token is used up, and every session of the account ends.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	var policyErr error
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		tokenRepo := h.tokenRepo.WithTx(tx)
+		now := time.Now()
+
+		reset, err := tokenRepo.GetPasswordResetTokenForUpdate(utils.HashToken(req.Token))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repository.ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+		if !reset.IsUsable(now) {
+			return repository.ErrInvalidResetToken
+		}
+
+		user, err := h.userRepo.WithTx(tx).GetByID(reset.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repository.ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+		if !user.IsActive {
+			return repository.ErrInvalidResetToken
+		}
+
+		if policyErr = utils.ValidatePassword(req.NewPassword, h.auth.PasswordMinLength, user.Username, user.Email); policyErr != nil {
+			return policyErr
+		}
+
+		if err := h.userRepo.WithTx(tx).UpdatePassword(user, req.NewPassword); err != nil {
+			return err
+		}
+		if err := tokenRepo.UsePasswordResetTokens(user.ID, now); err != nil {
+			return err
+		}
+		if err := revokeSessions(tx, h.userRepo, h.tokenRepo, user.ID, now); err != nil {
+			return err
+		}
+		return h.auditAs(tx, c, user.ID, models.AuditPasswordReset, models.AuditEntityUser, user.ID, nil, nil, nil)
+	})
+	if policyErr != nil {
+		utils.ValidationErrorResponse(c, policyErr.Error())
+		return
+	}
+	if errors.Is(err, repository.ErrInvalidResetToken) {
+		utils.ValidationErrorResponse(c, "Invalid or expired reset token")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	utils.SuccessResponse(c, "Password reset successfully; please log in with the new password", nil)
+}
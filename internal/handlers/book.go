@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -12,24 +13,31 @@ import (
 )
 
 type BookHandler struct {
-	bookRepo *repository.BookRepository
+	auditor
+	db       repository.Transactor
+	bookRepo repository.BookStore
+	copyRepo repository.BookCopyStore
 }
 
-func NewBookHandler() *BookHandler {
+func NewBookHandler(stores *repository.Stores) *BookHandler {
 	return &BookHandler{
-		bookRepo: repository.NewBookRepository(),
+		db:       stores.DB,
+		auditor:  auditor{auditRepo: stores.Audit},
+		bookRepo: stores.Books,
+		copyRepo: stores.Copies,
 	}
 }
 
 type CreateBookRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Author      string `json:"author" binding:"required"`
-	ISBN        string `json:"isbn" binding:"required"`
-	Publisher   string `json:"publisher"`
-	Year        int    `json:"year"`
-	Category    string `json:"category"`
-	Description string `json:"description"`
-	Stock       int    `json:"stock" binding:"required,min=0"`
+	Title         string `json:"title" binding:"required"`
+	Author        string `json:"author" binding:"required"`
+	ISBN          string `json:"isbn" binding:"required"`
+	Publisher     string `json:"publisher"`
+	Year          int    `json:"year"`
+	Category      string `json:"category"`
+	Description   string `json:"description"`
+	Stock         int    `json:"stock" binding:"min=0"`
+	ShelfLocation string `json:"shelf_location"`
 }
 
 type UpdateBookRequest struct {
@@ -40,24 +48,81 @@ type UpdateBookRequest struct {
 	Year        int    `json:"year"`
 	Category    string `json:"category"`
 	Description string `json:"description"`
-	Stock       int    `json:"stock" binding:"min=0"`
 }
 
-func GetAllBooks(c *gin.Context) {
-	handler := NewBookHandler()
-	
-	books, err := handler.bookRepo.GetAll()
+type BookListQuery struct {
+	Category string `form:"category"`
+	Author   string `form:"author"`
+	YearFrom int    `form:"year_from"`
+	YearTo   int    `form:"year_to"`
+}
+
+var bookSortFields = map[string]string{
+	"id":         "id",
+	"title":      "title",
+	"author":     "author",
+	"year":       "year",
+	"category":   "category",
+	"available":  "available",
+	"created_at": "created_at",
+}
+
+func (h *BookHandler) GetAllBooks(c *gin.Context) {
+	var query BookListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	page, err := parsePage(c, bookSortFields)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	filter := repository.BookFilter{
+		Category: query.Category,
+		Author:   query.Author,
+		YearFrom: query.YearFrom,
+		YearTo:   query.YearTo,
+	}
+
+	books, total, err := h.bookRepo.List(filter, page)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch books")
 		return
 	}
 
-	utils.SuccessResponse(c, "Books retrieved successfully", books)
+	utils.PaginatedResponse(c, "Books retrieved successfully", books, utils.NewPagination(page.Number, page.PerPage, total))
+}
+
+func (h *BookHandler) SearchBooks(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		utils.ValidationErrorResponse(c, "Query parameter q is required")
+		return
+	}
+
+	page, err := parsePage(c, nil)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	results, total, err := h.bookRepo.Search(q, page)
+	if errors.Is(err, repository.ErrEmptySearchQuery) {
+		utils.ValidationErrorResponse(c, "Query must contain at least one letter or digit")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to search books")
+		return
+	}
+
+	utils.PaginatedResponse(c, "Books retrieved successfully", results, utils.NewPagination(page.Number, page.PerPage, total))
 }
 
-func GetBookByID(c *gin.Context) {
-	handler := NewBookHandler()
-	
+func (h *BookHandler) GetBookByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -65,7 +130,7 @@ func GetBookByID(c *gin.Context) {
 		return
 	}
 
-	book, err := handler.bookRepo.GetByID(uint(id))
+	book, err := h.bookRepo.GetByID(uint(id))
 	if err != nil {
 		utils.NotFoundResponse(c, "Book not found")
 		return
@@ -74,16 +139,14 @@ func GetBookByID(c *gin.Context) {
 	utils.SuccessResponse(c, "Book retrieved successfully", book)
 }
 
-func CreateBook(c *gin.Context) {
-	handler := NewBookHandler()
-	
+func (h *BookHandler) CreateBook(c *gin.Context) {
 	var req CreateBookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, err.Error())
 		return
 	}
 
-	existingBook, _ := handler.bookRepo.GetByISBN(req.ISBN)
+	existingBook, _ := h.bookRepo.GetByISBN(req.ISBN)
 	if existingBook != nil {
 		utils.ErrorResponse(c, http.StatusConflict, "Book with this ISBN already exists")
 		return
@@ -97,11 +160,31 @@ func CreateBook(c *gin.Context) {
 		Year:        req.Year,
 		Category:    req.Category,
 		Description: req.Description,
-		Stock:       req.Stock,
-		Available:   req.Stock,
 	}
 
-	if err := handler.bookRepo.Create(book); err != nil {
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		bookRepo := h.bookRepo.WithTx(tx)
+		copyRepo := h.copyRepo.WithTx(tx)
+
+		if err := bookRepo.Create(book); err != nil {
+			return err
+		}
+		for i := 1; i <= req.Stock; i++ {
+			bookCopy := newBookCopy(book.ID, i)
+			bookCopy.ShelfLocation = req.ShelfLocation
+			if err := copyRepo.Create(bookCopy); err != nil {
+				return err
+			}
+		}
+		if err := bookRepo.SyncAvailability(book.ID); err != nil {
+			return err
+		}
+
+		book.Stock = req.Stock
+		book.Available = req.Stock
+		return h.audit(tx, c, models.AuditBookCreate, models.AuditEntityBook, book.ID, nil, book, nil)
+	})
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create book")
 		return
 	}
@@ -109,9 +192,7 @@ func CreateBook(c *gin.Context) {
 	utils.SuccessResponse(c, "Book created successfully", book)
 }
 
-func UpdateBook(c *gin.Context) {
-	handler := NewBookHandler()
-	
+func (h *BookHandler) UpdateBook(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -125,11 +206,12 @@ func UpdateBook(c *gin.Context) {
 		return
 	}
 
-	book, err := handler.bookRepo.GetByID(uint(id))
+	book, err := h.bookRepo.GetByID(uint(id))
 	if err != nil {
 		utils.NotFoundResponse(c, "Book not found")
 		return
 	}
+	before := *book
 
 	if req.Title != "" {
 		book.Title = req.Title
@@ -139,7 +221,7 @@ func UpdateBook(c *gin.Context) {
 	}
 	if req.ISBN != "" {
 		if req.ISBN != book.ISBN {
-			existingBook, _ := handler.bookRepo.GetByISBN(req.ISBN)
+			existingBook, _ := h.bookRepo.GetByISBN(req.ISBN)
 			if existingBook != nil {
 				utils.ErrorResponse(c, http.StatusConflict, "Book with this ISBN already exists")
 				return
@@ -159,16 +241,14 @@ func UpdateBook(c *gin.Context) {
 	if req.Description != "" {
 		book.Description = req.Description
 	}
-	if req.Stock >= 0 {
-		stockDiff := req.Stock - book.Stock
-		book.Stock = req.Stock
-		book.Available += stockDiff
-		if book.Available < 0 {
-			book.Available = 0
-		}
-	}
 
-	if err := handler.bookRepo.Update(book); err != nil {
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.bookRepo.WithTx(tx).Update(book); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditBookUpdate, models.AuditEntityBook, book.ID, before, book, nil)
+	})
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update book")
 		return
 	}
@@ -176,9 +256,7 @@ func UpdateBook(c *gin.Context) {
 	utils.SuccessResponse(c, "Book updated successfully", book)
 }
 
-func DeleteBook(c *gin.Context) {
-	handler := NewBookHandler()
-	
+func (h *BookHandler) DeleteBook(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -186,13 +264,19 @@ func DeleteBook(c *gin.Context) {
 		return
 	}
 
-	_, err = handler.bookRepo.GetByID(uint(id))
+	book, err := h.bookRepo.GetByID(uint(id))
 	if err != nil {
 		utils.NotFoundResponse(c, "Book not found")
 		return
 	}
 
-	if err := handler.bookRepo.Delete(uint(id)); err != nil {
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.bookRepo.WithTx(tx).Delete(book.ID); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditBookDelete, models.AuditEntityBook, book.ID, book, nil, nil)
+	})
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete book")
 		return
 	}
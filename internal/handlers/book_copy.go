@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"library-management-system/internal/clock"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BookCopyHandler struct {
+	auditor
+	db       repository.Transactor
+	bookRepo repository.BookStore
+	copyRepo repository.BookCopyStore
+	loanRepo repository.LoanStore
+	holdRepo repository.HoldStore
+	clock    clock.Clock
+}
+
+func NewBookCopyHandler(stores *repository.Stores, clk clock.Clock) *BookCopyHandler {
+	return &BookCopyHandler{
+		db:       stores.DB,
+		auditor:  auditor{auditRepo: stores.Audit},
+		bookRepo: stores.Books,
+		copyRepo: stores.Copies,
+		loanRepo: stores.Loans,
+		holdRepo: stores.Holds,
+		clock:    clk,
+	}
+}
+
+type CreateBookCopyRequest struct {
+	Barcode         string `json:"barcode"`
+	AccessionNumber string `json:"accession_number"`
+	ShelfLocation   string `json:"shelf_location"`
+	Condition       string `json:"condition"`
+	Notes           string `json:"notes"`
+}
+
+type UpdateBookCopyRequest struct {
+	ShelfLocation string `json:"shelf_location"`
+	Condition     string `json:"condition"`
+	Status        string `json:"status"`
+	Notes         string `json:"notes"`
+}
+
+// newBookCopy builds the seq-th copy of a book with a generated barcode and
+// accession number.
+func newBookCopy(bookID uint, seq int) *models.BookCopy {
+	return &models.BookCopy{
+		BookID:          bookID,
+		Barcode:         fmt.Sprintf("%08d%04d", bookID, seq),
+		AccessionNumber: fmt.Sprintf("ACC-%06d-%04d", bookID, seq),
+		Condition:       models.CopyConditionGood,
+		Status:          models.CopyStatusAvailable,
+	}
+}
+
+func (h *BookCopyHandler) GetBookCopies(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid book ID")
+		return
+	}
+
+	if _, err := h.bookRepo.GetByID(uint(id)); err != nil {
+		utils.NotFoundResponse(c, "Book not found")
+		return
+	}
+
+	copies, err := h.copyRepo.GetByBookID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch book copies")
+		return
+	}
+
+	utils.SuccessResponse(c, "Book copies retrieved successfully", copies)
+}
+
+func (h *BookCopyHandler) CreateBookCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid book ID")
+		return
+	}
+
+	var req CreateBookCopyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if req.Condition != "" && !models.IsValidCopyCondition(req.Condition) {
+		utils.ValidationErrorResponse(c, "Invalid condition")
+		return
+	}
+
+	if _, err := h.bookRepo.GetByID(uint(id)); err != nil {
+		utils.NotFoundResponse(c, "Book not found")
+		return
+	}
+
+	if req.Barcode != "" {
+		existingCopy, _ := h.copyRepo.GetByBarcode(req.Barcode)
+		if existingCopy != nil {
+			utils.ErrorResponse(c, http.StatusConflict, "Book copy with this barcode already exists")
+			return
+		}
+	}
+
+	var bookCopy *models.BookCopy
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		bookRepo := h.bookRepo.WithTx(tx)
+		copyRepo := h.copyRepo.WithTx(tx)
+
+		if _, err := bookRepo.GetByIDForUpdate(uint(id)); err != nil {
+			return err
+		}
+
+		seq, err := copyRepo.NextSequence(uint(id))
+		if err != nil {
+			return err
+		}
+
+		bookCopy = newBookCopy(uint(id), seq)
+		if req.Barcode != "" {
+			bookCopy.Barcode = req.Barcode
+		}
+		if req.AccessionNumber != "" {
+			bookCopy.AccessionNumber = req.AccessionNumber
+		}
+		if req.Condition != "" {
+			bookCopy.Condition = req.Condition
+		}
+		bookCopy.ShelfLocation = req.ShelfLocation
+		bookCopy.Notes = req.Notes
+
+		if err := copyRepo.Create(bookCopy); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditBookCopyCreate, models.AuditEntityBookCopy, bookCopy.ID, nil, bookCopy, nil); err != nil {
+			return err
+		}
+		if _, err := h.holdRepo.WithTx(tx).PassCopyOn(bookCopy.ID, bookCopy.BookID, h.clock.Now()); err != nil {
+			return err
+		}
+		return bookRepo.SyncAvailability(uint(id))
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create book copy")
+		return
+	}
+
+	utils.SuccessResponse(c, "Book copy created successfully", bookCopy)
+}
+
+func (h *BookCopyHandler) GetBookCopyByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid book copy ID")
+		return
+	}
+
+	bookCopy, err := h.copyRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "Book copy not found")
+		return
+	}
+
+	utils.SuccessResponse(c, "Book copy retrieved successfully", bookCopy)
+}
+
+func (h *BookCopyHandler) GetBookCopyByBarcode(c *gin.Context) {
+	bookCopy, err := h.copyRepo.GetByBarcode(c.Param("barcode"))
+	if err != nil {
+		utils.NotFoundResponse(c, "Book copy not found")
+		return
+	}
+
+	utils.SuccessResponse(c, "Book copy retrieved successfully", bookCopy)
+}
+
+func (h *BookCopyHandler) UpdateBookCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid book copy ID")
+		return
+	}
+
+	var req UpdateBookCopyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if req.Condition != "" && !models.IsValidCopyCondition(req.Condition) {
+		utils.ValidationErrorResponse(c, "Invalid condition")
+		return
+	}
+
+	switch req.Status {
+	case "", models.CopyStatusAvailable, models.CopyStatusDamaged, models.CopyStatusLost, models.CopyStatusWithdrawn:
+	default:
+		utils.ValidationErrorResponse(c, "Status must be one of available, damaged, lost or withdrawn")
+		return
+	}
+
+	existing, err := h.copyRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "Book copy not found")
+		return
+	}
+
+	var bookCopy *models.BookCopy
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		bookRepo := h.bookRepo.WithTx(tx)
+		copyRepo := h.copyRepo.WithTx(tx)
+
+		if _, err := bookRepo.GetByIDForUpdate(existing.BookID); err != nil {
+			return err
+		}
+
+		var err error
+		bookCopy, err = copyRepo.GetByIDForUpdate(uint(id))
+		if err != nil {
+			return err
+		}
+		before := *bookCopy
+
+		backOnShelf := false
+		if req.Status != "" && req.Status != bookCopy.Status {
+			// A borrowed copy comes back through the return endpoint; it
+			// can only be written off as lost while out.
+			if bookCopy.Status == models.CopyStatusOnLoan && req.Status != models.CopyStatusLost {
+				return repository.ErrCopyOnLoan
+			}
+			if bookCopy.Status == models.CopyStatusOnHold {
+				return repository.ErrCopyOnHold
+			}
+			backOnShelf = req.Status == models.CopyStatusAvailable
+			bookCopy.Status = req.Status
+		}
+		if backOnShelf {
+			// A copy written off as lost still belongs to its loan until
+			// that loan is returned; shelving it would let it be lent twice.
+			open, err := h.loanRepo.WithTx(tx).CountActiveByCopy(bookCopy.ID)
+			if err != nil {
+				return err
+			}
+			if open > 0 {
+				return repository.ErrCopyLoanOpen
+			}
+		}
+		if req.ShelfLocation != "" {
+			bookCopy.ShelfLocation = req.ShelfLocation
+		}
+		if req.Condition != "" {
+			bookCopy.Condition = req.Condition
+		}
+		if req.Notes != "" {
+			bookCopy.Notes = req.Notes
+		}
+
+		if err := copyRepo.Update(bookCopy); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditBookCopyUpdate, models.AuditEntityBookCopy, bookCopy.ID, before, bookCopy, nil); err != nil {
+			return err
+		}
+		if backOnShelf {
+			if _, err := h.holdRepo.WithTx(tx).PassCopyOn(bookCopy.ID, bookCopy.BookID, h.clock.Now()); err != nil {
+				return err
+			}
+		}
+		return bookRepo.SyncAvailability(bookCopy.BookID)
+	})
+	if errors.Is(err, repository.ErrCopyOnLoan) {
+		utils.ErrorResponse(c, http.StatusConflict, "Book copy is on loan; return it first")
+		return
+	}
+	if errors.Is(err, repository.ErrCopyOnHold) {
+		utils.ErrorResponse(c, http.StatusConflict, "Book copy is set aside for a hold; cancel the hold first")
+		return
+	}
+	if errors.Is(err, repository.ErrCopyLoanOpen) {
+		utils.ErrorResponse(c, http.StatusConflict, "Book copy is still on an open loan; return the loan first")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update book copy")
+		return
+	}
+
+	utils.SuccessResponse(c, "Book copy updated successfully", bookCopy)
+}
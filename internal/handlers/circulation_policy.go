@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CirculationPolicyHandler struct {
+	auditor
+	db         repository.Transactor
+	policyRepo repository.CirculationPolicyStore
+}
+
+func NewCirculationPolicyHandler(stores *repository.Stores) *CirculationPolicyHandler {
+	return &CirculationPolicyHandler{
+		db:         stores.DB,
+		auditor:    auditor{auditRepo: stores.Audit},
+		policyRepo: stores.Policies,
+	}
+}
+
+type CirculationPolicyRequest struct {
+	Name           string `json:"name" binding:"required"`
+	MemberType     string `json:"member_type"`
+	Category       string `json:"category"`
+	LoanPeriodDays int    `json:"loan_period_days" binding:"required,min=1"`
+	MaxLoans       int    `json:"max_loans" binding:"required,min=1"`
+	MaxRenewals    int    `json:"max_renewals" binding:"min=0"`
+	DailyFine      int64  `json:"daily_fine" binding:"min=0"`
+	GraceDays      int    `json:"grace_days" binding:"min=0"`
+	MaxFine        int64  `json:"max_fine" binding:"min=0"`
+}
+
+func (req *CirculationPolicyRequest) apply(policy *models.CirculationPolicy) {
+	policy.Name = req.Name
+	policy.MemberType = req.MemberType
+	policy.Category = req.Category
+	policy.LoanPeriodDays = req.LoanPeriodDays
+	policy.MaxLoans = req.MaxLoans
+	policy.MaxRenewals = req.MaxRenewals
+	policy.DailyFine = req.DailyFine
+	policy.GraceDays = req.GraceDays
+	policy.MaxFine = req.MaxFine
+}
+
+func (h *CirculationPolicyHandler) GetAllCirculationPolicies(c *gin.Context) {
+	policies, err := h.policyRepo.GetAll()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch circulation policies")
+		return
+	}
+
+	utils.SuccessResponse(c, "Circulation policies retrieved successfully", policies)
+}
+
+func (h *CirculationPolicyHandler) GetCirculationPolicyByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid policy ID")
+		return
+	}
+
+	policy, err := h.policyRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "Circulation policy not found")
+		return
+	}
+
+	utils.SuccessResponse(c, "Circulation policy retrieved successfully", policy)
+}
+
+// ResolveCirculationPolicy shows which policy applies to a member type and
+// book category, including the built-in default.
+func (h *CirculationPolicyHandler) ResolveCirculationPolicy(c *gin.Context) {
+	policy, err := h.policyRepo.Resolve(c.Query("member_type"), c.Query("category"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to resolve circulation policy")
+		return
+	}
+
+	utils.SuccessResponse(c, "Circulation policy resolved successfully", policy)
+}
+
+func (h *CirculationPolicyHandler) CreateCirculationPolicy(c *gin.Context) {
+	var req CirculationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	existing, _ := h.policyRepo.GetByScope(req.MemberType, req.Category)
+	if existing != nil {
+		utils.ErrorResponse(c, http.StatusConflict, "A policy for this member type and category already exists")
+		return
+	}
+
+	policy := &models.CirculationPolicy{}
+	req.apply(policy)
+
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.policyRepo.WithTx(tx).Create(policy); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditPolicyCreate, models.AuditEntityPolicy, policy.ID, nil, policy, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create circulation policy")
+		return
+	}
+
+	utils.SuccessResponse(c, "Circulation policy created successfully", policy)
+}
+
+func (h *CirculationPolicyHandler) UpdateCirculationPolicy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid policy ID")
+		return
+	}
+
+	var req CirculationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	policy, err := h.policyRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "Circulation policy not found")
+		return
+	}
+
+	existing, _ := h.policyRepo.GetByScope(req.MemberType, req.Category)
+	if existing != nil && existing.ID != policy.ID {
+		utils.ErrorResponse(c, http.StatusConflict, "A policy for this member type and category already exists")
+		return
+	}
+
+	before := *policy
+	req.apply(policy)
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.policyRepo.WithTx(tx).Update(policy); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditPolicyUpdate, models.AuditEntityPolicy, policy.ID, before, policy, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update circulation policy")
+		return
+	}
+
+	utils.SuccessResponse(c, "Circulation policy updated successfully", policy)
+}
+
+func (h *CirculationPolicyHandler) DeleteCirculationPolicy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid policy ID")
+		return
+	}
+
+	policy, err := h.policyRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "Circulation policy not found")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.policyRepo.WithTx(tx).Delete(policy.ID); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditPolicyDelete, models.AuditEntityPolicy, policy.ID, policy, nil, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete circulation policy")
+		return
+	}
+
+	utils.SuccessResponse(c, "Circulation policy deleted successfully", nil)
+}
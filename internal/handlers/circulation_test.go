@@ -0,0 +1,274 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"library-management-system/internal/clock"
+	"library-management-system/internal/config"
+	"library-management-system/internal/handlers"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// desk is the circulation desk on in-memory stores: the loan, hold, copy
+// and fine handlers, called as user 1.
+type desk struct {
+	t      *testing.T
+	stores *repository.Stores
+	clock  *clock.Fake
+	router *gin.Engine
+}
+
+func newDesk(t *testing.T) *desk {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Default()
+	d := &desk{
+		t:      t,
+		stores: memory.NewStores(cfg.Circulation),
+		clock:  clock.NewFake(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)),
+		router: gin.New(),
+	}
+
+	loans := handlers.NewLoanHandler(d.stores, cfg.Circulation, d.clock)
+	holds := handlers.NewHoldHandler(d.stores, d.clock)
+	copies := handlers.NewBookCopyHandler(d.stores, d.clock)
+	fines := handlers.NewFineHandler(d.stores)
+
+	d.router.Use(func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	d.router.POST("/loans", loans.CreateLoan)
+	d.router.PUT("/loans/:id/return", loans.ReturnBook)
+	d.router.POST("/holds", holds.CreateHold)
+	d.router.PUT("/copies/:id", copies.UpdateBookCopy)
+	d.router.POST("/fines/payments", fines.RecordFinePayment)
+	return d
+}
+
+// book adds a book with the given number of available copies.
+func (d *desk) book(copies int) *models.Book {
+	d.t.Helper()
+	book := &models.Book{Title: "Laskar Pelangi", Author: "Andrea Hirata", ISBN: "9789793062792"}
+	if err := d.stores.Books.Create(book); err != nil {
+		d.t.Fatal(err)
+	}
+	for i := 1; i <= copies; i++ {
+		bookCopy := &models.BookCopy{BookID: book.ID, Barcode: fmt.Sprintf("B%d-%d", book.ID, i), AccessionNumber: fmt.Sprintf("A%d-%d", book.ID, i)}
+		if err := d.stores.Copies.Create(bookCopy); err != nil {
+			d.t.Fatal(err)
+		}
+	}
+	if err := d.stores.Books.SyncAvailability(book.ID); err != nil {
+		d.t.Fatal(err)
+	}
+	return book
+}
+
+func (d *desk) member(name string) *models.Member {
+	d.t.Helper()
+	member := &models.Member{Name: name, Email: name + "@example.com", MemberCode: "M-" + name}
+	if err := d.stores.Members.Create(member); err != nil {
+		d.t.Fatal(err)
+	}
+	return member
+}
+
+// do sends body as JSON and decodes the response's data into out, when
+// out is not nil.
+func (d *desk) do(method, path string, body, out interface{}) int {
+	d.t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	d.router.ServeHTTP(rec, req)
+
+	if out != nil && rec.Code == http.StatusOK {
+		envelope := struct{ Data json.RawMessage }{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+			d.t.Fatal(err)
+		}
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			d.t.Fatal(err)
+		}
+	}
+	return rec.Code
+}
+
+func (d *desk) checkout(book *models.Book, member *models.Member) *models.Loan {
+	d.t.Helper()
+	var loan models.Loan
+	if code := d.do(http.MethodPost, "/loans", gin.H{"book_id": book.ID, "member_id": member.ID}, &loan); code != http.StatusOK {
+		d.t.Fatalf("checkout: status %d", code)
+	}
+	return &loan
+}
+
+func (d *desk) available(book *models.Book) int {
+	d.t.Helper()
+	current, err := d.stores.Books.GetByID(book.ID)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	return current.Available
+}
+
+func (d *desk) copyStatus(id uint) string {
+	d.t.Helper()
+	bookCopy, err := d.stores.Copies.GetByID(id)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	return bookCopy.Status
+}
+
+func (d *desk) notices(event string) []models.Notification {
+	d.t.Helper()
+	notices, _, err := d.stores.Notifications.List(repository.NotificationFilter{Event: event}, repository.Page{})
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	return notices
+}
+
+func TestCheckoutAndLateReturn(t *testing.T) {
+	d := newDesk(t)
+	book := d.book(1)
+	member := d.member("siti")
+
+	loan := d.checkout(book, member)
+	if loan.CopyID == nil {
+		t.Fatal("loan has no copy")
+	}
+	if got := d.copyStatus(*loan.CopyID); got != models.CopyStatusOnLoan {
+		t.Errorf("copy status = %q, want %q", got, models.CopyStatusOnLoan)
+	}
+	if got := d.available(book); got != 0 {
+		t.Errorf("available = %d, want 0", got)
+	}
+
+	if code := d.do(http.MethodPost, "/loans", gin.H{"book_id": book.ID, "member_id": d.member("budi").ID}, nil); code != http.StatusBadRequest {
+		t.Errorf("second checkout of the only copy: status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	d.clock.Set(loan.DueDate.AddDate(0, 0, 3))
+	var returned models.Loan
+	if code := d.do(http.MethodPut, fmt.Sprintf("/loans/%d/return", loan.ID), nil, &returned); code != http.StatusOK {
+		t.Fatalf("return: status %d", code)
+	}
+	daily := models.DefaultCirculationPolicy().DailyFine
+	if returned.Fine != 3*daily {
+		t.Errorf("fine = %d, want %d", returned.Fine, 3*daily)
+	}
+	if got := d.available(book); got != 1 {
+		t.Errorf("available after return = %d, want 1", got)
+	}
+	if got := len(d.notices(models.NotificationFineAssessed)); got != 1 {
+		t.Errorf("%d fine_assessed notices, want 1", got)
+	}
+
+	balance, err := d.stores.Fines.Balance(member.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Balance != 3*daily {
+		t.Fatalf("balance = %d, want %d", balance.Balance, 3*daily)
+	}
+	payment := gin.H{"member_id": member.ID, "amount": balance.Balance + 1, "method": models.PaymentMethodCash}
+	if code := d.do(http.MethodPost, "/fines/payments", payment, nil); code != http.StatusBadRequest {
+		t.Errorf("overpayment: status %d, want %d", code, http.StatusBadRequest)
+	}
+	payment["amount"] = balance.Balance
+	var paid struct {
+		Payment models.FineEntry   `json:"payment"`
+		Balance models.FineBalance `json:"balance"`
+	}
+	if code := d.do(http.MethodPost, "/fines/payments", payment, &paid); code != http.StatusOK {
+		t.Fatalf("payment: status %d", code)
+	}
+	if paid.Balance.Balance != 0 || paid.Payment.ReceiptNumber == nil {
+		t.Errorf("after payment: balance %d, receipt %v", paid.Balance.Balance, paid.Payment.ReceiptNumber)
+	}
+}
+
+func TestReturnPassesCopyToHold(t *testing.T) {
+	d := newDesk(t)
+	book := d.book(1)
+	borrower, waiting := d.member("siti"), d.member("budi")
+
+	loan := d.checkout(book, borrower)
+	var hold models.Hold
+	if code := d.do(http.MethodPost, "/holds", gin.H{"book_id": book.ID, "member_id": waiting.ID}, &hold); code != http.StatusOK {
+		t.Fatalf("hold: status %d", code)
+	}
+
+	if code := d.do(http.MethodPut, fmt.Sprintf("/loans/%d/return", loan.ID), nil, nil); code != http.StatusOK {
+		t.Fatalf("return: status %d", code)
+	}
+	ready, err := d.stores.Holds.GetByID(hold.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ready.Status != models.HoldStatusReady || ready.CopyID == nil || *ready.CopyID != *loan.CopyID {
+		t.Fatalf("hold after return: status %q, copy %v", ready.Status, ready.CopyID)
+	}
+	if got := d.copyStatus(*loan.CopyID); got != models.CopyStatusOnHold {
+		t.Errorf("copy status = %q, want %q", got, models.CopyStatusOnHold)
+	}
+	if got := d.available(book); got != 0 {
+		t.Errorf("available = %d, want 0", got)
+	}
+	if got := len(d.notices(models.NotificationHoldReady)); got != 1 {
+		t.Errorf("%d hold_ready notices, want 1", got)
+	}
+
+	if code := d.do(http.MethodPost, "/loans", gin.H{"book_id": book.ID, "member_id": borrower.ID}, nil); code != http.StatusBadRequest {
+		t.Errorf("checkout by someone else: status %d, want %d", code, http.StatusBadRequest)
+	}
+	collected := d.checkout(book, waiting)
+	if *collected.CopyID != *loan.CopyID {
+		t.Errorf("collected copy %d, want the one set aside, %d", *collected.CopyID, *loan.CopyID)
+	}
+	if fulfilled, _ := d.stores.Holds.GetByID(hold.ID); fulfilled.Status != models.HoldStatusFulfilled {
+		t.Errorf("hold status = %q, want %q", fulfilled.Status, models.HoldStatusFulfilled)
+	}
+}
+
+func TestLostCopyStaysOffShelfWhileLoanOpen(t *testing.T) {
+	d := newDesk(t)
+	book := d.book(1)
+	loan := d.checkout(book, d.member("siti"))
+	copyPath := fmt.Sprintf("/copies/%d", *loan.CopyID)
+
+	if code := d.do(http.MethodPut, copyPath, gin.H{"status": models.CopyStatusAvailable}, nil); code != http.StatusConflict {
+		t.Errorf("shelving a copy on loan: status %d, want %d", code, http.StatusConflict)
+	}
+	if code := d.do(http.MethodPut, copyPath, gin.H{"status": models.CopyStatusLost}, nil); code != http.StatusOK {
+		t.Fatalf("marking lost: status %d", code)
+	}
+	if code := d.do(http.MethodPut, copyPath, gin.H{"status": models.CopyStatusAvailable}, nil); code != http.StatusConflict {
+		t.Errorf("shelving a lost copy with an open loan: status %d, want %d", code, http.StatusConflict)
+	}
+	if got := d.available(book); got != 0 {
+		t.Errorf("available = %d, want 0", got)
+	}
+
+	if code := d.do(http.MethodPut, fmt.Sprintf("/loans/%d/return", loan.ID), nil, nil); code != http.StatusOK {
+		t.Fatalf("return: status %d", code)
+	}
+	if got := d.copyStatus(*loan.CopyID); got != models.CopyStatusAvailable {
+		t.Errorf("copy status after return = %q, want %q", got, models.CopyStatusAvailable)
+	}
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FineHandler struct {
+	auditor
+	db               repository.Transactor
+	fineRepo         repository.FineStore
+	memberRepo       repository.MemberStore
+	loanRepo         repository.LoanStore
+	notificationRepo repository.NotificationStore
+}
+
+func NewFineHandler(stores *repository.Stores) *FineHandler {
+	return &FineHandler{
+		db:               stores.DB,
+		auditor:          auditor{auditRepo: stores.Audit},
+		fineRepo:         stores.Fines,
+		memberRepo:       stores.Members,
+		loanRepo:         stores.Loans,
+		notificationRepo: stores.Notifications,
+	}
+}
+
+type FineChargeRequest struct {
+	MemberID uint   `json:"member_id" binding:"required"`
+	LoanID   *uint  `json:"loan_id"`
+	Amount   int64  `json:"amount" binding:"required,min=1"`
+	Reason   string `json:"reason" binding:"required"`
+}
+
+type FinePaymentRequest struct {
+	MemberID  uint   `json:"member_id" binding:"required"`
+	LoanID    *uint  `json:"loan_id"`
+	Amount    int64  `json:"amount" binding:"required,min=1"`
+	Method    string `json:"method" binding:"required"`
+	Reference string `json:"reference"`
+}
+
+type FineWaiverRequest struct {
+	MemberID uint   `json:"member_id" binding:"required"`
+	LoanID   *uint  `json:"loan_id"`
+	Amount   int64  `json:"amount" binding:"required,min=1"`
+	Reason   string `json:"reason" binding:"required"`
+}
+
+type FineListQuery struct {
+	MemberID uint   `form:"member_id"`
+	LoanID   uint   `form:"loan_id"`
+	Type     string `form:"type"`
+}
+
+var fineSortFields = map[string]string{
+	"id":         "id",
+	"amount":     "amount",
+	"type":       "type",
+	"created_at": "created_at",
+}
+
+// checkFineLoan makes sure an entry's loan, when given, belongs to the member.
+func (h *FineHandler) checkFineLoan(c *gin.Context, memberID uint, loanID *uint) bool {
+	if loanID == nil {
+		return true
+	}
+	loan, err := h.loanRepo.GetByID(*loanID)
+	if err != nil {
+		utils.NotFoundResponse(c, "Loan not found")
+		return false
+	}
+	if loan.MemberID != memberID {
+		utils.ValidationErrorResponse(c, "Loan does not belong to this member")
+		return false
+	}
+	return true
+}
+
+// settle records a payment or waiver, which may not exceed what the member
+// owes. The member's row lock keeps concurrent settlements from both
+// passing the balance check.
+func (h *FineHandler) settle(c *gin.Context, entry *models.FineEntry) (*models.FineBalance, error) {
+	var balance *models.FineBalance
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		fineRepo := h.fineRepo.WithTx(tx)
+
+		if _, err := h.memberRepo.WithTx(tx).GetByIDForUpdate(entry.MemberID); err != nil {
+			return err
+		}
+
+		current, err := fineRepo.Balance(entry.MemberID)
+		if err != nil {
+			return err
+		}
+		if entry.Amount > current.Balance {
+			return repository.ErrAmountExceedsFines
+		}
+
+		action := models.AuditFineWaive
+		if entry.Type == models.FineEntryPayment {
+			action = models.AuditFinePayment
+			err = fineRepo.CreatePayment(entry)
+		} else {
+			err = fineRepo.Create(entry)
+		}
+		if err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, action, models.AuditEntityFine, entry.ID, nil, entry, nil); err != nil {
+			return err
+		}
+
+		balance, err = fineRepo.Balance(entry.MemberID)
+		return err
+	})
+	return balance, err
+}
+
+func (h *FineHandler) GetAllFines(c *gin.Context) {
+	var query FineListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	page, err := parsePage(c, fineSortFields)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	filter := repository.FineFilter{MemberID: query.MemberID, LoanID: query.LoanID, Type: query.Type}
+	entries, total, err := h.fineRepo.List(filter, page)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch fines")
+		return
+	}
+
+	utils.PaginatedResponse(c, "Fines retrieved successfully", entries, utils.NewPagination(page.Number, page.PerPage, total))
+}
+
+func (h *FineHandler) GetFineByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid fine entry ID")
+		return
+	}
+
+	entry, err := h.fineRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "Fine entry not found")
+		return
+	}
+
+	utils.SuccessResponse(c, "Fine entry retrieved successfully", entry)
+}
+
+func (h *FineHandler) GetFineReceipt(c *gin.Context) {
+	entry, err := h.fineRepo.GetByReceiptNumber(c.Param("number"))
+	if err != nil {
+		utils.NotFoundResponse(c, "Receipt not found")
+		return
+	}
+
+	utils.SuccessResponse(c, "Receipt retrieved successfully", entry)
+}
+
+func (h *FineHandler) CreateFineCharge(c *gin.Context) {
+	var req FineChargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if _, err := h.memberRepo.GetByID(req.MemberID); err != nil {
+		utils.NotFoundResponse(c, "Member not found")
+		return
+	}
+	if !h.checkFineLoan(c, req.MemberID, req.LoanID) {
+		return
+	}
+
+	recordedBy := currentUserID(c)
+	entry := &models.FineEntry{
+		MemberID:     req.MemberID,
+		LoanID:       req.LoanID,
+		Type:         models.FineEntryCharge,
+		Amount:       req.Amount,
+		Reason:       req.Reason,
+		RecordedByID: &recordedBy,
+	}
+
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.fineRepo.WithTx(tx).Create(entry); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditFineCharge, models.AuditEntityFine, entry.ID, nil, entry, nil); err != nil {
+			return err
+		}
+		data := models.NotificationData{Amount: entry.Amount}
+		if entry.LoanID != nil {
+			data.LoanID = *entry.LoanID
+		}
+		return h.notificationRepo.WithTx(tx).Enqueue(entry.MemberID, models.NotificationFineAssessed,
+			fmt.Sprintf("fine_assessed:entry:%d", entry.ID), data)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to record charge")
+		return
+	}
+
+	utils.SuccessResponse(c, "Charge recorded successfully", entry)
+}
+
+func (h *FineHandler) RecordFinePayment(c *gin.Context) {
+	var req FinePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if !models.IsValidPaymentMethod(req.Method) {
+		utils.ValidationErrorResponse(c, "Method must be cash or transfer")
+		return
+	}
+	if req.Method == models.PaymentMethodTransfer && req.Reference == "" {
+		utils.ValidationErrorResponse(c, "Transfer payments need a reference")
+		return
+	}
+
+	if _, err := h.memberRepo.GetByID(req.MemberID); err != nil {
+		utils.NotFoundResponse(c, "Member not found")
+		return
+	}
+	if !h.checkFineLoan(c, req.MemberID, req.LoanID) {
+		return
+	}
+
+	recordedBy := currentUserID(c)
+	entry := &models.FineEntry{
+		MemberID:     req.MemberID,
+		LoanID:       req.LoanID,
+		Type:         models.FineEntryPayment,
+		Amount:       req.Amount,
+		Method:       req.Method,
+		Reference:    req.Reference,
+		RecordedByID: &recordedBy,
+	}
+
+	balance, err := h.settle(c, entry)
+	if errors.Is(err, repository.ErrAmountExceedsFines) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Payment exceeds the outstanding balance")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to record payment")
+		return
+	}
+
+	utils.SuccessResponse(c, "Payment recorded successfully", gin.H{
+		"payment": entry,
+		"balance": balance,
+	})
+}
+
+func (h *FineHandler) WaiveFine(c *gin.Context) {
+	var req FineWaiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if _, err := h.memberRepo.GetByID(req.MemberID); err != nil {
+		utils.NotFoundResponse(c, "Member not found")
+		return
+	}
+	if !h.checkFineLoan(c, req.MemberID, req.LoanID) {
+		return
+	}
+
+	approvedBy := currentUserID(c)
+	entry := &models.FineEntry{
+		MemberID:     req.MemberID,
+		LoanID:       req.LoanID,
+		Type:         models.FineEntryWaiver,
+		Amount:       req.Amount,
+		Reason:       req.Reason,
+		RecordedByID: &approvedBy,
+		ApprovedByID: &approvedBy,
+	}
+
+	balance, err := h.settle(c, entry)
+	if errors.Is(err, repository.ErrAmountExceedsFines) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Waiver exceeds the outstanding balance")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to record waiver")
+		return
+	}
+
+	utils.SuccessResponse(c, "Waiver recorded successfully", gin.H{
+		"waiver":  entry,
+		"balance": balance,
+	})
+}
+
+func (h *FineHandler) GetMemberFineBalance(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid member ID")
+		return
+	}
+
+	if _, err := h.memberRepo.GetByID(uint(id)); err != nil {
+		utils.NotFoundResponse(c, "Member not found")
+		return
+	}
+
+	balance, err := h.fineRepo.Balance(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate balance")
+		return
+	}
+
+	utils.SuccessResponse(c, "Fine balance retrieved successfully", balance)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"library-management-system/internal/health"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Livez answers as long as the process can serve HTTP. It checks nothing
+// else, so an orchestrator only restarts the server when restarting can
+// help.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	utils.SuccessResponse(c, "Library Management System is running", nil)
+}
+
+// Readyz answers 503 while the database is unreachable or behind on
+// migrations, so the load balancer sends requests elsewhere.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+	if !report.Ready {
+		c.JSON(http.StatusServiceUnavailable, utils.Response{
+			Status:  "error",
+			Message: "Not ready",
+			Data:    report,
+			Error:   "Not ready",
+		})
+		return
+	}
+	utils.SuccessResponse(c, "Ready", report)
+}
+
+// GetHealthDetails reports the build, uptime, database pool and scheduler
+// of this replica along with the readiness checks.
+func (h *HealthHandler) GetHealthDetails(c *gin.Context) {
+	utils.SuccessResponse(c, "Health details retrieved successfully", h.checker.Details(c.Request.Context()))
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"library-management-system/internal/clock"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HoldHandler struct {
+	auditor
+	db         repository.Transactor
+	holdRepo   repository.HoldStore
+	bookRepo   repository.BookStore
+	memberRepo repository.MemberStore
+	userRepo   repository.UserStore
+	clock      clock.Clock
+}
+
+func NewHoldHandler(stores *repository.Stores, clk clock.Clock) *HoldHandler {
+	return &HoldHandler{
+		db:         stores.DB,
+		auditor:    auditor{auditRepo: stores.Audit},
+		holdRepo:   stores.Holds,
+		bookRepo:   stores.Books,
+		memberRepo: stores.Members,
+		userRepo:   stores.Users,
+		clock:      clk,
+	}
+}
+
+type CreateHoldRequest struct {
+	BookID   uint   `json:"book_id" binding:"required"`
+	MemberID uint   `json:"member_id" binding:"required"`
+	Notes    string `json:"notes"`
+}
+
+// CreateOwnHoldRequest places a hold for the caller's own member record.
+type CreateOwnHoldRequest struct {
+	BookID uint   `json:"book_id" binding:"required"`
+	Notes  string `json:"notes"`
+}
+
+func (h *HoldHandler) withPositions(holds []models.Hold) error {
+	for i := range holds {
+		if holds[i].Status != models.HoldStatusWaiting {
+			continue
+		}
+		position, err := h.holdRepo.QueuePosition(&holds[i])
+		if err != nil {
+			return err
+		}
+		holds[i].Position = position
+	}
+	return nil
+}
+
+// ownMemberID returns the member the caller's account is linked to. When
+// there is none it answers 403 itself and reports false.
+func (h *HoldHandler) ownMemberID(c *gin.Context) (uint, bool) {
+	user, err := h.userRepo.GetByID(currentUserID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch account")
+		return 0, false
+	}
+	if user.MemberID == nil {
+		utils.ErrorResponse(c, http.StatusForbidden, "Account is not linked to a library member")
+		return 0, false
+	}
+	return *user.MemberID, true
+}
+
+func (h *HoldHandler) CreateHold(c *gin.Context) {
+	var req CreateHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+	h.placeHold(c, req.BookID, req.MemberID, req.Notes)
+}
+
+// CreateOwnHold places a hold for the member the caller's account is
+// linked to.
+func (h *HoldHandler) CreateOwnHold(c *gin.Context) {
+	var req CreateOwnHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+	memberID, ok := h.ownMemberID(c)
+	if !ok {
+		return
+	}
+	h.placeHold(c, req.BookID, memberID, req.Notes)
+}
+
+func (h *HoldHandler) placeHold(c *gin.Context, bookID, memberID uint, notes string) {
+	if _, err := h.bookRepo.GetByID(bookID); err != nil {
+		utils.NotFoundResponse(c, "Book not found")
+		return
+	}
+
+	member, err := h.memberRepo.GetByID(memberID)
+	if err != nil {
+		utils.NotFoundResponse(c, "Member not found")
+		return
+	}
+
+	if member.Status != "active" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Member is not active")
+		return
+	}
+
+	hold := &models.Hold{
+		BookID:   bookID,
+		MemberID: memberID,
+		Status:   models.HoldStatusWaiting,
+		Notes:    notes,
+	}
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		holdRepo := h.holdRepo.WithTx(tx)
+
+		book, err := h.bookRepo.WithTx(tx).GetByIDForUpdate(bookID)
+		if err != nil {
+			return err
+		}
+		if book.Available > 0 {
+			return repository.ErrHoldNotNeeded
+		}
+
+		if _, err := holdRepo.GetActive(memberID, bookID); err == nil {
+			return repository.ErrDuplicateHold
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := holdRepo.Create(hold); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditHoldCreate, models.AuditEntityHold, hold.ID, nil, hold, nil); err != nil {
+			return err
+		}
+		hold.Position, err = holdRepo.QueuePosition(hold)
+		return err
+	})
+	if errors.Is(err, repository.ErrHoldNotNeeded) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Book has copies available; borrow it instead")
+		return
+	}
+	if errors.Is(err, repository.ErrDuplicateHold) {
+		utils.ErrorResponse(c, http.StatusConflict, "Member already has a hold on this book")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to place hold")
+		return
+	}
+
+	utils.SuccessResponse(c, "Hold placed successfully", hold)
+}
+
+func (h *HoldHandler) GetHoldByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid hold ID")
+		return
+	}
+
+	hold, err := h.holdRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "Hold not found")
+		return
+	}
+
+	if hold.Status == models.HoldStatusWaiting {
+		if hold.Position, err = h.holdRepo.QueuePosition(hold); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch hold")
+			return
+		}
+	}
+
+	utils.SuccessResponse(c, "Hold retrieved successfully", hold)
+}
+
+func (h *HoldHandler) CancelHold(c *gin.Context) {
+	h.cancelHold(c, 0)
+}
+
+// CancelOwnHold cancels one of the holds of the member the caller's
+// account is linked to. Other members' holds are reported as not found.
+func (h *HoldHandler) CancelOwnHold(c *gin.Context) {
+	memberID, ok := h.ownMemberID(c)
+	if !ok {
+		return
+	}
+	h.cancelHold(c, memberID)
+}
+
+// cancelHold cancels the hold in the path. A non-zero memberID limits it
+// to that member's holds.
+func (h *HoldHandler) cancelHold(c *gin.Context, memberID uint) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid hold ID")
+		return
+	}
+
+	existing, err := h.holdRepo.GetByID(uint(id))
+	if err != nil || (memberID != 0 && existing.MemberID != memberID) {
+		utils.NotFoundResponse(c, "Hold not found")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		bookRepo := h.bookRepo.WithTx(tx)
+		holdRepo := h.holdRepo.WithTx(tx)
+
+		if _, err := bookRepo.GetByIDForUpdate(existing.BookID); err != nil {
+			return err
+		}
+
+		hold, err := holdRepo.GetByIDForUpdate(existing.ID)
+		if err != nil {
+			return err
+		}
+		before := *hold
+
+		if _, err := holdRepo.Close(hold, models.HoldStatusCancelled, h.clock.Now()); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditHoldCancel, models.AuditEntityHold, hold.ID, before, hold, nil); err != nil {
+			return err
+		}
+		return bookRepo.SyncAvailability(hold.BookID)
+	})
+	if errors.Is(err, repository.ErrHoldNotActive) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Hold is no longer active")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to cancel hold")
+		return
+	}
+
+	hold, err := h.holdRepo.GetByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get hold")
+		return
+	}
+
+	utils.SuccessResponse(c, "Hold cancelled successfully", hold)
+}
+
+func (h *HoldHandler) GetBookHolds(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid book ID")
+		return
+	}
+
+	if _, err := h.bookRepo.GetByID(uint(id)); err != nil {
+		utils.NotFoundResponse(c, "Book not found")
+		return
+	}
+
+	holds, err := h.holdRepo.GetActiveByBookID(uint(id))
+	if err == nil {
+		err = h.withPositions(holds)
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch holds")
+		return
+	}
+
+	utils.SuccessResponse(c, "Holds retrieved successfully", holds)
+}
+
+func (h *HoldHandler) GetMemberHolds(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid member ID")
+		return
+	}
+
+	if _, err := h.memberRepo.GetByID(uint(id)); err != nil {
+		utils.NotFoundResponse(c, "Member not found")
+		return
+	}
+
+	h.memberHolds(c, uint(id))
+}
+
+// GetOwnHolds lists the holds of the member the caller's account is
+// linked to.
+func (h *HoldHandler) GetOwnHolds(c *gin.Context) {
+	memberID, ok := h.ownMemberID(c)
+	if !ok {
+		return
+	}
+	h.memberHolds(c, memberID)
+}
+
+func (h *HoldHandler) memberHolds(c *gin.Context, memberID uint) {
+	holds, err := h.holdRepo.GetByMemberID(memberID)
+	if err == nil {
+		err = h.withPositions(holds)
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch holds")
+		return
+	}
+
+	utils.SuccessResponse(c, "Holds retrieved successfully", holds)
+}
+
+func (h *HoldHandler) ExpireHolds(c *gin.Context) {
+	promoted, err := h.holdRepo.ExpireReady(h.clock.Now())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to expire holds")
+		return
+	}
+
+	utils.SuccessResponse(c, "Expired holds processed successfully", gin.H{
+		"promoted_holds": promoted,
+	})
+}
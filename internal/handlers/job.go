@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"library-management-system/internal/scheduler"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	jobs *scheduler.Scheduler
+}
+
+func NewJobHandler(jobs *scheduler.Scheduler) *JobHandler {
+	return &JobHandler{jobs: jobs}
+}
+
+// GetJobStatuses reports what the background scheduler on this replica has
+// been doing.
+func (h *JobHandler) GetJobStatuses(c *gin.Context) {
+	utils.SuccessResponse(c, "Job statuses retrieved successfully", h.jobs.Status())
+}
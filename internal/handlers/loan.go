@@ -1,53 +1,113 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"library-management-system/internal/clock"
+	"library-management-system/internal/config"
 	"library-management-system/internal/models"
 	"library-management-system/internal/repository"
 	"library-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type LoanHandler struct {
-	loanRepo   *repository.LoanRepository
-	bookRepo   *repository.BookRepository
-	memberRepo *repository.MemberRepository
+	auditor
+	db               repository.Transactor
+	loanRepo         repository.LoanStore
+	bookRepo         repository.BookStore
+	copyRepo         repository.BookCopyStore
+	holdRepo         repository.HoldStore
+	memberRepo       repository.MemberStore
+	policyRepo       repository.CirculationPolicyStore
+	fineRepo         repository.FineStore
+	notificationRepo repository.NotificationStore
+	circulation      config.CirculationConfig
+	clock            clock.Clock
 }
 
-func NewLoanHandler() *LoanHandler {
+func NewLoanHandler(stores *repository.Stores, circulation config.CirculationConfig, clk clock.Clock) *LoanHandler {
 	return &LoanHandler{
-		loanRepo:   repository.NewLoanRepository(),
-		bookRepo:   repository.NewBookRepository(),
-		memberRepo: repository.NewMemberRepository(),
+		db:               stores.DB,
+		auditor:          auditor{auditRepo: stores.Audit},
+		loanRepo:         stores.Loans,
+		bookRepo:         stores.Books,
+		copyRepo:         stores.Copies,
+		holdRepo:         stores.Holds,
+		memberRepo:       stores.Members,
+		policyRepo:       stores.Policies,
+		fineRepo:         stores.Fines,
+		notificationRepo: stores.Notifications,
+		circulation:      circulation,
+		clock:            clk,
 	}
 }
 
 type CreateLoanRequest struct {
-	BookID   uint      `json:"book_id" binding:"required"`
+	BookID   uint      `json:"book_id"`
+	Barcode  string    `json:"barcode"`
 	MemberID uint      `json:"member_id" binding:"required"`
-	DueDate  time.Time `json:"due_date" binding:"required"`
+	DueDate  time.Time `json:"due_date"`
 	Notes    string    `json:"notes"`
 }
 
-func GetAllLoans(c *gin.Context) {
-	handler := NewLoanHandler()
-	
-	loans, err := handler.loanRepo.GetAll()
+type LoanListQuery struct {
+	Status   string    `form:"status"`
+	MemberID uint      `form:"member_id"`
+	BookID   uint      `form:"book_id"`
+	DueFrom  time.Time `form:"due_from" time_format:"2006-01-02"`
+	DueTo    time.Time `form:"due_to" time_format:"2006-01-02"`
+}
+
+var loanSortFields = map[string]string{
+	"id":          "id",
+	"loan_date":   "loan_date",
+	"due_date":    "due_date",
+	"return_date": "return_date",
+	"status":      "status",
+	"fine":        "fine",
+}
+
+func (h *LoanHandler) GetAllLoans(c *gin.Context) {
+	var query LoanListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	page, err := parsePage(c, loanSortFields)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	filter := repository.LoanFilter{
+		Status:   query.Status,
+		MemberID: query.MemberID,
+		BookID:   query.BookID,
+		DueFrom:  query.DueFrom,
+	}
+	if !query.DueTo.IsZero() {
+		// due_to is inclusive of the whole day
+		filter.DueTo = query.DueTo.AddDate(0, 0, 1)
+	}
+
+	loans, total, err := h.loanRepo.List(filter, page)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch loans")
 		return
 	}
 
-	utils.SuccessResponse(c, "Loans retrieved successfully", loans)
+	utils.PaginatedResponse(c, "Loans retrieved successfully", loans, utils.NewPagination(page.Number, page.PerPage, total))
 }
 
-func GetLoanByID(c *gin.Context) {
-	handler := NewLoanHandler()
-	
+func (h *LoanHandler) GetLoanByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -55,7 +115,7 @@ func GetLoanByID(c *gin.Context) {
 		return
 	}
 
-	loan, err := handler.loanRepo.GetByID(uint(id))
+	loan, err := h.loanRepo.GetByID(uint(id))
 	if err != nil {
 		utils.NotFoundResponse(c, "Loan not found")
 		return
@@ -64,27 +124,40 @@ func GetLoanByID(c *gin.Context) {
 	utils.SuccessResponse(c, "Loan retrieved successfully", loan)
 }
 
-func CreateLoan(c *gin.Context) {
-	handler := NewLoanHandler()
-	
+func (h *LoanHandler) CreateLoan(c *gin.Context) {
 	var req CreateLoanRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, err.Error())
 		return
 	}
 
-	book, err := handler.bookRepo.GetByID(req.BookID)
-	if err != nil {
-		utils.NotFoundResponse(c, "Book not found")
+	if req.BookID == 0 && req.Barcode == "" {
+		utils.ValidationErrorResponse(c, "Either book_id or barcode is required")
 		return
 	}
 
-	if book.Available <= 0 {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Book is not available for loan")
+	var requestedCopy *models.BookCopy
+	if req.Barcode != "" {
+		bookCopy, err := h.copyRepo.GetByBarcode(req.Barcode)
+		if err != nil {
+			utils.NotFoundResponse(c, "Book copy not found")
+			return
+		}
+		if req.BookID != 0 && req.BookID != bookCopy.BookID {
+			utils.ValidationErrorResponse(c, "Barcode does not belong to the given book")
+			return
+		}
+		requestedCopy = bookCopy
+		req.BookID = bookCopy.BookID
+	}
+
+	book, err := h.bookRepo.GetByID(req.BookID)
+	if err != nil {
+		utils.NotFoundResponse(c, "Book not found")
 		return
 	}
 
-	member, err := handler.memberRepo.GetByID(req.MemberID)
+	member, err := h.memberRepo.GetByID(req.MemberID)
 	if err != nil {
 		utils.NotFoundResponse(c, "Member not found")
 		return
@@ -95,37 +168,143 @@ func CreateLoan(c *gin.Context) {
 		return
 	}
 
-	if req.DueDate.Before(time.Now()) {
+	policy, err := h.policyRepo.Resolve(member.MemberType, book.Category)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to resolve circulation policy")
+		return
+	}
+
+	now := h.clock.Now()
+	latestDueDate := policy.DueDate(now)
+	if req.DueDate.IsZero() {
+		req.DueDate = latestDueDate
+	}
+	if req.DueDate.Before(now) {
 		utils.ValidationErrorResponse(c, "Due date must be in the future")
 		return
 	}
+	if req.DueDate.After(latestDueDate) {
+		utils.ValidationErrorResponse(c, fmt.Sprintf("Due date cannot be more than %d days from now", policy.LoanPeriodDays))
+		return
+	}
 
 	loan := &models.Loan{
 		BookID:   req.BookID,
 		MemberID: req.MemberID,
-		LoanDate: time.Now(),
+		LoanDate: now,
 		DueDate:  req.DueDate,
-		Status:   "borrowed",
+		Status:   models.LoanStatusBorrowed,
 		Notes:    req.Notes,
 	}
 
-	if err := handler.loanRepo.Create(loan); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create loan")
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		bookRepo := h.bookRepo.WithTx(tx)
+		copyRepo := h.copyRepo.WithTx(tx)
+		holdRepo := h.holdRepo.WithTx(tx)
+
+		// Locking the book serialises every checkout and return of its copies.
+		if _, err := bookRepo.GetByIDForUpdate(book.ID); err != nil {
+			return err
+		}
+
+		// Locking the member serialises their checkouts so the loan limit
+		// cannot be exceeded by concurrent requests.
+		if _, err := h.memberRepo.WithTx(tx).GetByIDForUpdate(member.ID); err != nil {
+			return err
+		}
+		activeLoans, err := h.loanRepo.WithTx(tx).CountActiveByMember(member.ID)
+		if err != nil {
+			return err
+		}
+		if activeLoans >= int64(policy.MaxLoans) {
+			return repository.ErrLoanLimitReached
+		}
+		fines, err := h.fineRepo.WithTx(tx).Balance(member.ID)
+		if err != nil {
+			return err
+		}
+		if fines.Balance > h.circulation.FineBlockThreshold {
+			return repository.ErrOutstandingFines
+		}
+
+		hold, err := holdRepo.GetActive(member.ID, book.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var bookCopy *models.BookCopy
+		switch {
+		case requestedCopy != nil:
+			bookCopy, err = copyRepo.GetByIDForUpdate(requestedCopy.ID)
+			if err != nil {
+				return err
+			}
+			heldForMember := hold != nil && hold.CopyID != nil && *hold.CopyID == bookCopy.ID
+			if bookCopy.Status != models.CopyStatusAvailable && !heldForMember {
+				return repository.ErrCopyNotAvailable
+			}
+		case hold != nil && hold.Status == models.HoldStatusReady:
+			bookCopy, err = copyRepo.GetByIDForUpdate(*hold.CopyID)
+		default:
+			bookCopy, err = copyRepo.FirstAvailable(book.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if hold != nil {
+			// The member is collecting the title they queued for. If the
+			// desk handed out a different copy than the one set aside, the
+			// set-aside copy goes to the next member in line.
+			heldCopyID := hold.CopyID
+			if _, err := holdRepo.Close(hold, models.HoldStatusFulfilled, loan.LoanDate); err != nil {
+				return err
+			}
+			if heldCopyID != nil && *heldCopyID != bookCopy.ID {
+				if _, err := holdRepo.PassCopyOn(*heldCopyID, book.ID, loan.LoanDate); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := copyRepo.UpdateStatus(bookCopy.ID, models.CopyStatusOnLoan); err != nil {
+			return err
+		}
+
+		loan.CopyID = &bookCopy.ID
+		if err := h.loanRepo.WithTx(tx).Create(loan); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditLoanCreate, models.AuditEntityLoan, loan.ID, nil, loan, nil); err != nil {
+			return err
+		}
+		return bookRepo.SyncAvailability(book.ID)
+	})
+	if errors.Is(err, repository.ErrBookNotAvailable) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Book is not available for loan")
 		return
 	}
-
-	book.Available--
-	if err := handler.bookRepo.Update(book); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update book availability")
+	if errors.Is(err, repository.ErrCopyNotAvailable) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "This copy is not available for loan")
+		return
+	}
+	if errors.Is(err, repository.ErrOutstandingFines) {
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Member has outstanding fines above %d; please settle them first", h.circulation.FineBlockThreshold))
+		return
+	}
+	if errors.Is(err, repository.ErrLoanLimitReached) {
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Member has reached the maximum of %d concurrent loans", policy.MaxLoans))
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create loan")
 		return
 	}
 
 	utils.SuccessResponse(c, "Loan created successfully", loan)
 }
 
-func ReturnBook(c *gin.Context) {
-	handler := NewLoanHandler()
-	
+func (h *LoanHandler) ReturnBook(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -133,44 +312,205 @@ func ReturnBook(c *gin.Context) {
 		return
 	}
 
-	loan, err := handler.loanRepo.GetByID(uint(id))
-	if err != nil {
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		loan, err := h.loanRepo.WithTx(tx).GetByIDForUpdate(uint(id))
+		if err != nil {
+			return err
+		}
+
+		if !loan.IsOpen() {
+			return repository.ErrLoanAlreadyReturned
+		}
+		before := *loan
+
+		bookRepo := h.bookRepo.WithTx(tx)
+		copyRepo := h.copyRepo.WithTx(tx)
+
+		book, err := bookRepo.GetByIDForUpdate(loan.BookID)
+		if err != nil {
+			return err
+		}
+
+		member, err := h.memberRepo.WithTx(tx).GetByID(loan.MemberID)
+		if err != nil {
+			return err
+		}
+
+		policy, err := h.policyRepo.WithTx(tx).Resolve(member.MemberType, book.Category)
+		if err != nil {
+			return err
+		}
+
+		var bookCopy *models.BookCopy
+		if loan.CopyID != nil {
+			bookCopy, err = copyRepo.GetByIDForUpdate(*loan.CopyID)
+		} else {
+			bookCopy, err = copyRepo.FirstUntrackedOnLoan(loan.BookID)
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		now := h.clock.Now()
+		loan.ReturnDate = &now
+		loan.Status = models.LoanStatusReturned
+
+		recordedBy := currentUserID(c)
+		fine := policy.FineFor(loan.DueDate, now)
+		if err := h.fineRepo.WithTx(tx).ChargeLoan(loan, fine, "Overdue return", &recordedBy); err != nil {
+			return err
+		}
+		if loan.Fine > 0 {
+			err := h.notificationRepo.WithTx(tx).Enqueue(loan.MemberID, models.NotificationFineAssessed,
+				fmt.Sprintf("fine_assessed:loan:%d", loan.ID),
+				models.NotificationData{LoanID: loan.ID, BookID: loan.BookID, DueDate: &loan.DueDate, Amount: loan.Fine})
+			if err != nil {
+				return err
+			}
+		}
+
+		if bookCopy != nil {
+			loan.CopyID = &bookCopy.ID
+			if bookCopy.Status == models.CopyStatusOnLoan || bookCopy.Status == models.CopyStatusLost {
+				if _, err := h.holdRepo.WithTx(tx).PassCopyOn(bookCopy.ID, loan.BookID, now); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := h.loanRepo.WithTx(tx).Update(loan); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditLoanReturn, models.AuditEntityLoan, loan.ID, before, loan, nil); err != nil {
+			return err
+		}
+		return bookRepo.SyncAvailability(loan.BookID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		utils.NotFoundResponse(c, "Loan not found")
 		return
 	}
-
-	if loan.Status == "returned" {
+	if errors.Is(err, repository.ErrLoanAlreadyReturned) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Book is already returned")
 		return
 	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to return book")
+		return
+	}
 
-	now := time.Now()
-	var fine float64 = 0
-	if now.After(loan.DueDate) {
-		daysOverdue := int(now.Sub(loan.DueDate).Hours() / 24)
-		fine = float64(daysOverdue) * 1000
+	loan, err := h.loanRepo.GetByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get loan")
+		return
 	}
 
-	loan.ReturnDate = &now
-	loan.Status = "returned"
-	loan.Fine = fine
+	utils.SuccessResponse(c, "Book returned successfully", loan)
+}
 
-	if err := handler.loanRepo.Update(loan); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update loan")
+func (h *LoanHandler) RenewLoan(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid loan ID")
 		return
 	}
 
-	book, err := handler.bookRepo.GetByID(loan.BookID)
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		loan, err := h.loanRepo.WithTx(tx).GetByIDForUpdate(uint(id))
+		if err != nil {
+			return err
+		}
+
+		if !loan.IsOpen() {
+			return repository.ErrLoanAlreadyReturned
+		}
+		before := *loan
+
+		member, err := h.memberRepo.WithTx(tx).GetByID(loan.MemberID)
+		if err != nil {
+			return err
+		}
+		if member.Status != "active" {
+			return repository.ErrMemberNotActive
+		}
+
+		book, err := h.bookRepo.WithTx(tx).GetByID(loan.BookID)
+		if err != nil {
+			return err
+		}
+
+		policy, err := h.policyRepo.WithTx(tx).Resolve(member.MemberType, book.Category)
+		if err != nil {
+			return err
+		}
+
+		onHold, err := h.holdRepo.WithTx(tx).HasWaitingFromOthers(loan.BookID, loan.MemberID)
+		if err != nil {
+			return err
+		}
+		if onHold {
+			return repository.ErrTitleOnHold
+		}
+
+		if loan.RenewalCount >= policy.MaxRenewals {
+			return repository.ErrRenewalLimitReached
+		}
+
+		now := h.clock.Now()
+		if !policy.InGracePeriod(loan.DueDate, now) {
+			return repository.ErrLoanTooOverdue
+		}
+
+		// A loan renewed within the grace period is extended from today so
+		// the member gets the full renewal period.
+		base := loan.DueDate
+		if now.After(base) {
+			base = now
+		}
+		loan.DueDate = base.AddDate(0, 0, policy.LoanPeriodDays)
+		loan.RenewalCount++
+		loan.Status = models.LoanStatusBorrowed
+
+		if err := h.loanRepo.WithTx(tx).Update(loan); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditLoanRenew, models.AuditEntityLoan, loan.ID, before, loan, nil)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.NotFoundResponse(c, "Loan not found")
+		return
+	}
+	if errors.Is(err, repository.ErrLoanAlreadyReturned) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Book is already returned")
+		return
+	}
+	if errors.Is(err, repository.ErrMemberNotActive) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Member is not active")
+		return
+	}
+	if errors.Is(err, repository.ErrTitleOnHold) {
+		utils.ErrorResponse(c, http.StatusConflict, "Another member has a hold on this title")
+		return
+	}
+	if errors.Is(err, repository.ErrRenewalLimitReached) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Loan has reached the maximum number of renewals")
+		return
+	}
+	if errors.Is(err, repository.ErrLoanTooOverdue) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Loan is too far overdue to be renewed; please return the book")
+		return
+	}
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get book")
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to renew loan")
 		return
 	}
 
-	book.Available++
-	if err := handler.bookRepo.Update(book); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update book availability")
+	loan, err := h.loanRepo.GetByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get loan")
 		return
 	}
 
-	utils.SuccessResponse(c, "Book returned successfully", loan)
+	utils.SuccessResponse(c, "Loan renewed successfully", loan)
 }
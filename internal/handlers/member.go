@@ -14,49 +14,96 @@ import (
 )
 
 type MemberHandler struct {
-	memberRepo *repository.MemberRepository
+	auditor
+	db         repository.Transactor
+	memberRepo repository.MemberStore
 }
 
-func NewMemberHandler() *MemberHandler {
+func NewMemberHandler(stores *repository.Stores) *MemberHandler {
 	return &MemberHandler{
-		memberRepo: repository.NewMemberRepository(),
+		db:         stores.DB,
+		auditor:    auditor{auditRepo: stores.Audit},
+		memberRepo: stores.Members,
 	}
 }
 
 type CreateMemberRequest struct {
-	Name    string `json:"name" binding:"required"`
-	Email   string `json:"email" binding:"required,email"`
-	Phone   string `json:"phone"`
-	Address string `json:"address"`
+	Name                   string     `json:"name" binding:"required"`
+	Email                  string     `json:"email" binding:"required,email"`
+	Phone                  string     `json:"phone"`
+	Address                string     `json:"address"`
+	MemberType             string     `json:"member_type"`
+	ExpiresAt              *time.Time `json:"expires_at"`
+	NotificationPreference string     `json:"notification_preference"`
+	Language               string     `json:"language"`
 }
 
 type UpdateMemberRequest struct {
-	Name    string `json:"name"`
-	Email   string `json:"email"`
-	Phone   string `json:"phone"`
-	Address string `json:"address"`
-	Status  string `json:"status"`
+	Name                   string     `json:"name"`
+	Email                  string     `json:"email"`
+	Phone                  string     `json:"phone"`
+	Address                string     `json:"address"`
+	Status                 string     `json:"status"`
+	MemberType             string     `json:"member_type"`
+	ExpiresAt              *time.Time `json:"expires_at"`
+	NotificationPreference string     `json:"notification_preference"`
+	Language               string     `json:"language"`
+}
+
+// validNotificationSettings checks the optional notification fields of a
+// member request.
+func validNotificationSettings(c *gin.Context, preference, language string) bool {
+	if preference != "" && preference != models.NotifyByEmail && preference != models.NotifyNone {
+		utils.ValidationErrorResponse(c, "notification_preference must be email or none")
+		return false
+	}
+	if language != "" && language != models.LanguageIndonesian && language != models.LanguageEnglish {
+		utils.ValidationErrorResponse(c, "language must be id or en")
+		return false
+	}
+	return true
 }
 
 func generateMemberCode() string {
 	return fmt.Sprintf("MEM%06d", time.Now().Unix()%1000000)
 }
 
-func GetAllMembers(c *gin.Context) {
-	handler := NewMemberHandler()
+type MemberListQuery struct {
+	Status string `form:"status"`
+}
+
+var memberSortFields = map[string]string{
+	"id":          "id",
+	"name":        "name",
+	"email":       "email",
+	"member_code": "member_code",
+	"status":      "status",
+	"created_at":  "created_at",
+}
+
+func (h *MemberHandler) GetAllMembers(c *gin.Context) {
+	var query MemberListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	page, err := parsePage(c, memberSortFields)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
 
-	members, err := handler.memberRepo.GetAll()
+	members, total, err := h.memberRepo.List(repository.MemberFilter{Status: query.Status}, page)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch members")
 		return
 	}
 
-	utils.SuccessResponse(c, "Members retrieved successfully", members)
+	utils.PaginatedResponse(c, "Members retrieved successfully", members, utils.NewPagination(page.Number, page.PerPage, total))
 }
 
-func GetMemberByID(c *gin.Context) {
-	handler := NewMemberHandler()
-
+func (h *MemberHandler) GetMemberByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -64,7 +111,7 @@ func GetMemberByID(c *gin.Context) {
 		return
 	}
 
-	member, err := handler.memberRepo.GetByID(uint(id))
+	member, err := h.memberRepo.GetByID(uint(id))
 	if err != nil {
 		utils.NotFoundResponse(c, "Member not found")
 		return
@@ -73,16 +120,18 @@ func GetMemberByID(c *gin.Context) {
 	utils.SuccessResponse(c, "Member retrieved successfully", member)
 }
 
-func CreateMember(c *gin.Context) {
-	handler := NewMemberHandler()
-
+func (h *MemberHandler) CreateMember(c *gin.Context) {
 	var req CreateMemberRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, err.Error())
 		return
 	}
 
-	existingMember, _ := handler.memberRepo.GetByEmail(req.Email)
+	if !validNotificationSettings(c, req.NotificationPreference, req.Language) {
+		return
+	}
+
+	existingMember, _ := h.memberRepo.GetByEmail(req.Email)
 	if existingMember != nil {
 		utils.ErrorResponse(c, http.StatusConflict, "Member with this email already exists")
 		return
@@ -90,7 +139,7 @@ func CreateMember(c *gin.Context) {
 
 	memberCode := generateMemberCode()
 	for {
-		existingCode, _ := handler.memberRepo.GetByMemberCode(memberCode)
+		existingCode, _ := h.memberRepo.GetByMemberCode(memberCode)
 		if existingCode == nil {
 			break
 		}
@@ -98,15 +147,35 @@ func CreateMember(c *gin.Context) {
 	}
 
 	member := &models.Member{
-		Name:       req.Name,
-		Email:      req.Email,
-		Phone:      req.Phone,
-		Address:    req.Address,
-		MemberCode: memberCode,
-		Status:     "active",
+		Name:                   req.Name,
+		Email:                  req.Email,
+		Phone:                  req.Phone,
+		Address:                req.Address,
+		MemberCode:             memberCode,
+		MemberType:             req.MemberType,
+		Status:                 "active",
+		ExpiresAt:              req.ExpiresAt,
+		NotificationPreference: req.NotificationPreference,
+		Language:               req.Language,
+	}
+
+	if member.MemberType == "" {
+		member.MemberType = "general"
+	}
+	if member.NotificationPreference == "" {
+		member.NotificationPreference = models.NotifyByEmail
+	}
+	if member.Language == "" {
+		member.Language = models.LanguageIndonesian
 	}
 
-	if err := handler.memberRepo.Create(member); err != nil {
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.memberRepo.WithTx(tx).Create(member); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditMemberCreate, models.AuditEntityMember, member.ID, nil, member, nil)
+	})
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create member")
 		return
 	}
@@ -114,9 +183,7 @@ func CreateMember(c *gin.Context) {
 	utils.SuccessResponse(c, "Member created successfully", member)
 }
 
-func UpdateMember(c *gin.Context) {
-	handler := NewMemberHandler()
-
+func (h *MemberHandler) UpdateMember(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -130,18 +197,23 @@ func UpdateMember(c *gin.Context) {
 		return
 	}
 
-	member, err := handler.memberRepo.GetByID(uint(id))
+	if !validNotificationSettings(c, req.NotificationPreference, req.Language) {
+		return
+	}
+
+	member, err := h.memberRepo.GetByID(uint(id))
 	if err != nil {
 		utils.NotFoundResponse(c, "Member not found")
 		return
 	}
+	before := *member
 
 	if req.Name != "" {
 		member.Name = req.Name
 	}
 	if req.Email != "" {
 		if req.Email != member.Email {
-			existingMember, _ := handler.memberRepo.GetByEmail(req.Email)
+			existingMember, _ := h.memberRepo.GetByEmail(req.Email)
 			if existingMember != nil {
 				utils.ErrorResponse(c, http.StatusConflict, "Member with this email already exists")
 				return
@@ -158,8 +230,26 @@ func UpdateMember(c *gin.Context) {
 	if req.Status != "" {
 		member.Status = req.Status
 	}
+	if req.MemberType != "" {
+		member.MemberType = req.MemberType
+	}
+	if req.ExpiresAt != nil {
+		member.ExpiresAt = req.ExpiresAt
+	}
+	if req.NotificationPreference != "" {
+		member.NotificationPreference = req.NotificationPreference
+	}
+	if req.Language != "" {
+		member.Language = req.Language
+	}
 
-	if err := handler.memberRepo.Update(member); err != nil {
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.memberRepo.WithTx(tx).Update(member); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditMemberUpdate, models.AuditEntityMember, member.ID, before, member, nil)
+	})
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update member")
 		return
 	}
@@ -167,9 +257,7 @@ func UpdateMember(c *gin.Context) {
 	utils.SuccessResponse(c, "Member updated successfully", member)
 }
 
-func DeleteMember(c *gin.Context) {
-	handler := NewMemberHandler()
-
+func (h *MemberHandler) DeleteMember(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -177,13 +265,19 @@ func DeleteMember(c *gin.Context) {
 		return
 	}
 
-	_, err = handler.memberRepo.GetByID(uint(id))
+	member, err := h.memberRepo.GetByID(uint(id))
 	if err != nil {
 		utils.NotFoundResponse(c, "Member not found")
 		return
 	}
 
-	if err := handler.memberRepo.Delete(uint(id)); err != nil {
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.memberRepo.WithTx(tx).Delete(member.ID); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditMemberDelete, models.AuditEntityMember, member.ID, member, nil, nil)
+	})
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete member")
 		return
 	}
@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/loginguard"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const recoveryCodeCount = 10
+
+var errInvalidSecondFactor = errors.New("invalid two-factor code")
+
+type MFAHandler struct {
+	auditor
+	db               repository.Transactor
+	userRepo         repository.UserStore
+	recoveryCodeRepo repository.RecoveryCodeStore
+	tokenRepo        repository.TokenStore
+	auth             config.AuthConfig
+	issuer           *utils.TokenIssuer
+	secrets          *utils.SecretBox
+	guard            *loginguard.Guard
+}
+
+func NewMFAHandler(stores *repository.Stores, auth config.AuthConfig, issuer *utils.TokenIssuer, secrets *utils.SecretBox, guard *loginguard.Guard) *MFAHandler {
+	return &MFAHandler{
+		db:               stores.DB,
+		auditor:          auditor{auditRepo: stores.Audit},
+		userRepo:         stores.Users,
+		recoveryCodeRepo: stores.RecoveryCodes,
+		tokenRepo:        stores.Tokens,
+		auth:             auth,
+		issuer:           issuer,
+		secrets:          secrets,
+		guard:            guard,
+	}
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type VerifyMFARequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFAChallengeResponse is what Login returns instead of tokens when the
+// user has to pass a second step. With EnrollmentRequired, MFAToken only
+// works for enrolling; otherwise it goes to POST /api/auth/2fa/verify.
+type MFAChallengeResponse struct {
+	MFARequired        bool      `json:"mfa_required"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+	MFAToken           string    `json:"mfa_token"`
+	ExpiresAt          time.Time `json:"expires_at"`
+}
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFAConfirmResponse struct {
+	TokenResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// needsSecondFactor reports whether a login of this user must go through
+// two-factor authentication, either because they enabled it or because
+// their role requires it.
+func needsSecondFactor(auth config.AuthConfig, user *models.User) bool {
+	return user.TOTPEnabled || auth.MFARequired(user.Role)
+}
+
+func mfaChallenge(issuer *utils.TokenIssuer, auth config.AuthConfig, user *models.User) (*MFAChallengeResponse, error) {
+	purpose := utils.TokenPurposeMFA
+	if !user.TOTPEnabled {
+		purpose = utils.TokenPurposeMFAEnroll
+	}
+	token, claims, err := issuer.GeneratePurposeToken(user.ID, user.Username, user.Role, purpose, auth.MFATokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAChallengeResponse{
+		MFARequired:        true,
+		EnrollmentRequired: !user.TOTPEnabled,
+		MFAToken:           token,
+		ExpiresAt:          claims.ExpiresAt.Time,
+	}, nil
+}
+
+// newRecoveryCodes returns fresh codes to show the user once, and the
+// hashes to store.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := utils.RandomToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, utils.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed with or without the dash, in
+// any case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// checkTOTP validates a code from the user's authenticator and uses up its
+// time step, so the same code cannot be replayed. A secret stored in the
+// clear, from before secrets were encrypted, still works and is encrypted
+// once a code from it is accepted.
+func (h *MFAHandler) checkTOTP(tx *repository.Tx, user *models.User, code string, now time.Time) error {
+	secret := user.TOTPSecret
+	if utils.IsSealed(secret) {
+		var err error
+		if secret, err = h.secrets.Open(secret); err != nil {
+			return fmt.Errorf("decrypt TOTP secret of user %d: %w", user.ID, err)
+		}
+	}
+	step, ok := utils.ValidateTOTP(secret, code, now, user.TOTPLastStep)
+	if !ok {
+		return errInvalidSecondFactor
+	}
+	used, err := h.userRepo.WithTx(tx).UseTOTPStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errInvalidSecondFactor
+	}
+	user.TOTPLastStep = step
+	if utils.IsSealed(user.TOTPSecret) {
+		return nil
+	}
+	if user.TOTPSecret, err = h.secrets.Seal(secret); err != nil {
+		return err
+	}
+	return h.userRepo.WithTx(tx).UpdateTOTP(user)
+}
+
+// EnrollMFA starts two-factor enrollment with a new secret. It takes
+// effect once ConfirmMFA sees a code generated from it; enrolling again
+// before that replaces the secret.
+func (h *MFAHandler) EnrollMFA(c *gin.Context) {
+	user, err := h.userRepo.GetByID(currentUserID(c))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+	if user.TOTPEnabled {
+		utils.ErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start enrollment")
+		return
+	}
+	if user.TOTPSecret, err = h.secrets.Seal(secret); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start enrollment")
+		return
+	}
+	user.TOTPLastStep = 0
+	if err := h.userRepo.UpdateTOTP(user); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start enrollment")
+		return
+	}
+
+	utils.SuccessResponse(c, "Scan the URI with an authenticator app, then confirm with a code", MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(h.auth.MFAIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmMFA turns two-factor authentication on with a first code. Every
+// other session ends, and the response carries new tokens and the
+// recovery codes, which are shown only this once.
+func (h *MFAHandler) ConfirmMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetByID(currentUserID(c))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+	if user.TOTPEnabled {
+		utils.ErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+	if user.TOTPSecret == "" {
+		utils.ValidationErrorResponse(c, "Start enrollment first")
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		return
+	}
+
+	var tokens *TokenResponse
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		now := time.Now()
+		if err := h.checkTOTP(tx, user, req.Code, now); err != nil {
+			return err
+		}
+		user.TOTPEnabled = true
+		if err := h.userRepo.WithTx(tx).UpdateTOTP(user); err != nil {
+			return err
+		}
+		if err := h.recoveryCodeRepo.WithTx(tx).Replace(user.ID, hashes); err != nil {
+			return err
+		}
+		if err := h.audit(tx, c, models.AuditMFAEnabled, models.AuditEntityUser, user.ID, nil, nil, nil); err != nil {
+			return err
+		}
+		if err := revokeSessions(tx, h.userRepo, h.tokenRepo, user.ID, now); err != nil {
+			return err
+		}
+		_, tokens, err = issueTokens(c, tx, h.issuer, h.tokenRepo, user, "")
+		return err
+	})
+	if errors.Is(err, errInvalidSecondFactor) {
+		utils.ValidationErrorResponse(c, "Invalid code")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		return
+	}
+
+	utils.SuccessResponse(c, "Two-factor authentication enabled; store the recovery codes somewhere safe", MFAConfirmResponse{
+		TokenResponse: *tokens,
+		RecoveryCodes: codes,
+	})
+}
+
+// VerifyMFA finishes a login with the "mfa pending" token from Login and
+// either a code from the authenticator or a recovery code. Wrong codes
+// count as failed logins.
+func (h *MFAHandler) VerifyMFA(c *gin.Context) {
+	var req VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		utils.ValidationErrorResponse(c, "Provide either code or recovery_code")
+		return
+	}
+
+	claims, err := h.issuer.ValidateToken(req.MFAToken)
+	if err != nil || claims.Purpose != utils.TokenPurposeMFA {
+		utils.UnauthorizedResponse(c, "Invalid or expired MFA token")
+		return
+	}
+
+	ip := c.ClientIP()
+	now := time.Now()
+	wait, err := h.guard.Check(claims.Username, ip, now)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check login attempts")
+		return
+	}
+	if wait > 0 {
+		tooManyAttempts(c, wait)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(claims.UserID)
+	if err != nil || !user.IsActive || !user.TOTPEnabled {
+		utils.UnauthorizedResponse(c, "Invalid or expired MFA token")
+		return
+	}
+
+	var tokens *TokenResponse
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if req.Code != "" {
+			if err := h.checkTOTP(tx, user, req.Code, now); err != nil {
+				return err
+			}
+		} else {
+			hash := utils.HashToken(normalizeRecoveryCode(req.RecoveryCode))
+			used, err := h.recoveryCodeRepo.WithTx(tx).Use(user.ID, hash, now)
+			if err != nil {
+				return err
+			}
+			if !used {
+				return errInvalidSecondFactor
+			}
+		}
+		if _, tokens, err = issueTokens(c, tx, h.issuer, h.tokenRepo, user, ""); err != nil {
+			return err
+		}
+		method := map[string]string{"method": "totp"}
+		if req.Code == "" {
+			method["method"] = "recovery_code"
+		}
+		return h.auditAs(tx, c, user.ID, models.AuditLogin, models.AuditEntityUser, user.ID, nil, nil, method)
+	})
+	if errors.Is(err, errInvalidSecondFactor) {
+		if err := h.guard.Fail(user.Username, ip, c.GetString("request_id"), now); err != nil {
+			log.Printf("failed to record login failure: %v", err)
+		}
+		if err := h.auditAs(nil, c, user.ID, models.AuditLoginFailed, models.AuditEntityUser, user.ID, nil, nil, map[string]string{"step": "mfa"}); err != nil {
+			log.Printf("failed to audit login failure: %v", err)
+		}
+		utils.UnauthorizedResponse(c, "Invalid code")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify code")
+		return
+	}
+
+	if err := h.guard.Succeed(user.Username); err != nil {
+		log.Printf("failed to clear login failures: %v", err)
+	}
+
+	utils.SuccessResponse(c, "Login successful", LoginResponse{
+		TokenResponse: *tokens,
+		User:          *user,
+	})
+}
+
+// DisableMFA turns two-factor authentication off for the caller, unless
+// their role requires it.
+func (h *MFAHandler) DisableMFA(c *gin.Context) {
+	var req DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetByID(currentUserID(c))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+	if !user.TOTPEnabled {
+		utils.ValidationErrorResponse(c, "Two-factor authentication is not enabled")
+		return
+	}
+	if h.auth.MFARequired(user.Role) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Two-factor authentication is required for your role")
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		utils.ValidationErrorResponse(c, "Password is incorrect")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.checkTOTP(tx, user, req.Code, time.Now()); err != nil {
+			return err
+		}
+		return h.clear(tx, c, user, models.AuditMFADisabled)
+	})
+	if errors.Is(err, errInvalidSecondFactor) {
+		utils.ValidationErrorResponse(c, "Invalid code")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		return
+	}
+
+	utils.SuccessResponse(c, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes, e.g. when
+// most are used up. The old codes stop working.
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetByID(currentUserID(c))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+	if !user.TOTPEnabled {
+		utils.ValidationErrorResponse(c, "Two-factor authentication is not enabled")
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create recovery codes")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.checkTOTP(tx, user, req.Code, time.Now()); err != nil {
+			return err
+		}
+		return h.recoveryCodeRepo.WithTx(tx).Replace(user.ID, hashes)
+	})
+	if errors.Is(err, errInvalidSecondFactor) {
+		utils.ValidationErrorResponse(c, "Invalid code")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create recovery codes")
+		return
+	}
+
+	utils.SuccessResponse(c, "Recovery codes replaced", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetUserMFA lets an admin remove a user's second factor, e.g. after a
+// lost phone with no recovery codes left. The user's sessions end; if
+// their role requires two-factor authentication, they enroll again at the
+// next login.
+func (h *MFAHandler) ResetUserMFA(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.clear(tx, c, user, models.AuditMFAReset); err != nil {
+			return err
+		}
+		return revokeSessions(tx, h.userRepo, h.tokenRepo, user.ID, time.Now())
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset two-factor authentication")
+		return
+	}
+
+	utils.SuccessResponse(c, "Two-factor authentication reset successfully", nil)
+}
+
+// clear removes the user's second factor and recovery codes.
+func (h *MFAHandler) clear(tx *repository.Tx, c *gin.Context, user *models.User, action string) error {
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := h.userRepo.WithTx(tx).UpdateTOTP(user); err != nil {
+		return err
+	}
+	if err := h.recoveryCodeRepo.WithTx(tx).DeleteAll(user.ID); err != nil {
+		return err
+	}
+	return h.audit(tx, c, action, models.AuditEntityUser, user.ID, nil, nil, nil)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"library-management-system/internal/repository"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationRepo repository.NotificationStore
+}
+
+func NewNotificationHandler(stores *repository.Stores) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: stores.Notifications,
+	}
+}
+
+type NotificationListQuery struct {
+	MemberID uint   `form:"member_id"`
+	Event    string `form:"event"`
+	Status   string `form:"status"`
+}
+
+var notificationSortFields = map[string]string{
+	"id":              "id",
+	"created_at":      "created_at",
+	"next_attempt_at": "next_attempt_at",
+	"attempts":        "attempts",
+}
+
+func (h *NotificationHandler) GetAllNotifications(c *gin.Context) {
+	var query NotificationListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	page, err := parsePage(c, notificationSortFields)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	filter := repository.NotificationFilter{MemberID: query.MemberID, Event: query.Event, Status: query.Status}
+	notifications, total, err := h.notificationRepo.List(filter, page)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch notifications")
+		return
+	}
+
+	utils.PaginatedResponse(c, "Notifications retrieved successfully", notifications, utils.NewPagination(page.Number, page.PerPage, total))
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"library-management-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// parsePage reads page, per_page and sort from the query string. sortable
+// maps the field names clients may sort by to their database columns;
+// "sort=-year,title" sorts by year descending, then title.
+func parsePage(c *gin.Context, sortable map[string]string) (repository.Page, error) {
+	page := repository.Page{Number: 1, PerPage: defaultPerPage}
+
+	if value := c.Query("page"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return page, fmt.Errorf("page must be a positive integer")
+		}
+		page.Number = number
+	}
+
+	if value := c.Query("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return page, fmt.Errorf("per_page must be between 1 and %d", maxPerPage)
+		}
+		page.PerPage = perPage
+	}
+
+	if value := c.Query("sort"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			column, ok := sortable[strings.TrimPrefix(field, "-")]
+			if !ok {
+				return page, fmt.Errorf("cannot sort by %q", field)
+			}
+			page.Sort = append(page.Sort, repository.SortField{Column: column, Desc: desc})
+		}
+	}
+
+	return page, nil
+}
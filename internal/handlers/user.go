@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/loginguard"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	auditor
+	db         repository.Transactor
+	userRepo   repository.UserStore
+	memberRepo repository.MemberStore
+	tokenRepo  repository.TokenStore
+	auth       config.AuthConfig
+	guard      *loginguard.Guard
+}
+
+func NewUserHandler(stores *repository.Stores, auth config.AuthConfig, guard *loginguard.Guard) *UserHandler {
+	return &UserHandler{
+		db:         stores.DB,
+		auditor:    auditor{auditRepo: stores.Audit},
+		userRepo:   stores.Users,
+		memberRepo: stores.Members,
+		tokenRepo:  stores.Tokens,
+		auth:       auth,
+		guard:      guard,
+	}
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// LinkMemberRequest links an account to a library member; a null
+// member_id unlinks it.
+type LinkMemberRequest struct {
+	MemberID *uint `json:"member_id"`
+}
+
+type ChangeRoleRequest struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+func currentUserID(c *gin.Context) uint {
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(uint)
+	return id
+}
+
+func (h *UserHandler) GetAllUsers(c *gin.Context) {
+	users, err := h.userRepo.GetAll()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch users")
+		return
+	}
+
+	utils.SuccessResponse(c, "Users retrieved successfully", users)
+}
+
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if !models.IsValidRole(req.Role) {
+		utils.ValidationErrorResponse(c, "Invalid role")
+		return
+	}
+
+	if err := utils.ValidatePassword(req.Password, h.auth.PasswordMinLength, req.Username, req.Email); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	existingUser, _ := h.userRepo.GetByUsername(req.Username)
+	if existingUser != nil {
+		utils.ErrorResponse(c, http.StatusConflict, "Username already exists")
+		return
+	}
+
+	existingEmail, _ := h.userRepo.GetByEmail(req.Email)
+	if existingEmail != nil {
+		utils.ErrorResponse(c, http.StatusConflict, "Email already exists")
+		return
+	}
+
+	user := &models.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     req.Role,
+	}
+
+	err := h.db.Transaction(func(tx *repository.Tx) error {
+		userRepo := h.userRepo.WithTx(tx)
+		if err := userRepo.Create(user); err != nil {
+			return err
+		}
+		err := userRepo.CreateRoleChange(&models.RoleChange{
+			UserID:      user.ID,
+			NewRole:     user.Role,
+			ChangedByID: currentUserID(c),
+			Reason:      "account created",
+		})
+		if err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditUserCreate, models.AuditEntityUser, user.ID, nil, user, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+
+	utils.SuccessResponse(c, "User created successfully", user)
+}
+
+func (h *UserHandler) ChangeUserRole(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if !models.IsValidRole(req.Role) {
+		utils.ValidationErrorResponse(c, "Invalid role")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	if user.ID == currentUserID(c) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "You cannot change your own role")
+		return
+	}
+
+	if user.Role == req.Role {
+		utils.SuccessResponse(c, "User role unchanged", user)
+		return
+	}
+
+	change := &models.RoleChange{
+		UserID:      user.ID,
+		OldRole:     user.Role,
+		NewRole:     req.Role,
+		ChangedByID: currentUserID(c),
+		Reason:      req.Reason,
+	}
+
+	before := *user
+	user.Role = req.Role
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		userRepo := h.userRepo.WithTx(tx)
+		if err := userRepo.UpdateRole(user.ID, req.Role); err != nil {
+			return err
+		}
+		if err := userRepo.CreateRoleChange(change); err != nil {
+			return err
+		}
+		// Tokens carry the role, so sessions started under the old one end.
+		if err := revokeSessions(tx, h.userRepo, h.tokenRepo, user.ID, time.Now()); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditUserRoleChange, models.AuditEntityUser, user.ID, before, user, map[string]string{"reason": req.Reason})
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to change user role")
+		return
+	}
+
+	utils.SuccessResponse(c, "User role changed successfully", user)
+}
+
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	if user.ID == currentUserID(c) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "You cannot deactivate your own account")
+		return
+	}
+
+	before := *user
+	user.IsActive = false
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.userRepo.WithTx(tx).SetActive(user.ID, false); err != nil {
+			return err
+		}
+		if err := revokeSessions(tx, h.userRepo, h.tokenRepo, user.ID, time.Now()); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditUserDeactivate, models.AuditEntityUser, user.ID, before, user, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to deactivate user")
+		return
+	}
+
+	utils.SuccessResponse(c, "User deactivated successfully", user)
+}
+
+// LinkMember sets which library member an account belongs to, which is
+// whose holds it may manage under /api/me/holds.
+func (h *UserHandler) LinkMember(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req LinkMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	if req.MemberID != nil {
+		if _, err := h.memberRepo.GetByID(*req.MemberID); err != nil {
+			utils.NotFoundResponse(c, "Member not found")
+			return
+		}
+		if linked, err := h.userRepo.GetByMemberID(*req.MemberID); err == nil && linked.ID != user.ID {
+			utils.ErrorResponse(c, http.StatusConflict, "Member is already linked to another account")
+			return
+		}
+	}
+
+	before := *user
+	user.MemberID = req.MemberID
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := h.userRepo.WithTx(tx).SetMember(user.ID, req.MemberID); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditUserLinkMember, models.AuditEntityUser, user.ID, before, user, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to link member")
+		return
+	}
+
+	utils.SuccessResponse(c, "Member link updated successfully", user)
+}
+
+// LogoutUser ends every session of another user, e.g. when a device is
+// lost.
+func (h *UserHandler) LogoutUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	if _, err := h.userRepo.GetByID(uint(id)); err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *repository.Tx) error {
+		if err := revokeSessions(tx, h.userRepo, h.tokenRepo, uint(id), time.Now()); err != nil {
+			return err
+		}
+		return h.audit(tx, c, models.AuditUserLogout, models.AuditEntityUser, id, nil, nil, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to log out user")
+		return
+	}
+
+	utils.SuccessResponse(c, "User logged out of all sessions successfully", nil)
+}
+
+// UnlockUser lifts a login lockout of a user before it runs out.
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(uint(id))
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	if err := h.guard.UnlockAccount(user.Username); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to unlock user")
+		return
+	}
+	if err := h.audit(nil, c, models.AuditLoginUnlock, models.AuditEntityUser, user.ID, nil, nil, nil); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to unlock user")
+		return
+	}
+
+	utils.SuccessResponse(c, "User unlocked successfully", nil)
+}
+
+type UnlockIPRequest struct {
+	IP string `json:"ip" binding:"required,ip"`
+}
+
+// UnlockIP lifts a login lockout of a client IP before it runs out.
+func (h *UserHandler) UnlockIP(c *gin.Context) {
+	var req UnlockIPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if err := h.guard.UnlockIP(req.IP); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to unlock IP")
+		return
+	}
+	if err := h.audit(nil, c, models.AuditLoginUnlock, loginguard.EntityIP, req.IP, nil, nil, nil); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to unlock IP")
+		return
+	}
+
+	utils.SuccessResponse(c, "IP unlocked successfully", nil)
+}
+
+func (h *UserHandler) GetUserRoleChanges(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	if _, err := h.userRepo.GetByID(uint(id)); err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	changes, err := h.userRepo.GetRoleChanges(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch role changes")
+		return
+	}
+
+	utils.SuccessResponse(c, "Role changes retrieved successfully", changes)
+}
@@ -0,0 +1,240 @@
+// Package health answers the load balancer's and the operators' questions
+// about whether the server can do its job.
+package health
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"library-management-system/internal/scheduler"
+	"library-management-system/migrations"
+
+	"gorm.io/gorm"
+)
+
+// Version and Commit identify the build. Release builds set them with
+//
+//	go build -ldflags "-X library-management-system/internal/health.Version=1.4.0 -X library-management-system/internal/health.Commit=$(git rev-parse --short HEAD)"
+//
+// Without -ldflags, Commit falls back to the revision go build recorded.
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// checkTimeout bounds each dependency check, so a hung database makes the
+// server unready instead of hanging the probe.
+const checkTimeout = 2 * time.Second
+
+// Check statuses.
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDisabled = "disabled"
+)
+
+type Check struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the result of a readiness check. Only the database and its
+// migrations decide Ready: taking a replica out of the load balancer does
+// not help a stuck background job, so the workers are reported but do not
+// count.
+type Report struct {
+	Ready  bool             `json:"ready"`
+	Checks map[string]Check `json:"checks"`
+}
+
+type MigrationStatus struct {
+	Version int64 `json:"version"`
+	Latest  int64 `json:"latest"`
+}
+
+type PoolStats struct {
+	MaxOpen      int    `json:"max_open"`
+	Open         int    `json:"open"`
+	InUse        int    `json:"in_use"`
+	Idle         int    `json:"idle"`
+	WaitCount    int64  `json:"wait_count"`
+	WaitDuration string `json:"wait_duration"`
+	// Connections closed for being idle too long, too many or too old.
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// Details is everything an operator may want to know about this replica.
+type Details struct {
+	Report
+	Version    string           `json:"version"`
+	Commit     string           `json:"commit"`
+	GoVersion  string           `json:"go_version"`
+	StartedAt  time.Time        `json:"started_at"`
+	Uptime     string           `json:"uptime"`
+	Database   string           `json:"database"`
+	Pool       *PoolStats       `json:"pool,omitempty"`
+	Migrations *MigrationStatus `json:"migrations,omitempty"`
+	Scheduler  scheduler.Status `json:"scheduler"`
+}
+
+// Checker checks the database, its migrations and the background jobs.
+type Checker struct {
+	db        *gorm.DB
+	migrator  *migrations.Migrator
+	jobs      *scheduler.Scheduler
+	startedAt time.Time
+}
+
+func NewChecker(db *gorm.DB, migrator *migrations.Migrator, jobs *scheduler.Scheduler) *Checker {
+	return &Checker{
+		db:        db,
+		migrator:  migrator,
+		jobs:      jobs,
+		startedAt: time.Now(),
+	}
+}
+
+// Ready checks whether the server can serve requests.
+func (c *Checker) Ready(ctx context.Context) Report {
+	report, _ := c.check(ctx)
+	return report
+}
+
+func (c *Checker) check(ctx context.Context) (Report, *MigrationStatus) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	report := Report{Checks: map[string]Check{}}
+	var migrationStatus *MigrationStatus
+
+	database := Check{Status: StatusOK}
+	if err := c.ping(ctx); err != nil {
+		database = failing(err)
+	}
+	report.Checks["database"] = database
+
+	if database.Status == StatusOK {
+		report.Checks["migrations"], migrationStatus = c.migrationCheck(ctx)
+	} else {
+		report.Checks["migrations"] = Check{Status: StatusFailing, Error: "database unreachable"}
+	}
+
+	report.Checks["scheduler"] = c.schedulerCheck()
+
+	report.Ready = report.Checks["database"].Status == StatusOK &&
+		report.Checks["migrations"].Status == StatusOK
+	return report, migrationStatus
+}
+
+func (c *Checker) migrationCheck(ctx context.Context) (Check, *MigrationStatus) {
+	version, err := c.migrator.WithContext(ctx).Version()
+	if err != nil {
+		return failing(err), nil
+	}
+	status := &MigrationStatus{Version: version, Latest: c.migrator.Latest()}
+	// A database ahead of this build is fine: it happens while a newer
+	// release rolls out.
+	if version < status.Latest {
+		return failing(fmt.Errorf("database is at version %d, this build needs %d; run `migrate up`", version, status.Latest)), status
+	}
+	return Check{Status: StatusOK}, status
+}
+
+func (c *Checker) ping(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (c *Checker) schedulerCheck() Check {
+	status := c.jobs.Status()
+	if !status.Enabled {
+		return Check{Status: StatusDisabled}
+	}
+	if c.jobs.Stalled(time.Now()) {
+		return Check{Status: StatusFailing, Error: "scheduler is late for its next run; a job may be stuck"}
+	}
+	var failed []string
+	for _, job := range status.Jobs {
+		if job.LastError != "" {
+			failed = append(failed, job.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return Check{Status: StatusFailing, Error: "last run failed: " + strings.Join(failed, ", ")}
+	}
+	return Check{Status: StatusOK}
+}
+
+// Details runs the readiness checks and adds the build, uptime, pool and
+// scheduler details.
+func (c *Checker) Details(ctx context.Context) Details {
+	report, migrationStatus := c.check(ctx)
+	details := Details{
+		Report:     report,
+		Version:    Version,
+		Commit:     commit(),
+		GoVersion:  runtime.Version(),
+		StartedAt:  c.startedAt,
+		Uptime:     time.Since(c.startedAt).Round(time.Second).String(),
+		Database:   c.db.Dialector.Name(),
+		Migrations: migrationStatus,
+		Scheduler:  c.jobs.Status(),
+	}
+	if sqlDB, err := c.db.DB(); err == nil {
+		stats := sqlDB.Stats()
+		details.Pool = &PoolStats{
+			MaxOpen:           stats.MaxOpenConnections,
+			Open:              stats.OpenConnections,
+			InUse:             stats.InUse,
+			Idle:              stats.Idle,
+			WaitCount:         stats.WaitCount,
+			WaitDuration:      stats.WaitDuration.String(),
+			MaxIdleClosed:     stats.MaxIdleClosed,
+			MaxIdleTimeClosed: stats.MaxIdleTimeClosed,
+			MaxLifetimeClosed: stats.MaxLifetimeClosed,
+		}
+	}
+	return details
+}
+
+func failing(err error) Check {
+	return Check{Status: StatusFailing, Error: err.Error()}
+}
+
+func commit() string {
+	if Commit != "" {
+		return Commit
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "unknown"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return revision
+}
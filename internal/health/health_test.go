@@ -0,0 +1,146 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/handlers"
+	"library-management-system/internal/health"
+	"library-management-system/internal/scheduler"
+	"library-management-system/migrations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// readyz asks checker's /readyz and returns the status and the report.
+func readyz(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/readyz", handlers.NewHealthHandler(checker).Readyz)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var body struct {
+		Data health.Report `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("/readyz answered %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, body.Data
+}
+
+// migrated returns a database migrated up to limit versions, all of them
+// when limit is 0, and its migrator.
+func migrated(t *testing.T, driver string, limit int) (*gorm.DB, *migrations.Migrator) {
+	t.Helper()
+	db := e2e.NewTestDatabase(t, driver).DB
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(limit); err != nil {
+		t.Fatal(err)
+	}
+	return db, migrator
+}
+
+func expectChecks(t *testing.T, report health.Report, want map[string]string) {
+	t.Helper()
+	for name, status := range want {
+		if got := report.Checks[name].Status; got != status {
+			t.Errorf("%s check is %s (%s), want %s", name, got, report.Checks[name].Error, status)
+		}
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			t.Run("ready", func(t *testing.T) {
+				db, migrator := migrated(t, driver, 0)
+				code, report := readyz(t, health.NewChecker(db, migrator, scheduler.New(db, time.Minute)))
+				if code != http.StatusOK || !report.Ready {
+					t.Errorf("migrated database: status %d, report %+v", code, report)
+				}
+				expectChecks(t, report, map[string]string{
+					"database":   health.StatusOK,
+					"migrations": health.StatusOK,
+					"scheduler":  health.StatusDisabled,
+				})
+			})
+
+			t.Run("migrations behind", func(t *testing.T) {
+				db, migrator := migrated(t, driver, 1)
+				code, report := readyz(t, health.NewChecker(db, migrator, scheduler.New(db, time.Minute)))
+				if code != http.StatusServiceUnavailable || report.Ready {
+					t.Errorf("database behind on migrations: status %d, report %+v", code, report)
+				}
+				expectChecks(t, report, map[string]string{
+					"database":   health.StatusOK,
+					"migrations": health.StatusFailing,
+				})
+			})
+
+			t.Run("database unreachable", func(t *testing.T) {
+				db, migrator := migrated(t, driver, 0)
+				sqlDB, err := db.DB()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := sqlDB.Close(); err != nil {
+					t.Fatal(err)
+				}
+				code, report := readyz(t, health.NewChecker(db, migrator, scheduler.New(db, time.Minute)))
+				if code != http.StatusServiceUnavailable || report.Ready {
+					t.Errorf("closed database: status %d, report %+v", code, report)
+				}
+				expectChecks(t, report, map[string]string{
+					"database":   health.StatusFailing,
+					"migrations": health.StatusFailing,
+				})
+			})
+
+			t.Run("scheduler failing", func(t *testing.T) {
+				db, migrator := migrated(t, driver, 0)
+				jobs := scheduler.New(db, time.Minute)
+				jobs.Register("fails", func(ctx context.Context, now time.Time) (string, error) {
+					return "", errors.New("mail server refused")
+				})
+				ctx, cancel := context.WithCancel(context.Background())
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					jobs.Run(ctx)
+				}()
+				defer func() {
+					cancel()
+					<-done
+				}()
+				for deadline := time.Now().Add(5 * time.Second); jobs.Status().Jobs[0].Runs == 0; time.Sleep(5 * time.Millisecond) {
+					if time.Now().After(deadline) {
+						t.Fatal("the job never ran")
+					}
+				}
+
+				code, report := readyz(t, health.NewChecker(db, migrator, jobs))
+				if code != http.StatusOK || !report.Ready {
+					t.Errorf("failing job made the server unready: status %d, report %+v", code, report)
+				}
+				expectChecks(t, report, map[string]string{
+					"database":   health.StatusOK,
+					"migrations": health.StatusOK,
+					"scheduler":  health.StatusFailing,
+				})
+			})
+		})
+	}
+}
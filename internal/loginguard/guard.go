@@ -0,0 +1,152 @@
+package loginguard
+
+import (
+	"strings"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+)
+
+// Audit entity types for lockout events.
+const (
+	EntityAccount = "login_account"
+	EntityIP      = "login_ip"
+)
+
+const (
+	// freeFailures is how many failures in a row cost nothing, to allow
+	// for typos.
+	freeFailures = 2
+	maxDelay     = 30 * time.Second
+)
+
+// Guard throttles password guessing. Past a couple of failures, every
+// further failure makes the caller wait longer before the next try; at
+// the configured maximum the account or IP is locked out, and each
+// failure after that doubles the lockout.
+type Guard struct {
+	store     Store
+	config    config.LoginGuardConfig
+	auditRepo repository.AuditStore
+}
+
+func New(store Store, cfg config.LoginGuardConfig, audits repository.AuditStore) *Guard {
+	return &Guard{
+		store:     store,
+		config:    cfg,
+		auditRepo: audits,
+	}
+}
+
+// AccountKey counts failures per username regardless of case, so guesses
+// at "Admin" and "admin" share one quota.
+func AccountKey(username string) string {
+	return "user:" + normalizeUsername(username)
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long the caller must wait before trying to log in as
+// username from ip. Zero means the attempt may go ahead.
+func (g *Guard) Check(username, ip string, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range []string{AccountKey(username), IPKey(ip)} {
+		attempt, err := g.store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if attempt == nil || attempt.LockedUntil == nil {
+			continue
+		}
+		if remaining := attempt.LockedUntil.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+	return wait, nil
+}
+
+// Fail records a failed login against both the account and the IP. A
+// lockout it causes is audited under the request's ID.
+func (g *Guard) Fail(username, ip, requestID string, now time.Time) error {
+	event := models.AuditEvent{
+		Action:    models.AuditLoginLockout,
+		IPAddress: ip,
+		RequestID: requestID,
+	}
+	if err := g.fail(AccountKey(username), EntityAccount, normalizeUsername(username), g.config.MaxFailures, event, now); err != nil {
+		return err
+	}
+	return g.fail(IPKey(ip), EntityIP, ip, g.config.IPMaxFailures, event, now)
+}
+
+func (g *Guard) fail(key, entityType, entityID string, maxFailures int, event models.AuditEvent, now time.Time) error {
+	attempt, err := g.store.RecordFailure(key, now, g.config.Window)
+	if err != nil {
+		return err
+	}
+
+	delay := g.penalty(attempt.Failures, maxFailures)
+	if delay == 0 {
+		return nil
+	}
+	until := now.Add(delay)
+	if err := g.store.Lock(key, until); err != nil {
+		return err
+	}
+	if attempt.Failures < maxFailures {
+		return nil
+	}
+
+	event.EntityType = entityType
+	event.EntityID = entityID
+	return g.auditRepo.Record(&event, nil, nil, map[string]interface{}{
+		"failures":     attempt.Failures,
+		"locked_until": until,
+	})
+}
+
+// Succeed clears the account's failures. The IP count is left alone, so a
+// valid login of one account does not reset guessing at others.
+func (g *Guard) Succeed(username string) error {
+	return g.store.Reset(AccountKey(username))
+}
+
+// UnlockAccount lifts a lockout of username early.
+func (g *Guard) UnlockAccount(username string) error {
+	return g.store.Reset(AccountKey(username))
+}
+
+// UnlockIP lifts a lockout of a client IP early.
+func (g *Guard) UnlockIP(ip string) error {
+	return g.store.Reset(IPKey(ip))
+}
+
+func (g *Guard) penalty(failures, maxFailures int) time.Duration {
+	if failures >= maxFailures {
+		return double(g.config.Lockout, failures-maxFailures, g.config.MaxLockout)
+	}
+	if failures > freeFailures {
+		return double(time.Second, failures-freeFailures-1, maxDelay)
+	}
+	return 0
+}
+
+// double returns base doubled steps times, capped at limit.
+func double(base time.Duration, steps int, limit time.Duration) time.Duration {
+	d := base
+	for i := 0; i < steps && d < limit; i++ {
+		d *= 2
+	}
+	if d > limit {
+		return limit
+	}
+	return d
+}
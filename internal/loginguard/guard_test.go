@@ -0,0 +1,167 @@
+package loginguard_test
+
+import (
+	"testing"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/loginguard"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/repository/memory"
+)
+
+var start = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+func testConfig() config.LoginGuardConfig {
+	return config.LoginGuardConfig{
+		MaxFailures:   5,
+		IPMaxFailures: 8,
+		Window:        time.Hour,
+		Lockout:       15 * time.Minute,
+		MaxLockout:    time.Hour,
+	}
+}
+
+// forEachStore runs test with a guard over the in-memory store and over
+// the database store on each driver.
+func forEachStore(t *testing.T, test func(t *testing.T, guard *loginguard.Guard, audits repository.AuditStore)) {
+	t.Run("memory", func(t *testing.T) {
+		audits := memory.NewStores(config.Default().Circulation).Audit
+		test(t, loginguard.New(loginguard.NewMemoryStore(), testConfig(), audits), audits)
+	})
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run("database/"+driver, func(t *testing.T) {
+			h := e2e.NewTestHarness(t, driver)
+			store, err := loginguard.NewStore("database", h.DB)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test(t, loginguard.New(store, testConfig(), h.Stores.Audit), h.Stores.Audit)
+		})
+	}
+}
+
+func check(t *testing.T, guard *loginguard.Guard, username, ip string, now time.Time) time.Duration {
+	t.Helper()
+	wait, err := guard.Check(username, ip, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wait
+}
+
+func fail(t *testing.T, guard *loginguard.Guard, username, ip string, now time.Time) {
+	t.Helper()
+	if err := guard.Fail(username, ip, "req-1", now); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestProgressiveDelay fails one account's login again and again: the
+// first two failures are free, the next ones cost a doubling delay, and
+// the fifth locks the account out, for longer with each failure after.
+func TestProgressiveDelay(t *testing.T) {
+	forEachStore(t, func(t *testing.T, guard *loginguard.Guard, audits repository.AuditStore) {
+		want := []time.Duration{0, 0, time.Second, 2 * time.Second, 15 * time.Minute, 30 * time.Minute, time.Hour, time.Hour}
+		now := start
+		for i, delay := range want {
+			fail(t, guard, "siti", "192.0.2.1", now)
+			if got := check(t, guard, "siti", "192.0.2.2", now); got != delay {
+				t.Errorf("after %d failures the wait is %s, want %s", i+1, got, delay)
+			}
+			if got := check(t, guard, "siti", "192.0.2.2", now.Add(delay)); got != 0 {
+				t.Errorf("after %d failures the wait is %s once the delay has passed", i+1, got)
+			}
+			now = now.Add(delay)
+		}
+
+		lockouts, _, err := audits.List(repository.AuditFilter{Action: models.AuditLoginLockout, EntityType: loginguard.EntityAccount}, repository.Page{Number: 1, PerPage: 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lockouts) != 4 || lockouts[0].EntityID != "siti" || lockouts[0].RequestID != "req-1" {
+			t.Errorf("lockouts audited: %+v, want 4 of siti", lockouts)
+		}
+	})
+}
+
+// TestKeys fails logins of many accounts from one IP and of one account
+// from many IPs. Each is counted against both the account and the IP, and
+// usernames are counted regardless of case.
+func TestKeys(t *testing.T) {
+	forEachStore(t, func(t *testing.T, guard *loginguard.Guard, audits repository.AuditStore) {
+		for _, username := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+			fail(t, guard, username, "192.0.2.1", start)
+		}
+		if got := check(t, guard, "i", "192.0.2.1", start); got != 15*time.Minute {
+			t.Errorf("eight failures from one IP make it wait %s, want the lockout", got)
+		}
+		if got := check(t, guard, "a", "192.0.2.2", start); got != 0 {
+			t.Errorf("an account with one failure waits %s from another IP", got)
+		}
+
+		for i, ip := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+			username := []string{"budi", "Budi", " BUDI "}[i]
+			fail(t, guard, username, ip, start)
+		}
+		if got := check(t, guard, "budi", "198.51.100.4", start); got != time.Second {
+			t.Errorf("three failures of one account from three IPs make it wait %s, want 1s", got)
+		}
+		if got := check(t, guard, "rina", "198.51.100.1", start); got != 0 {
+			t.Errorf("another account waits %s from an IP with one failure", got)
+		}
+	})
+}
+
+func TestUnlock(t *testing.T) {
+	forEachStore(t, func(t *testing.T, guard *loginguard.Guard, audits repository.AuditStore) {
+		for i := 0; i < 8; i++ {
+			fail(t, guard, "siti", "192.0.2.1", start)
+		}
+		if got := check(t, guard, "siti", "192.0.2.2", start); got == 0 {
+			t.Fatal("account is not locked out")
+		}
+		if err := guard.UnlockAccount("Siti"); err != nil {
+			t.Fatal(err)
+		}
+		if got := check(t, guard, "siti", "192.0.2.2", start); got != 0 {
+			t.Errorf("unlocked account waits %s", got)
+		}
+		if got := check(t, guard, "siti", "192.0.2.1", start); got == 0 {
+			t.Error("unlocking the account unlocked the IP too")
+		}
+		if err := guard.UnlockIP("192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+		if got := check(t, guard, "siti", "192.0.2.1", start); got != 0 {
+			t.Errorf("unlocked account and IP wait %s", got)
+		}
+	})
+}
+
+// TestResetCounts checks that a failure count starts over after a
+// successful login and once the window has passed.
+func TestResetCounts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, guard *loginguard.Guard, audits repository.AuditStore) {
+		for i := 0; i < 4; i++ {
+			fail(t, guard, "siti", "192.0.2.1", start)
+		}
+		if err := guard.Succeed("siti"); err != nil {
+			t.Fatal(err)
+		}
+		fail(t, guard, "siti", "192.0.2.2", start)
+		fail(t, guard, "siti", "192.0.2.2", start)
+		if got := check(t, guard, "siti", "192.0.2.2", start); got != 0 {
+			t.Errorf("two failures after a successful login make the account wait %s", got)
+		}
+
+		later := start.Add(2 * time.Hour)
+		fail(t, guard, "siti", "192.0.2.3", later)
+		if got := check(t, guard, "siti", "192.0.2.3", later); got != 0 {
+			t.Errorf("a failure after the window makes the account wait %s", got)
+		}
+	})
+}
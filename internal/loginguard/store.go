@@ -0,0 +1,135 @@
+package loginguard
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Store keeps failed login counts. The in-memory store is enough for a
+// single instance; instances behind a load balancer must share the
+// database store, or each would allow its own quota of guesses.
+type Store interface {
+	// Get returns the count for key, or nil when there is none.
+	Get(key string) (*models.LoginAttempt, error)
+	// RecordFailure adds one failure, starting again from one when the
+	// last failure is older than window.
+	RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+// NewStore builds the store chosen by LOGIN_GUARD_STORE. "postgres" is
+// the name the database store had before SQLite was supported.
+func NewStore(name string, db *gorm.DB) (Store, error) {
+	switch name {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "database", "postgres":
+		return &DBStore{repo: repository.NewLoginAttemptRepository(db)}, nil
+	default:
+		return nil, fmt.Errorf("unknown login guard store %q", name)
+	}
+}
+
+// memorySweepSize is how many keys the in-memory store holds before it
+// drops the ones that no longer matter.
+const memorySweepSize = 10000
+
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: make(map[string]models.LoginAttempt)}
+}
+
+func (s *MemoryStore) Get(key string) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (s *MemoryStore) RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.attempts) >= memorySweepSize {
+		s.sweep(now, window)
+	}
+
+	attempt, ok := s.attempts[key]
+	if !ok || attempt.LastFailureAt.Before(now.Add(-window)) {
+		attempt.Key = key
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	s.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (s *MemoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.LockedUntil = &until
+		s.attempts[key] = attempt
+	}
+	return nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time, window time.Duration) {
+	for key, attempt := range s.attempts {
+		locked := attempt.LockedUntil != nil && attempt.LockedUntil.After(now)
+		if !locked && attempt.LastFailureAt.Before(now.Add(-window)) {
+			delete(s.attempts, key)
+		}
+	}
+}
+
+// DBStore keeps counts in the login_attempts table.
+type DBStore struct {
+	repo repository.LoginAttemptStore
+}
+
+func (s *DBStore) Get(key string) (*models.LoginAttempt, error) {
+	attempt, err := s.repo.Get(key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return attempt, err
+}
+
+func (s *DBStore) RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	return s.repo.RecordFailure(key, now, window)
+}
+
+func (s *DBStore) Lock(key string, until time.Time) error {
+	return s.repo.Lock(key, until)
+}
+
+func (s *DBStore) Reset(key string) error {
+	return s.repo.Delete(key)
+}
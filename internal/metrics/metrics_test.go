@@ -0,0 +1,158 @@
+package metrics_test
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/metrics"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scrape fetches /metrics from router with the given bearer token and
+// returns the status and, on success, the value of each unlabelled series.
+func scrape(t *testing.T, router http.Handler, token string) (int, map[string]float64) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+
+	values := map[string]float64{}
+	lines := bufio.NewScanner(rec.Body)
+	for lines.Scan() {
+		name, value, ok := strings.Cut(lines.Text(), " ")
+		if !ok || strings.HasPrefix(name, "#") || strings.Contains(name, "{") {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			t.Fatalf("series %s has value %q", name, value)
+		}
+		values[name] = n
+	}
+	return rec.Code, values
+}
+
+// TestScrape checks that /metrics wants its token and reports the
+// circulation and sign-in figures from the database.
+func TestScrape(t *testing.T) {
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			h := e2e.NewTestHarness(t, driver)
+			if err := h.Seed(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, token := range []string{"", "not-the-metrics-token"} {
+				if code, _ := scrape(t, h.Router, token); code != http.StatusUnauthorized {
+					t.Errorf("scrape with token %q: status %d, want %d", token, code, http.StatusUnauthorized)
+				}
+			}
+
+			book := &models.Book{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", ISBN: "978-979-97312-3-4", Stock: 3}
+			if err := h.Stores.Books.Create(book); err != nil {
+				t.Fatal(err)
+			}
+			member := &models.Member{Name: "Budi Santoso", Email: "budi@example.com", MemberCode: "M-0001", Status: "active"}
+			if err := h.Stores.Members.Create(member); err != nil {
+				t.Fatal(err)
+			}
+			returned := e2e.Start.Add(24 * time.Hour)
+			loans := []*models.Loan{
+				{Status: models.LoanStatusBorrowed},
+				{Status: models.LoanStatusOverdue},
+				{Status: models.LoanStatusOverdue},
+				{Status: models.LoanStatusReturned, ReturnDate: &returned},
+			}
+			for _, loan := range loans {
+				loan.BookID, loan.MemberID = book.ID, member.ID
+				loan.LoanDate, loan.DueDate = e2e.Start, e2e.Start.Add(24*time.Hour)
+				if err := h.Stores.Loans.Create(loan); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// A deleted loan still counts in the totals but not as overdue.
+			if err := h.DB.Delete(&models.Loan{}, loans[2].ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Stores.Fines.ChargeLoan(loans[1], 3000, "Overdue fine", nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Stores.Fines.ChargeLoan(loans[3], 1500, "Overdue fine", nil); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username":"`+e2e.LibrarianUsername+`","password":"NotThePassword1"}`))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				h.Router.ServeHTTP(rec, req)
+				if rec.Code != http.StatusUnauthorized {
+					t.Fatalf("login with the wrong password: status %d", rec.Code)
+				}
+			}
+
+			code, values := scrape(t, h.Router, e2e.MetricsToken)
+			if code != http.StatusOK {
+				t.Fatalf("scrape with the token: status %d", code)
+			}
+			for name, want := range map[string]float64{
+				"library_loans_active":                2,
+				"library_loans_overdue":               1,
+				"library_loans_created_total":         4,
+				"library_loans_returned_total":        1,
+				"library_fines_assessed_total":        2,
+				"library_fines_assessed_amount_total": 4500,
+				"library_login_failures_total":        2,
+			} {
+				if got, ok := values[name]; !ok || got != want {
+					t.Errorf("%s = %v (reported %t), want %v", name, got, ok, want)
+				}
+			}
+		})
+	}
+}
+
+// failingFines is a fine store whose totals cannot be read.
+type failingFines struct {
+	repository.FineStore
+}
+
+func (failingFines) ChargeTotals() (int64, int64, error) {
+	return 0, 0, errors.New("fine_entries is gone")
+}
+
+// TestScrapeFailsWithQuery checks that a figure that cannot be read fails
+// the scrape instead of being reported as zero.
+func TestScrapeFailsWithQuery(t *testing.T) {
+	h := e2e.NewTestHarness(t, config.DriverSQLite)
+	stores := *h.Stores
+	stores.Fines = failingFines{stores.Fines}
+	m, err := metrics.New(h.DB, &stores)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/metrics", gin.WrapH(m.Handler()))
+	if code, _ := scrape(t, router, ""); code != http.StatusInternalServerError {
+		t.Errorf("scrape with a failing query: status %d, want %d", code, http.StatusInternalServerError)
+	}
+}
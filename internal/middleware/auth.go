@@ -1,15 +1,31 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"library-management-system/internal/repository"
 	"library-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(issuer *utils.TokenIssuer, users repository.UserStore, tokens repository.TokenStore) gin.HandlerFunc {
+	return authenticate(issuer, users, tokens, "")
+}
+
+// MFAEnrollmentMiddleware lets through access tokens and the enrollment
+// tokens given to users who must set up two-factor authentication before
+// their login can finish.
+func MFAEnrollmentMiddleware(issuer *utils.TokenIssuer, users repository.UserStore, tokens repository.TokenStore) gin.HandlerFunc {
+	return authenticate(issuer, users, tokens, "", utils.TokenPurposeMFAEnroll)
+}
+
+// authenticate accepts tokens issued for one of the given purposes; an
+// access token has the empty purpose.
+func authenticate(issuer *utils.TokenIssuer, users repository.UserStore, tokens repository.TokenStore, purposes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -26,36 +42,67 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims, err := utils.ValidateToken(tokenString)
-		if err != nil {
+		claims, err := issuer.ValidateToken(tokenString)
+		if err != nil || !hasPurpose(claims, purposes) {
 			utils.UnauthorizedResponse(c, "Invalid or expired token")
 			c.Abort()
 			return
 		}
 
+		revoked, err := isRevoked(users, tokens, claims)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check token")
+			c.Abort()
+			return
+		}
+		if revoked {
+			utils.UnauthorizedResponse(c, "Token has been revoked")
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("token_id", claims.ID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+		c.Set("token_purpose", claims.Purpose)
 
 		c.Next()
 	}
 }
 
-func AdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists {
-			utils.UnauthorizedResponse(c, "User role not found")
-			c.Abort()
-			return
+func hasPurpose(claims *utils.Claims, purposes []string) bool {
+	for _, purpose := range purposes {
+		if claims.Purpose == purpose {
+			return true
 		}
+	}
+	return false
+}
 
-		if role != "admin" {
-			utils.ErrorResponse(c, http.StatusForbidden, "Access denied. Admin role required")
-			c.Abort()
-			return
-		}
+// isRevoked reports whether a valid token may no longer be used: it was
+// logged out, its user was logged out everywhere after it was issued, or
+// the account is gone or deactivated.
+func isRevoked(users repository.UserStore, tokens repository.TokenStore, claims *utils.Claims) (bool, error) {
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return true, nil
+	}
 
-		c.Next()
+	revoked, err := tokens.IsAccessTokenRevoked(claims.ID)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	user, err := users.GetByID(claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !user.IsActive {
+		return true, nil
 	}
+	return user.SessionsRevokedAt != nil && claims.IssuedAt.Time.Before(*user.SessionsRevokedAt), nil
 }
@@ -0,0 +1,124 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/middleware"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/repository/memory"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+var issuer = utils.NewTokenIssuer("middleware-test-secret-that-is-long-enough", 15*time.Minute, time.Hour)
+
+// forEachStores runs test on the in-memory stores and on the database
+// stores of each driver.
+func forEachStores(t *testing.T, test func(t *testing.T, stores *repository.Stores)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, memory.NewStores(config.Default().Circulation))
+	})
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run("database/"+driver, func(t *testing.T) {
+			test(t, e2e.NewTestHarness(t, driver).Stores)
+		})
+	}
+}
+
+// TestAuthRevocation checks the tokens the middleware turns away although
+// they are signed and unexpired.
+func TestAuthRevocation(t *testing.T) {
+	forEachStores(t, func(t *testing.T, stores *repository.Stores) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/", middleware.AuthMiddleware(issuer, stores.Users, stores.Tokens), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		get := func(token string) int {
+			t.Helper()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		user := &models.User{Username: "siti", Email: "siti@example.com", Password: "BorrowMore42", Role: models.RoleMember, IsActive: true}
+		if err := stores.Users.Create(user); err != nil {
+			t.Fatal(err)
+		}
+		token := func(userID uint) (string, *utils.Claims) {
+			t.Helper()
+			token, claims, err := issuer.GenerateToken(userID, user.Username, user.Role)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token, claims
+		}
+
+		valid, claims := token(user.ID)
+		if code := get(valid); code != http.StatusOK {
+			t.Fatalf("valid token: status %d", code)
+		}
+
+		loggedOut, loggedOutClaims := token(user.ID)
+		if err := stores.Tokens.RevokeAccessToken(loggedOutClaims.ID, user.ID, loggedOutClaims.ExpiresAt.Time); err != nil {
+			t.Fatal(err)
+		}
+		if code := get(loggedOut); code != http.StatusUnauthorized {
+			t.Errorf("token with a revoked jti: status %d, want %d", code, http.StatusUnauthorized)
+		}
+		if code := get(valid); code != http.StatusOK {
+			t.Errorf("revoking one token turned away another: status %d", code)
+		}
+
+		// Issue times have one-second resolution, so a token issued in the
+		// second sessions were revoked survives, and one from the second
+		// before does not.
+		issued := claims.IssuedAt.Time
+		if err := stores.Users.RevokeSessions(user.ID, issued.Add(500*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+		if code := get(valid); code != http.StatusOK {
+			t.Errorf("token issued in the second of the revocation: status %d, want %d", code, http.StatusOK)
+		}
+		if err := stores.Users.RevokeSessions(user.ID, issued.Add(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		if code := get(valid); code != http.StatusUnauthorized {
+			t.Errorf("token issued before the sessions were revoked: status %d, want %d", code, http.StatusUnauthorized)
+		}
+
+		if err := stores.Users.RevokeSessions(user.ID, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		active, _ := token(user.ID)
+		if err := stores.Users.SetActive(user.ID, false); err != nil {
+			t.Fatal(err)
+		}
+		if code := get(active); code != http.StatusUnauthorized {
+			t.Errorf("token of a deactivated user: status %d, want %d", code, http.StatusUnauthorized)
+		}
+
+		unknown, _ := token(user.ID + 1000)
+		if code := get(unknown); code != http.StatusUnauthorized {
+			t.Errorf("token of an unknown user: status %d, want %d", code, http.StatusUnauthorized)
+		}
+
+		enroll, _, err := issuer.GeneratePurposeToken(user.ID, user.Username, user.Role, utils.TokenPurposeMFAEnroll, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := get(enroll); code != http.StatusUnauthorized {
+			t.Errorf("enrollment token as an access token: status %d, want %d", code, http.StatusUnauthorized)
+		}
+	})
+}
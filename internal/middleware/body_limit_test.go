@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"library-management-system/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestBodyLimit sends bodies just under and over the limit, with their
+// length declared up front and streamed without one.
+func TestBodyLimit(t *testing.T) {
+	const limit = 1024
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var reached bool
+	router.POST("/", middleware.BodyLimit(limit), func(c *gin.Context) {
+		reached = true
+		body, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	})
+
+	for _, c := range []struct {
+		name     string
+		size     int
+		streamed bool
+		code     int
+		reached  bool
+	}{
+		{"declared at the limit", limit, false, http.StatusOK, true},
+		{"declared over the limit", limit + 1, false, http.StatusRequestEntityTooLarge, false},
+		{"streamed at the limit", limit, true, http.StatusOK, true},
+		{"streamed over the limit", limit + 1, true, http.StatusBadRequest, true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			reached = false
+			var body io.Reader = strings.NewReader(strings.Repeat("x", c.size))
+			if c.streamed {
+				// Hiding the reader's type keeps the length from being declared.
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			if c.streamed {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != c.code || reached != c.reached {
+				t.Errorf("status %d with the handler reached %t, want %d and %t", rec.Code, reached, c.code, c.reached)
+			}
+			if c.code == http.StatusRequestEntityTooLarge && !strings.Contains(rec.Body.String(), "1024 bytes") {
+				t.Errorf("413 body is %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Permission string
+
+const (
+	PermBooksRead      Permission = "books:read"
+	PermBooksWrite     Permission = "books:write"
+	PermBooksDelete    Permission = "books:delete"
+	PermMembersRead    Permission = "members:read"
+	PermMembersWrite   Permission = "members:write"
+	PermMembersDelete  Permission = "members:delete"
+	PermLoansRead      Permission = "loans:read"
+	PermLoansWrite     Permission = "loans:write"
+	PermUsersManage    Permission = "users:manage"
+	PermPoliciesManage Permission = "policies:manage"
+	PermFinesRead      Permission = "fines:read"
+	PermFinesWrite     Permission = "fines:write"
+	PermFinesWaive     Permission = "fines:waive"
+	PermJobsRead       Permission = "jobs:read"
+	PermAuditRead      Permission = "audit:read"
+	// PermHoldsOwn covers /api/me/holds: the holds of the member the
+	// account is linked to, and nobody else's.
+	PermHoldsOwn Permission = "holds:own"
+)
+
+// RolePermissions is the single source of truth for what each role may do.
+var RolePermissions = map[string][]Permission{
+	models.RoleAdmin: {
+		PermBooksRead, PermBooksWrite, PermBooksDelete,
+		PermMembersRead, PermMembersWrite, PermMembersDelete,
+		PermLoansRead, PermLoansWrite,
+		PermFinesRead, PermFinesWrite, PermFinesWaive,
+		PermUsersManage,
+		PermPoliciesManage,
+		PermJobsRead,
+		PermAuditRead,
+	},
+	models.RoleLibrarian: {
+		PermBooksRead, PermBooksWrite,
+		PermMembersRead, PermMembersWrite,
+		PermLoansRead, PermLoansWrite,
+		PermFinesRead, PermFinesWrite,
+	},
+	models.RoleAuditor: {
+		PermBooksRead,
+		PermMembersRead,
+		PermLoansRead,
+		PermFinesRead,
+		PermAuditRead,
+	},
+	models.RoleMember: {
+		PermBooksRead,
+		PermHoldsOwn,
+	},
+	models.RoleUser: {
+		PermBooksRead,
+		PermHoldsOwn,
+	},
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission must run after AuthMiddleware, which puts the caller's
+// role on the context.
+func RequirePermission(permission Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("role")
+		if !exists {
+			utils.UnauthorizedResponse(c, "User role not found")
+			c.Abort()
+			return
+		}
+
+		roleName, _ := role.(string)
+		if !HasPermission(roleName, permission) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Access denied. Missing permission "+string(permission))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
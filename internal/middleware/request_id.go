@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"regexp"
+
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID keeps caller-supplied IDs short and printable, since they
+// end up in the audit log.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestID tags every request with an ID, taken from the X-Request-ID
+// header when the caller (or a proxy) sent a usable one. The ID is echoed
+// in the response and stored with audit events.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id, _ = utils.RandomToken(16)
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaticToken lets through requests that carry token as a bearer token,
+// for machine clients such as a Prometheus scraper that cannot sign in.
+func StaticToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			utils.UnauthorizedResponse(c, "Invalid or missing token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
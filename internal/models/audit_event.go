@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditEvent records who changed what. ActorID is empty when the system
+// acted on its own, e.g. when it locks an account after too many failed
+// logins. Before and After are JSON snapshots of the entity, empty for
+// creations and deletions respectively, and Changes holds the fields that
+// differ between them. Details is a JSON object with anything else worth
+// keeping, such as a reason or an amount.
+type AuditEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorID    *uint     `json:"actor_id" gorm:"index"`
+	Action     string    `json:"action" gorm:"not null;index"`
+	EntityType string    `json:"entity_type" gorm:"not null;index:idx_audit_events_entity"`
+	EntityID   string    `json:"entity_id" gorm:"index:idx_audit_events_entity"`
+	Before     string    `json:"before,omitempty" gorm:"type:text"`
+	After      string    `json:"after,omitempty" gorm:"type:text"`
+	Changes    string    `json:"changes,omitempty" gorm:"type:text"`
+	Details    string    `json:"details,omitempty" gorm:"type:text"`
+	IPAddress  string    `json:"ip_address"`
+	RequestID  string    `json:"request_id" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// Audited actions, named "<entity>.<verb>".
+const (
+	AuditBookCreate     = "book.create"
+	AuditBookUpdate     = "book.update"
+	AuditBookDelete     = "book.delete"
+	AuditBookCopyCreate = "book_copy.create"
+	AuditBookCopyUpdate = "book_copy.update"
+
+	AuditMemberCreate = "member.create"
+	AuditMemberUpdate = "member.update"
+	AuditMemberDelete = "member.delete"
+
+	AuditLoanCreate = "loan.create"
+	AuditLoanReturn = "loan.return"
+	AuditLoanRenew  = "loan.renew"
+
+	AuditHoldCreate = "hold.create"
+	AuditHoldCancel = "hold.cancel"
+	AuditHoldExpire = "hold.expire"
+
+	AuditFineCharge  = "fine.charge"
+	AuditFinePayment = "fine.payment"
+	AuditFineWaive   = "fine.waive"
+
+	AuditPolicyCreate = "circulation_policy.create"
+	AuditPolicyUpdate = "circulation_policy.update"
+	AuditPolicyDelete = "circulation_policy.delete"
+
+	AuditUserCreate     = "user.create"
+	AuditUserRoleChange = "user.role_change"
+	AuditUserDeactivate = "user.deactivate"
+	AuditUserLogout     = "user.logout"
+	AuditUserLinkMember = "user.link_member"
+
+	AuditRegister             = "auth.register"
+	AuditLogin                = "auth.login"
+	AuditLoginFailed          = "auth.login_failed"
+	AuditLogout               = "auth.logout"
+	AuditLogoutAll            = "auth.logout_all"
+	AuditPasswordChange       = "auth.password_change"
+	AuditPasswordResetRequest = "auth.password_reset_request"
+	AuditPasswordReset        = "auth.password_reset"
+	AuditLoginLockout         = "auth.lockout"
+	AuditLoginUnlock          = "auth.unlock"
+	AuditMFAEnabled           = "auth.mfa_enabled"
+	AuditMFADisabled          = "auth.mfa_disabled"
+	AuditMFAReset             = "auth.mfa_reset"
+)
+
+// Audited entity types.
+const (
+	AuditEntityBook     = "book"
+	AuditEntityBookCopy = "book_copy"
+	AuditEntityMember   = "member"
+	AuditEntityLoan     = "loan"
+	AuditEntityHold     = "hold"
+	AuditEntityFine     = "fine_entry"
+	AuditEntityPolicy   = "circulation_policy"
+	AuditEntityUser     = "user"
+)
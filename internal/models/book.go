@@ -13,7 +13,7 @@ type Book struct {
 	ISBN        string         `json:"isbn" gorm:"unique;not null"`
 	Publisher   string         `json:"publisher"`
 	Year        int            `json:"year"`
-	Category    string         `json:"category"`
+	Category    string         `json:"category" gorm:"index"`
 	Description string         `json:"description"`
 	Stock       int            `json:"stock" gorm:"default:0"`
 	Available   int            `json:"available" gorm:"default:0"`
@@ -21,4 +21,5 @@ type Book struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 	Loans       []Loan         `json:"loans,omitempty" gorm:"foreignKey:BookID"`
+	Copies      []BookCopy     `json:"copies,omitempty" gorm:"foreignKey:BookID"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CopyStatusAvailable = "available"
+	CopyStatusOnLoan    = "on_loan"
+	CopyStatusOnHold    = "on_hold"
+	CopyStatusDamaged   = "damaged"
+	CopyStatusLost      = "lost"
+	CopyStatusWithdrawn = "withdrawn"
+
+	CopyConditionGood    = "good"
+	CopyConditionFair    = "fair"
+	CopyConditionPoor    = "poor"
+	CopyConditionDamaged = "damaged"
+)
+
+type BookCopy struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	BookID          uint           `json:"book_id" gorm:"not null;index"`
+	Barcode         string         `json:"barcode" gorm:"unique;not null"`
+	AccessionNumber string         `json:"accession_number" gorm:"unique;not null"`
+	ShelfLocation   string         `json:"shelf_location"`
+	Condition       string         `json:"condition" gorm:"default:'good'"`
+	Status          string         `json:"status" gorm:"default:'available';index"`
+	Notes           string         `json:"notes"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+	Book            *Book          `json:"book,omitempty" gorm:"foreignKey:BookID"`
+}
+
+func IsValidCopyCondition(condition string) bool {
+	switch condition {
+	case CopyConditionGood, CopyConditionFair, CopyConditionPoor, CopyConditionDamaged:
+		return true
+	}
+	return false
+}
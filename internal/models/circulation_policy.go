@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// CirculationPolicy sets the lending rules for a member type and book
+// category. An empty MemberType or Category matches any value; the most
+// specific policy wins. Fines are whole rupiah.
+type CirculationPolicy struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"not null"`
+	MemberType     string    `json:"member_type" gorm:"uniqueIndex:idx_circulation_policies_scope"`
+	Category       string    `json:"category" gorm:"uniqueIndex:idx_circulation_policies_scope"`
+	LoanPeriodDays int       `json:"loan_period_days" gorm:"not null"`
+	MaxLoans       int       `json:"max_loans" gorm:"not null"`
+	MaxRenewals    int       `json:"max_renewals" gorm:"not null"`
+	DailyFine      int64     `json:"daily_fine" gorm:"not null"`
+	GraceDays      int       `json:"grace_days" gorm:"not null;default:0"`
+	MaxFine        int64     `json:"max_fine" gorm:"not null;default:0"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DefaultCirculationPolicy applies when no stored policy matches a loan.
+func DefaultCirculationPolicy() CirculationPolicy {
+	return CirculationPolicy{
+		Name:           "Default",
+		LoanPeriodDays: 14,
+		MaxLoans:       5,
+		MaxRenewals:    2,
+		DailyFine:      1000,
+	}
+}
+
+func (p *CirculationPolicy) DueDate(from time.Time) time.Time {
+	return from.AddDate(0, 0, p.LoanPeriodDays)
+}
+
+// DaysOverdue counts whole days between the due date and at.
+func (p *CirculationPolicy) DaysOverdue(due, at time.Time) int {
+	if !at.After(due) {
+		return 0
+	}
+	return int(at.Sub(due).Hours() / 24)
+}
+
+// FineFor is the fine owed for a loan due at due and returned (or still
+// out) at at. Grace days are never charged, and the total is capped at
+// MaxFine when it is set.
+func (p *CirculationPolicy) FineFor(due, at time.Time) int64 {
+	chargeable := p.DaysOverdue(due, at) - p.GraceDays
+	if chargeable <= 0 {
+		return 0
+	}
+	fine := int64(chargeable) * p.DailyFine
+	if p.MaxFine > 0 && fine > p.MaxFine {
+		fine = p.MaxFine
+	}
+	return fine
+}
+
+// InGracePeriod reports whether a loan may still be renewed at at.
+func (p *CirculationPolicy) InGracePeriod(due, at time.Time) bool {
+	return !at.After(due.AddDate(0, 0, p.GraceDays))
+}
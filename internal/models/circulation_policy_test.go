@@ -0,0 +1,63 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"library-management-system/internal/models"
+)
+
+var due = time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+func after(days, hours int) time.Time {
+	return due.AddDate(0, 0, days).Add(time.Duration(hours) * time.Hour)
+}
+
+func TestFineFor(t *testing.T) {
+	policy := models.CirculationPolicy{DailyFine: 500, GraceDays: 2, MaxFine: 3000}
+	for _, c := range []struct {
+		name string
+		at   time.Time
+		want int64
+	}{
+		{"before the due date", after(-1, 0), 0},
+		{"on the due date", due, 0},
+		{"part of a day late", after(0, 23), 0},
+		{"within the grace days", after(2, 0), 0},
+		{"a day past the grace days", after(3, 0), 500},
+		{"part days are not charged", after(4, 23), 1000},
+		{"up to the cap", after(8, 0), 3000},
+		{"past the cap", after(30, 0), 3000},
+	} {
+		if got := policy.FineFor(due, c.at); got != c.want {
+			t.Errorf("%s: fine is %d, want %d", c.name, got, c.want)
+		}
+	}
+
+	uncapped := models.DefaultCirculationPolicy()
+	if got := uncapped.FineFor(due, after(30, 0)); got != 30000 {
+		t.Errorf("30 days late without a cap: fine is %d, want 30000", got)
+	}
+}
+
+func TestDueDateAndGracePeriod(t *testing.T) {
+	policy := models.CirculationPolicy{LoanPeriodDays: 7, GraceDays: 1}
+	if got, want := policy.DueDate(due), after(7, 0); !got.Equal(want) {
+		t.Errorf("due date is %s, want %s", got, want)
+	}
+	if got := policy.DaysOverdue(due, after(3, 12)); got != 3 {
+		t.Errorf("days overdue is %d, want 3", got)
+	}
+	for _, c := range []struct {
+		at   time.Time
+		want bool
+	}{
+		{due, true},
+		{after(1, 0), true},
+		{after(1, 1), false},
+	} {
+		if got := policy.InGracePeriod(due, c.at); got != c.want {
+			t.Errorf("in grace period at %s is %t, want %t", c.at, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	FineEntryCharge  = "charge"
+	FineEntryPayment = "payment"
+	FineEntryWaiver  = "waiver"
+)
+
+const (
+	PaymentMethodCash     = "cash"
+	PaymentMethodTransfer = "transfer"
+)
+
+func IsValidPaymentMethod(method string) bool {
+	return method == PaymentMethodCash || method == PaymentMethodTransfer
+}
+
+// FineEntry is one line of a member's fines ledger. Amount is always
+// positive in whole rupiah: charges add to the balance, payments and
+// waivers take from it. Entries are never edited or deleted; mistakes are
+// corrected with a waiver.
+type FineEntry struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	MemberID      uint      `json:"member_id" gorm:"not null;index"`
+	LoanID        *uint     `json:"loan_id" gorm:"index"`
+	Type          string    `json:"type" gorm:"not null;index"`
+	Amount        int64     `json:"amount" gorm:"not null"`
+	Method        string    `json:"method,omitempty"`
+	Reference     string    `json:"reference,omitempty"`
+	ReceiptNumber *string   `json:"receipt_number,omitempty" gorm:"uniqueIndex"`
+	Reason        string    `json:"reason,omitempty"`
+	RecordedByID  *uint     `json:"recorded_by_id"`
+	ApprovedByID  *uint     `json:"approved_by_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	Member        *Member   `json:"member,omitempty" gorm:"foreignKey:MemberID"`
+	Loan          *Loan     `json:"loan,omitempty" gorm:"foreignKey:LoanID"`
+}
+
+// FineBalance summarises a member's ledger.
+type FineBalance struct {
+	MemberID uint  `json:"member_id"`
+	Charged  int64 `json:"charged"`
+	Paid     int64 `json:"paid"`
+	Waived   int64 `json:"waived"`
+	Balance  int64 `json:"balance"`
+}
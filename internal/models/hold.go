@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	HoldStatusWaiting   = "waiting"
+	HoldStatusReady     = "ready_for_pickup"
+	HoldStatusFulfilled = "fulfilled"
+	HoldStatusCancelled = "cancelled"
+	HoldStatusExpired   = "expired"
+)
+
+type Hold struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	BookID    uint           `json:"book_id" gorm:"not null;index"`
+	MemberID  uint           `json:"member_id" gorm:"not null;index"`
+	CopyID    *uint          `json:"copy_id"`
+	Status    string         `json:"status" gorm:"default:'waiting';index"`
+	Position  int            `json:"position,omitempty" gorm:"-"`
+	ReadyAt   *time.Time     `json:"ready_at"`
+	ExpiresAt *time.Time     `json:"expires_at"`
+	ClosedAt  *time.Time     `json:"closed_at"`
+	Notes     string         `json:"notes"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	Book      *Book          `json:"book,omitempty" gorm:"foreignKey:BookID"`
+	Member    *Member        `json:"member,omitempty" gorm:"foreignKey:MemberID"`
+	Copy      *BookCopy      `json:"copy,omitempty" gorm:"foreignKey:CopyID"`
+}
+
+func (h *Hold) IsActive() bool {
+	return h.Status == HoldStatusWaiting || h.Status == HoldStatusReady
+}
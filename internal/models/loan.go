@@ -6,19 +6,33 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	LoanStatusBorrowed = "borrowed"
+	LoanStatusOverdue  = "overdue"
+	LoanStatusReturned = "returned"
+)
+
 type Loan struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
 	BookID       uint           `json:"book_id" gorm:"not null"`
 	MemberID     uint           `json:"member_id" gorm:"not null"`
+	CopyID       *uint          `json:"copy_id" gorm:"index"`
 	LoanDate     time.Time      `json:"loan_date" gorm:"not null"`
 	DueDate      time.Time      `json:"due_date" gorm:"not null"`
 	ReturnDate   *time.Time     `json:"return_date"`
-	Status       string         `json:"status" gorm:"default:'borrowed'"`
-	Fine         float64        `json:"fine" gorm:"default:0"`
+	Status       string         `json:"status" gorm:"default:'borrowed';index"`
+	Fine         int64          `json:"fine" gorm:"default:0"`
+	RenewalCount int            `json:"renewal_count" gorm:"default:0"`
 	Notes        string         `json:"notes"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 	Book         Book           `json:"book,omitempty" gorm:"foreignKey:BookID"`
 	Member       Member         `json:"member,omitempty" gorm:"foreignKey:MemberID"`
+	Copy         *BookCopy      `json:"copy,omitempty" gorm:"foreignKey:CopyID"`
+}
+
+// IsOpen reports whether the book is still out, overdue or not.
+func (l *Loan) IsOpen() bool {
+	return l.Status == LoanStatusBorrowed || l.Status == LoanStatusOverdue
 }
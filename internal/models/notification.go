@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	NotificationDueSoon      = "due_soon"
+	NotificationOverdue      = "overdue"
+	NotificationHoldReady    = "hold_ready"
+	NotificationFineAssessed = "fine_assessed"
+)
+
+const (
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+	NotificationStatusSkipped = "skipped"
+)
+
+// Members choose whether they hear from the library and in which language.
+const (
+	NotifyByEmail = "email"
+	NotifyNone    = "none"
+
+	LanguageIndonesian = "id"
+	LanguageEnglish    = "en"
+)
+
+// NotificationData holds the facts a message is rendered from. Titles,
+// names and addresses are looked up when the message is sent.
+type NotificationData struct {
+	LoanID    uint       `json:"loan_id,omitempty"`
+	HoldID    uint       `json:"hold_id,omitempty"`
+	BookID    uint       `json:"book_id,omitempty"`
+	DueDate   *time.Time `json:"due_date,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Amount    int64      `json:"amount,omitempty"`
+}
+
+// Notification is an outbox entry. It is written in the same transaction
+// as the change it reports and sent later, with retries, by the scheduler.
+// DedupKey stops the same event from being queued twice.
+type Notification struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	MemberID      uint       `json:"member_id" gorm:"not null;index"`
+	Event         string     `json:"event" gorm:"not null"`
+	DedupKey      string     `json:"dedup_key" gorm:"not null;uniqueIndex"`
+	Data          string     `json:"data" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"default:'pending';index"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+type RoleChange struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	OldRole     string    `json:"old_role"`
+	NewRole     string    `json:"new_role" gorm:"not null"`
+	ChangedByID uint      `json:"changed_by_id" gorm:"not null"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
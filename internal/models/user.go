@@ -7,18 +7,55 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleAdmin     = "admin"
+	RoleLibrarian = "librarian"
+	RoleMember    = "member"
+	RoleAuditor   = "auditor"
+
+	// RoleUser is the role older accounts were created with. It carries the
+	// same permissions as RoleMember.
+	RoleUser = "user"
+)
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleLibrarian, RoleMember, RoleAuditor:
+		return true
+	}
+	return false
+}
+
+// User is a login account. Access tokens issued before SessionsRevokedAt
+// are no longer accepted. TOTPSecret is set from enrollment on, but the
+// second factor is only asked for once TOTPEnabled. MemberID is the
+// library member the account belongs to, if any; it decides whose holds
+// the account may place and cancel.
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"unique;not null"`
-	Email     string         `json:"email" gorm:"unique;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	Role      string         `json:"role" gorm:"default:'user'"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Username          string         `json:"username" gorm:"unique;not null"`
+	Email             string         `json:"email" gorm:"unique;not null"`
+	Password          string         `json:"-" gorm:"not null"`
+	Role              string         `json:"role" gorm:"default:'member'"`
+	IsActive          bool           `json:"is_active" gorm:"default:true"`
+	TOTPEnabled       bool           `json:"totp_enabled" gorm:"not null;default:false"`
+	TOTPSecret        string         `json:"-"`
+	TOTPLastStep      int64          `json:"-" gorm:"not null;default:0"`
+	MemberID          *uint          `json:"member_id" gorm:"uniqueIndex"`
+	SessionsRevokedAt *time.Time     `json:"-"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
-func (u *User) BeforeCreate(tx *gorm.DB) error {
+// BeforeSave hashes the password whenever it is set in plain text, on
+// create and on update alike, so no code path can store it unhashed.
+// Updates must go through the model (Save, or Updates with a User) for
+// the hook to see the new value.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.Password == "" || isPasswordHash(u.Password) {
+		return nil
+	}
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -27,6 +64,11 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func isPasswordHash(password string) bool {
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
+
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
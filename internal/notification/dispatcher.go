@@ -0,0 +1,135 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const deliverBatchSize = 100
+
+// Dispatcher sends the messages waiting in the outbox.
+type Dispatcher struct {
+	sender      Sender
+	maxAttempts int
+	stores      *repository.Stores
+}
+
+// NewDispatcher builds a dispatcher that gives up on a message after
+// maxAttempts tries.
+func NewDispatcher(stores *repository.Stores, sender Sender, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		sender:      sender,
+		maxAttempts: maxAttempts,
+		stores:      stores,
+	}
+}
+
+// Deliver sends up to one batch of due messages. A failed send is retried
+// with exponential backoff until it has been tried maxAttempts times.
+func (d *Dispatcher) Deliver(ctx context.Context, now time.Time) (sent, failed int, err error) {
+	stores := d.stores.WithContext(ctx)
+	due, err := stores.Notifications.GetDue(now, deliverBatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i := range due {
+		if err := ctx.Err(); err != nil {
+			return sent, failed, err
+		}
+
+		notification := &due[i]
+		if err := d.deliver(ctx, stores, notification, now); err != nil {
+			failed++
+		} else if notification.Status == models.NotificationStatusSent {
+			sent++
+		}
+		if err := stores.Notifications.Update(notification); err != nil {
+			return sent, failed, err
+		}
+	}
+	return sent, failed, nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, stores *repository.Stores, notification *models.Notification, now time.Time) error {
+	member, err := stores.Members.GetByID(notification.MemberID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		notification.Status = models.NotificationStatusSkipped
+		notification.LastError = "member not found"
+		return nil
+	}
+	if err != nil {
+		return d.retryLater(notification, now, err)
+	}
+	if member.NotificationPreference == models.NotifyNone || member.Email == "" {
+		notification.Status = models.NotificationStatusSkipped
+		return nil
+	}
+
+	var data models.NotificationData
+	if err := json.Unmarshal([]byte(notification.Data), &data); err != nil {
+		return d.fail(notification, err)
+	}
+
+	language := member.Language
+	message := messageData{
+		MemberName: member.Name,
+		DueDate:    formatDate(data.DueDate, language),
+		ExpiresAt:  formatDate(data.ExpiresAt, language),
+		Amount:     formatAmount(data.Amount, language),
+	}
+	if data.BookID != 0 {
+		book, err := stores.Books.GetByID(data.BookID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return d.retryLater(notification, now, err)
+		}
+		if book != nil {
+			message.BookTitle = book.Title
+		}
+	}
+
+	subject, body, err := render(notification.Event, language, message)
+	if err != nil {
+		return d.fail(notification, err)
+	}
+	notification.Recipient = member.Email
+	notification.Subject = subject
+
+	err = d.sender.Send(ctx, Message{To: member.Email, Subject: subject, Body: body})
+	if err != nil {
+		return d.retryLater(notification, now, err)
+	}
+
+	notification.Attempts++
+	notification.Status = models.NotificationStatusSent
+	notification.LastError = ""
+	notification.SentAt = &now
+	return nil
+}
+
+func (d *Dispatcher) retryLater(notification *models.Notification, now time.Time, err error) error {
+	notification.Attempts++
+	notification.LastError = err.Error()
+	if notification.Attempts >= d.maxAttempts {
+		notification.Status = models.NotificationStatusFailed
+		return err
+	}
+	backoff := time.Minute << uint(notification.Attempts-1)
+	notification.NextAttemptAt = now.Add(backoff)
+	return err
+}
+
+// fail gives up on a message that can never be sent as it stands.
+func (d *Dispatcher) fail(notification *models.Notification, err error) error {
+	notification.Attempts++
+	notification.LastError = err.Error()
+	notification.Status = models.NotificationStatusFailed
+	return err
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"library-management-system/internal/models"
+)
+
+const eventPasswordReset = "password_reset"
+
+// PasswordResetMessage builds the email that carries a password reset
+// token. The token is appended to resetURL, or sent bare when resetURL is
+// empty. The message is sent straight away rather than through the
+// outbox, which would keep the token in the database in plain text.
+func PasswordResetMessage(user *models.User, token, resetURL string, ttl time.Duration, language string) (Message, error) {
+	link := token
+	if resetURL != "" {
+		base, err := url.Parse(resetURL)
+		if err != nil {
+			return Message{}, err
+		}
+		query := base.Query()
+		query.Set("token", token)
+		base.RawQuery = query.Encode()
+		link = base.String()
+	}
+
+	subject, body, err := render(eventPasswordReset, language, messageData{
+		MemberName: user.Username,
+		ResetLink:  link,
+		ExpiresIn:  formatDuration(ttl, language),
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{To: user.Email, Subject: subject, Body: body}, nil
+}
+
+func formatDuration(d time.Duration, language string) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if language == models.LanguageEnglish {
+		switch {
+		case minutes == 60:
+			return "1 hour"
+		case minutes%60 == 0:
+			return fmt.Sprintf("%d hours", minutes/60)
+		}
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d jam", minutes/60)
+	}
+	return fmt.Sprintf("%d menit", minutes)
+}
@@ -0,0 +1,158 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"library-management-system/internal/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers a rendered message. Implementations must be safe for
+// concurrent use.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender builds the sender chosen by NOTIFY_SENDER.
+func NewSender(cfg config.NotificationConfig) (Sender, error) {
+	switch name := cfg.Sender; name {
+	case "smtp":
+		if cfg.SMTP.Host == "" || cfg.SMTP.From == "" {
+			return nil, fmt.Errorf("SMTP_HOST and SMTP_FROM are required for the smtp sender")
+		}
+		return &SMTPSender{config: cfg.SMTP}, nil
+	case "log":
+		return &LogSender{Path: cfg.LogFile}, nil
+	default:
+		return nil, fmt.Errorf("unknown notification sender %q", name)
+	}
+}
+
+// smtpTimeout bounds a delivery whose context carries no deadline, so a
+// stalled server cannot hold up the dispatcher.
+const smtpTimeout = 30 * time.Second
+
+type SMTPSender struct {
+	config config.SMTPConfig
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+
+	from := headerValue(s.config.From)
+	to := headerValue(msg.To)
+	headers := []string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + headerValue(msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(msg.Body, "\n", "\r\n")
+
+	addr := net.JoinHostPort(s.config.Host, s.config.Port)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Cancelling ctx aborts a conversation that is waiting on the server.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+			if err := client.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// headerValue drops line breaks from a header value. Subjects carry
+// catalog text such as book titles, and a CR or LF there would let it
+// start headers of its own.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+}
+
+// LogSender appends messages to a file, or writes them to the server log
+// when Path is empty. It is meant for development.
+type LogSender struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	entry := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	if s.Path == "" {
+		log.Print("notification: " + entry)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "--- %s\n%s\n", time.Now().Format(time.RFC3339), entry)
+	return err
+}
@@ -0,0 +1,199 @@
+package notification
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"library-management-system/internal/models"
+)
+
+// messageData is what templates see. Dates and amounts are already
+// formatted for the member's language.
+type messageData struct {
+	MemberName string
+	BookTitle  string
+	DueDate    string
+	ExpiresAt  string
+	Amount     string
+	ResetLink  string
+	ExpiresIn  string
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func newTemplate(subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(body)),
+	}
+}
+
+var templates = map[string]map[string]messageTemplate{
+	models.NotificationDueSoon: {
+		models.LanguageIndonesian: newTemplate(
+			`Pengingat: "{{.BookTitle}}" jatuh tempo {{.DueDate}}`,
+			`Halo {{.MemberName}},
+
+Buku "{{.BookTitle}}" yang Anda pinjam jatuh tempo pada {{.DueDate}}. Silakan kembalikan atau perpanjang sebelum tanggal tersebut agar tidak terkena denda.
+
+Salam,
+Perpustakaan`),
+		models.LanguageEnglish: newTemplate(
+			`Reminder: "{{.BookTitle}}" is due {{.DueDate}}`,
+			`Hello {{.MemberName}},
+
+"{{.BookTitle}}" is due back on {{.DueDate}}. Please return or renew it by then to avoid a fine.
+
+Regards,
+The Library`),
+	},
+	models.NotificationOverdue: {
+		models.LanguageIndonesian: newTemplate(
+			`Terlambat: "{{.BookTitle}}" belum dikembalikan`,
+			`Halo {{.MemberName}},
+
+Buku "{{.BookTitle}}" seharusnya dikembalikan pada {{.DueDate}}. Denda keterlambatan terus bertambah sampai buku dikembalikan.
+
+Salam,
+Perpustakaan`),
+		models.LanguageEnglish: newTemplate(
+			`Overdue: "{{.BookTitle}}" has not been returned`,
+			`Hello {{.MemberName}},
+
+"{{.BookTitle}}" was due back on {{.DueDate}}. Late fines keep adding up until it is returned.
+
+Regards,
+The Library`),
+	},
+	models.NotificationHoldReady: {
+		models.LanguageIndonesian: newTemplate(
+			`"{{.BookTitle}}" siap diambil`,
+			`Halo {{.MemberName}},
+
+Buku "{{.BookTitle}}" yang Anda pesan sudah tersedia dan kami simpan untuk Anda sampai {{.ExpiresAt}}. Setelah itu buku akan diberikan ke anggota berikutnya.
+
+Salam,
+Perpustakaan`),
+		models.LanguageEnglish: newTemplate(
+			`"{{.BookTitle}}" is ready for pickup`,
+			`Hello {{.MemberName}},
+
+"{{.BookTitle}}", which you placed a hold on, is waiting for you at the desk until {{.ExpiresAt}}. After that it goes to the next member in line.
+
+Regards,
+The Library`),
+	},
+	models.NotificationFineAssessed: {
+		models.LanguageIndonesian: newTemplate(
+			`Denda perpustakaan sebesar {{.Amount}}`,
+			`Halo {{.MemberName}},
+
+Anda dikenakan denda sebesar {{.Amount}}{{if .BookTitle}} untuk buku "{{.BookTitle}}"{{end}}. Silakan lakukan pembayaran di meja layanan perpustakaan.
+
+Salam,
+Perpustakaan`),
+		models.LanguageEnglish: newTemplate(
+			`Library fine of {{.Amount}}`,
+			`Hello {{.MemberName}},
+
+You have been charged a fine of {{.Amount}}{{if .BookTitle}} for "{{.BookTitle}}"{{end}}. Please pay at the library desk.
+
+Regards,
+The Library`),
+	},
+	eventPasswordReset: {
+		models.LanguageIndonesian: newTemplate(
+			`Atur ulang kata sandi akun perpustakaan`,
+			`Halo {{.MemberName}},
+
+Kami menerima permintaan untuk mengatur ulang kata sandi akun Anda. Gunakan tautan berikut dalam {{.ExpiresIn}}:
+
+{{.ResetLink}}
+
+Tautan hanya dapat dipakai sekali. Jika Anda tidak meminta ini, abaikan email ini; kata sandi Anda tidak berubah.
+
+Salam,
+Perpustakaan`),
+		models.LanguageEnglish: newTemplate(
+			`Reset your library account password`,
+			`Hello {{.MemberName}},
+
+We received a request to reset the password of your account. Use the link below within {{.ExpiresIn}}:
+
+{{.ResetLink}}
+
+The link works once. If you did not ask for this, ignore this email; your password has not changed.
+
+Regards,
+The Library`),
+	},
+}
+
+var indonesianMonths = []string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+func formatDate(t *time.Time, language string) string {
+	if t == nil {
+		return ""
+	}
+	if language == models.LanguageEnglish {
+		return t.Format("January 2, 2006")
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), indonesianMonths[t.Month()-1], t.Year())
+}
+
+// formatAmount writes whole rupiah with thousands separators: "Rp 10.000"
+// in Indonesian and "Rp 10,000" in English.
+func formatAmount(amount int64, language string) string {
+	separator := "."
+	if language == models.LanguageEnglish {
+		separator = ","
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+
+	var groups []string
+	for len(digits) > 3 {
+		groups = append([]string{digits[len(digits)-3:]}, groups...)
+		digits = digits[:len(digits)-3]
+	}
+	groups = append([]string{digits}, groups...)
+
+	formatted := "Rp " + strings.Join(groups, separator)
+	if negative {
+		formatted = "-" + formatted
+	}
+	return formatted
+}
+
+// render fills in the template for an event in the member's language,
+// falling back to Indonesian.
+func render(event, language string, data messageData) (subject, body string, err error) {
+	byLanguage, ok := templates[event]
+	if !ok {
+		return "", "", fmt.Errorf("no template for event %q", event)
+	}
+	tmpl, ok := byLanguage[language]
+	if !ok {
+		tmpl = byLanguage[models.LanguageIndonesian]
+	}
+
+	var subjectText, bodyText strings.Builder
+	if err := tmpl.subject.Execute(&subjectText, data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.body.Execute(&bodyText, data); err != nil {
+		return "", "", err
+	}
+	return subjectText.String(), bodyText.String(), nil
+}
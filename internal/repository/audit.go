@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Fields that change on every write and would only clutter a diff.
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) WithTx(tx *Tx) AuditStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &AuditRepository{db: db}
+}
+
+// Record stores an event. before and after are the entity as it was and
+// as it is now; either may be nil. They are kept as flat JSON snapshots,
+// using the models' JSON tags so hidden fields such as passwords stay
+// out, together with the fields that changed. details is stored as JSON.
+func (r *AuditRepository) Record(event *models.AuditEvent, before, after, details interface{}) error {
+	if err := FillAuditEvent(event, before, after, details); err != nil {
+		return err
+	}
+	return r.db.Create(event).Error
+}
+
+// FillAuditEvent sets the JSON snapshots and changes of event from before,
+// after and details, as Record stores them.
+func FillAuditEvent(event *models.AuditEvent, before, after, details interface{}) error {
+	beforeFields, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+	afterFields, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	if event.Before, err = encodeJSON(beforeFields); err != nil {
+		return err
+	}
+	if event.After, err = encodeJSON(afterFields); err != nil {
+		return err
+	}
+	if beforeFields != nil && afterFields != nil {
+		if event.Changes, err = encodeJSON(diff(beforeFields, afterFields)); err != nil {
+			return err
+		}
+	}
+	if details != nil {
+		if event.Details, err = encodeJSON(details); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot turns an entity into its top-level JSON fields. Nested objects
+// and lists, i.e. preloaded associations, are left out; they have audit
+// events of their own.
+func snapshot(entity interface{}) (map[string]interface{}, error) {
+	if entity == nil || reflect.ValueOf(entity).Kind() == reflect.Ptr && reflect.ValueOf(entity).IsNil() {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			delete(fields, key)
+		}
+	}
+	return fields, nil
+}
+
+type fieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+func diff(before, after map[string]interface{}) map[string]fieldChange {
+	changes := make(map[string]fieldChange)
+	for key, to := range after {
+		if from := before[key]; !auditIgnoredFields[key] && !reflect.DeepEqual(from, to) {
+			changes[key] = fieldChange{From: from, To: to}
+		}
+	}
+	for key, from := range before {
+		if _, ok := after[key]; !ok && !auditIgnoredFields[key] {
+			changes[key] = fieldChange{From: from}
+		}
+	}
+	return changes
+}
+
+func encodeJSON(value interface{}) (string, error) {
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Map && reflect.ValueOf(value).IsNil() {
+		return "", nil
+	}
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+type AuditFilter struct {
+	ActorID    uint
+	Action     string
+	EntityType string
+	EntityID   string
+	RequestID  string
+	From       time.Time
+	To         time.Time
+}
+
+func (f AuditFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.ActorID != 0 {
+		db = db.Where("actor_id = ?", f.ActorID)
+	}
+	if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	if f.EntityType != "" {
+		db = db.Where("entity_type = ?", f.EntityType)
+	}
+	if f.EntityID != "" {
+		db = db.Where("entity_id = ?", f.EntityID)
+	}
+	if f.RequestID != "" {
+		db = db.Where("request_id = ?", f.RequestID)
+	}
+	if !f.From.IsZero() {
+		db = db.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		db = db.Where("created_at < ?", f.To)
+	}
+	return db
+}
+
+func (r *AuditRepository) CountByAction(action string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.AuditEvent{}).Where("action = ?", action).Count(&count).Error
+	return count, err
+}
+
+func (r *AuditRepository) GetByID(id uint) (*models.AuditEvent, error) {
+	var event models.AuditEvent
+	err := r.db.First(&event, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *AuditRepository) List(filter AuditFilter, page Page) ([]models.AuditEvent, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.AuditEvent{}).Scopes(filter.apply).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	err := r.db.Scopes(filter.apply, paginate(page)).Find(&events).Error
+	return events, total, err
+}
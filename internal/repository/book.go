@@ -1,52 +1,117 @@
 package repository
 
 import (
-	"library-management-system/internal/config"
 	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type BookRepository struct{}
+type BookRepository struct {
+	db *gorm.DB
+}
 
-func NewBookRepository() *BookRepository {
-	return &BookRepository{}
+func NewBookRepository(db *gorm.DB) *BookRepository {
+	return &BookRepository{db: db}
+}
+
+func (r *BookRepository) WithTx(tx *Tx) BookStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &BookRepository{db: db}
 }
 
 func (r *BookRepository) Create(book *models.Book) error {
-	return config.GetDB().Create(book).Error
+	return r.db.Create(book).Error
 }
 
 func (r *BookRepository) GetAll() ([]models.Book, error) {
 	var books []models.Book
-	err := config.GetDB().Find(&books).Error
+	err := r.db.Find(&books).Error
 	return books, err
 }
 
 func (r *BookRepository) GetByID(id uint) (*models.Book, error) {
 	var book models.Book
-	err := config.GetDB().First(&book, id).Error
+	err := r.db.First(&book, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &book, nil
 }
 
+// Update saves the catalog fields of a book. Stock and available are derived
+// from its copies and only change through SyncAvailability.
 func (r *BookRepository) Update(book *models.Book) error {
-	return config.GetDB().Save(book).Error
+	return r.db.Omit("stock", "available").Save(book).Error
 }
 
 func (r *BookRepository) Delete(id uint) error {
-	return config.GetDB().Delete(&models.Book{}, id).Error
+	return r.db.Delete(&models.Book{}, id).Error
 }
 
 func (r *BookRepository) GetByISBN(isbn string) (*models.Book, error) {
 	var book models.Book
-	err := config.GetDB().Where("isbn = ?", isbn).First(&book).Error
+	err := r.db.Where("isbn = ?", isbn).First(&book).Error
 	if err != nil {
 		return nil, err
 	}
 	return &book, nil
 }
 
-func (r *BookRepository) UpdateStock(id uint, available int) error {
-	return config.GetDB().Model(&models.Book{}).Where("id = ?", id).Update("available", available).Error
+func (r *BookRepository) GetByIDForUpdate(id uint) (*models.Book, error) {
+	var book models.Book
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&book, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// SyncAvailability recomputes stock and available from the statuses of the
+// book's copies. Lost and withdrawn copies no longer count towards stock.
+func (r *BookRepository) SyncAvailability(id uint) error {
+	copies := r.db.Model(&models.BookCopy{}).Select("COUNT(*)").Where("book_id = ?", id)
+	return r.db.Model(&models.Book{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"stock": copies.Session(&gorm.Session{}).
+			Where("status NOT IN ?", []string{models.CopyStatusLost, models.CopyStatusWithdrawn}),
+		"available": copies.Session(&gorm.Session{}).
+			Where("status = ?", models.CopyStatusAvailable),
+	}).Error
+}
+
+type BookFilter struct {
+	Category string
+	Author   string
+	YearFrom int
+	YearTo   int
+}
+
+func (f BookFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.Category != "" {
+		db = db.Where("LOWER(category) = LOWER(?)", f.Category)
+	}
+	if f.Author != "" {
+		db = db.Where("LOWER(author) LIKE LOWER(?)", "%"+f.Author+"%")
+	}
+	if f.YearFrom > 0 {
+		db = db.Where("year >= ?", f.YearFrom)
+	}
+	if f.YearTo > 0 {
+		db = db.Where("year <= ?", f.YearTo)
+	}
+	return db
+}
+
+func (r *BookRepository) List(filter BookFilter, page Page) ([]models.Book, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Book{}).Scopes(filter.apply).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var books []models.Book
+	err := r.db.Scopes(filter.apply, paginate(page)).Find(&books).Error
+	return books, total, err
 }
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookCopyRepository struct {
+	db *gorm.DB
+}
+
+func NewBookCopyRepository(db *gorm.DB) *BookCopyRepository {
+	return &BookCopyRepository{db: db}
+}
+
+func (r *BookCopyRepository) WithTx(tx *Tx) BookCopyStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &BookCopyRepository{db: db}
+}
+
+func (r *BookCopyRepository) Create(bookCopy *models.BookCopy) error {
+	return r.db.Create(bookCopy).Error
+}
+
+func (r *BookCopyRepository) GetByID(id uint) (*models.BookCopy, error) {
+	var bookCopy models.BookCopy
+	err := r.db.First(&bookCopy, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+func (r *BookCopyRepository) GetByIDForUpdate(id uint) (*models.BookCopy, error) {
+	var bookCopy models.BookCopy
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bookCopy, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+func (r *BookCopyRepository) GetByBarcode(barcode string) (*models.BookCopy, error) {
+	var bookCopy models.BookCopy
+	err := r.db.Preload("Book").Where("barcode = ?", barcode).First(&bookCopy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+func (r *BookCopyRepository) GetByBookID(bookID uint) ([]models.BookCopy, error) {
+	var copies []models.BookCopy
+	err := r.db.Where("book_id = ?", bookID).Order("id").Find(&copies).Error
+	return copies, err
+}
+
+func (r *BookCopyRepository) Update(bookCopy *models.BookCopy) error {
+	return r.db.Save(bookCopy).Error
+}
+
+func (r *BookCopyRepository) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&models.BookCopy{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// NextSequence returns the number to use for the next bookCopy of a book when
+// generating barcodes. Deleted copies are counted so numbers are never reused.
+func (r *BookCopyRepository) NextSequence(bookID uint) (int, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.BookCopy{}).Where("book_id = ?", bookID).Count(&count).Error
+	return int(count) + 1, err
+}
+
+// FirstAvailable returns an available bookCopy of a book. Callers must hold the
+// book's row lock (BookRepository.GetByIDForUpdate) so that two checkouts
+// cannot pick the same bookCopy.
+func (r *BookCopyRepository) FirstAvailable(bookID uint) (*models.BookCopy, error) {
+	var bookCopy models.BookCopy
+	err := r.db.Where("book_id = ? AND status = ?", bookID, models.CopyStatusAvailable).
+		Order("id").First(&bookCopy).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrBookNotAvailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+// FirstUntrackedOnLoan finds an on-loan bookCopy that no active loan points at.
+// Loans made before copies were tracked have no copy_id; returning one of
+// them puts such a bookCopy back on the shelf.
+func (r *BookCopyRepository) FirstUntrackedOnLoan(bookID uint) (*models.BookCopy, error) {
+	var bookCopy models.BookCopy
+	err := r.db.
+		Where("book_id = ? AND status = ?", bookID, models.CopyStatusOnLoan).
+		Where("id NOT IN (?)", r.db.Model(&models.Loan{}).
+			Select("copy_id").
+			Where("copy_id IS NOT NULL AND status <> ?", models.LoanStatusReturned)).
+		Order("id").First(&bookCopy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
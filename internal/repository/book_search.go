@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"library-management-system/internal/models"
+)
+
+type BookHighlights struct {
+	Title   string `json:"title"`
+	Author  string `json:"author"`
+	Snippet string `json:"snippet"`
+}
+
+type BookSearchResult struct {
+	models.Book
+	Rank       float64        `json:"rank"`
+	Highlights BookHighlights `json:"highlights" gorm:"embedded;embeddedPrefix:highlight_"`
+}
+
+// The database marks matches with these control characters rather than
+// <mark> tags. The catalog text around them is raw, so markHighlights
+// escapes it as HTML first and only then turns the markers into tags.
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+
+	headlineOptions = `StartSel="` + markStart + `", StopSel="` + markStop + `"`
+)
+
+var highlightTags = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// markHighlights makes the highlights safe to render as HTML: everything
+// is escaped except the <mark> tags around the matches.
+func markHighlights(results []BookSearchResult) {
+	for i := range results {
+		h := &results[i].Highlights
+		h.Title = highlightTags.Replace(html.EscapeString(h.Title))
+		h.Author = highlightTags.Replace(html.EscapeString(h.Author))
+		h.Snippet = highlightTags.Replace(html.EscapeString(h.Snippet))
+	}
+}
+
+// searchWords splits free text into lowercase words. Anything other than
+// letters and digits is dropped, so user input can never inject query
+// operators.
+func searchWords(q string) []string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+// Search ranks books against q using the full-text index maintained by
+// the book_search migration. Every word must match, as a prefix. It
+// returns ErrEmptySearchQuery when q has no searchable words.
+func (r *BookRepository) Search(q string, page Page) ([]BookSearchResult, int64, error) {
+	words := searchWords(q)
+	if len(words) == 0 {
+		return nil, 0, ErrEmptySearchQuery
+	}
+	if r.db.Dialector.Name() == "sqlite" {
+		return r.searchFTS5(words, page)
+	}
+
+	// A prefix-matching tsquery such as "harry:* & potter:*".
+	terms := strings.Join(words, ":* & ") + ":*"
+
+	var total int64
+	err := r.db.Raw(`
+		SELECT COUNT(*) FROM books
+		WHERE deleted_at IS NULL
+		  AND search_vector @@ to_tsquery('library_search', ?)`, terms).
+		Scan(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var results []BookSearchResult
+	err = r.db.Raw(`
+		SELECT books.*,
+		       ts_rank_cd(books.search_vector, query) AS rank,
+		       ts_headline('library_search', books.title, query, ?) AS highlight_title,
+		       ts_headline('library_search', books.author, query, ?) AS highlight_author,
+		       ts_headline('library_search', coalesce(books.description, ''), query, ?) AS highlight_snippet
+		FROM books, to_tsquery('library_search', ?) AS query
+		WHERE books.deleted_at IS NULL
+		  AND books.search_vector @@ query
+		ORDER BY rank DESC, books.id
+		LIMIT ? OFFSET ?`,
+		headlineOptions+", HighlightAll=true",
+		headlineOptions+", HighlightAll=true",
+		headlineOptions+", MaxWords=35, MinWords=15, MaxFragments=2",
+		terms, page.PerPage, page.Offset()).
+		Scan(&results).Error
+	markHighlights(results)
+	return results, total, err
+}
+
+// searchFTS5 is Search on SQLite, using the books_fts table. bm25 weighs
+// the columns as the Postgres search vector does and is negated so that,
+// as there, a higher rank is a better match.
+func (r *BookRepository) searchFTS5(words []string, page Page) ([]BookSearchResult, int64, error) {
+	// A prefix-matching FTS5 query such as `"harry"* "potter"*`.
+	terms := `"` + strings.Join(words, `"* "`) + `"*`
+
+	var total int64
+	err := r.db.Raw(`
+		SELECT COUNT(*) FROM books_fts
+		JOIN books ON books.id = books_fts.rowid
+		WHERE books.deleted_at IS NULL
+		  AND books_fts MATCH ?`, terms).
+		Scan(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var results []BookSearchResult
+	err = r.db.Raw(`
+		SELECT books.*,
+		       -bm25(books_fts, 1.0, 1.0, 0.4, 0.2, 0.1) AS rank,
+		       highlight(books_fts, 0, ?, ?) AS highlight_title,
+		       highlight(books_fts, 1, ?, ?) AS highlight_author,
+		       snippet(books_fts, 4, ?, ?, '...', 35) AS highlight_snippet
+		FROM books_fts
+		JOIN books ON books.id = books_fts.rowid
+		WHERE books.deleted_at IS NULL
+		  AND books_fts MATCH ?
+		ORDER BY rank DESC, books.id
+		LIMIT ? OFFSET ?`,
+		markStart, markStop,
+		markStart, markStop,
+		markStart, markStop,
+		terms, page.PerPage, page.Offset()).
+		Scan(&results).Error
+	markHighlights(results)
+	return results, total, err
+}
@@ -0,0 +1,130 @@
+package repository_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+)
+
+var firstPage = repository.Page{Number: 1, PerPage: 20}
+
+func TestBookSearch(t *testing.T) {
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			books := e2e.NewTestHarness(t, driver).Stores.Books
+			create := func(book models.Book) *models.Book {
+				t.Helper()
+				if err := books.Create(&book); err != nil {
+					t.Fatal(err)
+				}
+				return &book
+			}
+			search := func(q string) []repository.BookSearchResult {
+				t.Helper()
+				results, total, err := books.Search(q, firstPage)
+				if err != nil {
+					t.Fatalf("search for %q: %v", q, err)
+				}
+				if total != int64(len(results)) {
+					t.Errorf("search for %q counted %d books but returned %d", q, total, len(results))
+				}
+				return results
+			}
+			ids := func(results []repository.BookSearchResult) []uint {
+				var ids []uint
+				for _, result := range results {
+					ids = append(ids, result.ID)
+				}
+				return ids
+			}
+
+			inTitle := create(models.Book{Title: "Arus Balik", Author: "Pramoedya Ananta Toer", ISBN: "978-979-97312-8-9", Description: "Kisah kerajaan maritim Nusantara."})
+			inDescription := create(models.Book{Title: "Gadis Pantai", Author: "Pramoedya Ananta Toer", ISBN: "978-979-97312-5-8", Description: "Arus kehidupan seorang gadis dari pesisir."})
+			accented := create(models.Book{Title: "Noli Me Tángere", Author: "José Rizal", ISBN: "978-971-08-5011-7"})
+			create(models.Book{Title: "Laskar Pelangi", Author: "Andrea Hirata", ISBN: "978-979-3062-79-1"})
+
+			t.Run("ranking", func(t *testing.T) {
+				results := search("arus")
+				if got := ids(results); len(got) != 2 || got[0] != inTitle.ID || got[1] != inDescription.ID {
+					t.Fatalf("search for arus found %v, want the title match %d before the description match %d", got, inTitle.ID, inDescription.ID)
+				}
+				if results[0].Rank <= results[1].Rank {
+					t.Errorf("title match ranks %f, not above the description match at %f", results[0].Rank, results[1].Rank)
+				}
+			})
+
+			t.Run("prefix", func(t *testing.T) {
+				if got := ids(search("pram")); len(got) != 2 {
+					t.Errorf("search for pram found %v, want both books by Pramoedya", got)
+				}
+				if got := ids(search("pram gadis")); len(got) != 1 || got[0] != inDescription.ID {
+					t.Errorf("search for pram gadis found %v, want only %d", got, inDescription.ID)
+				}
+			})
+
+			t.Run("accents", func(t *testing.T) {
+				for _, q := range []string{"jose", "José", "tangere"} {
+					if got := ids(search(q)); len(got) != 1 || got[0] != accented.ID {
+						t.Errorf("search for %q found %v, want %d", q, got, accented.ID)
+					}
+				}
+			})
+
+			t.Run("updates and deletes", func(t *testing.T) {
+				book := create(models.Book{Title: "Ronggeng Dukuh Paruk", Author: "Ahmad Tohari", ISBN: "978-979-22-0196-8"})
+				book.Title = "Lintang Kemukus Dini Hari"
+				if err := books.Update(book); err != nil {
+					t.Fatal(err)
+				}
+				if got := ids(search("ronggeng")); len(got) != 0 {
+					t.Errorf("search for the old title found %v", got)
+				}
+				if got := ids(search("kemukus")); len(got) != 1 || got[0] != book.ID {
+					t.Errorf("search for the new title found %v, want %d", got, book.ID)
+				}
+				if err := books.Delete(book.ID); err != nil {
+					t.Fatal(err)
+				}
+				if got := ids(search("tohari")); len(got) != 0 {
+					t.Errorf("search found deleted book: %v", got)
+				}
+			})
+
+			t.Run("escaping", func(t *testing.T) {
+				create(models.Book{
+					Title:       "<script>alert(1)</script> Harry & Co",
+					Author:      "J. <b>Rowling</b>",
+					ISBN:        "978-0-7475-3269-9",
+					Description: "Harry goes <img src=x onerror=alert(1)> to school.",
+				})
+				results := search("harry")
+				if len(results) != 1 {
+					t.Fatalf("search for harry found %d books, want 1", len(results))
+				}
+				h := results[0].Highlights
+				for _, field := range []string{h.Title, h.Author, h.Snippet} {
+					if strings.Contains(field, "<script") || strings.Contains(field, "<img") || strings.Contains(field, "<b>") {
+						t.Errorf("highlight %q holds unescaped markup", field)
+					}
+				}
+				if !strings.Contains(h.Title, "&lt;script&gt;") || !strings.Contains(h.Title, "<mark>Harry</mark>") || !strings.Contains(h.Title, "&amp;") {
+					t.Errorf("title highlight is %q", h.Title)
+				}
+				if !strings.Contains(h.Snippet, "<mark>Harry</mark>") || !strings.Contains(h.Snippet, "&lt;img") {
+					t.Errorf("snippet is %q", h.Snippet)
+				}
+			})
+
+			t.Run("no words", func(t *testing.T) {
+				if _, _, err := books.Search(`"*" & :`, firstPage); !errors.Is(err, repository.ErrEmptySearchQuery) {
+					t.Errorf("search for punctuation returned %v, want %v", err, repository.ErrEmptySearchQuery)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CirculationPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewCirculationPolicyRepository(db *gorm.DB) *CirculationPolicyRepository {
+	return &CirculationPolicyRepository{db: db}
+}
+
+func (r *CirculationPolicyRepository) WithTx(tx *Tx) CirculationPolicyStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &CirculationPolicyRepository{db: db}
+}
+
+func (r *CirculationPolicyRepository) Create(policy *models.CirculationPolicy) error {
+	return r.db.Create(policy).Error
+}
+
+func (r *CirculationPolicyRepository) GetAll() ([]models.CirculationPolicy, error) {
+	var policies []models.CirculationPolicy
+	err := r.db.Order("member_type, category").Find(&policies).Error
+	return policies, err
+}
+
+func (r *CirculationPolicyRepository) GetByID(id uint) (*models.CirculationPolicy, error) {
+	var policy models.CirculationPolicy
+	err := r.db.First(&policy, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *CirculationPolicyRepository) GetByScope(memberType, category string) (*models.CirculationPolicy, error) {
+	var policy models.CirculationPolicy
+	err := r.db.Where("member_type = ? AND category = ?", memberType, category).First(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *CirculationPolicyRepository) Update(policy *models.CirculationPolicy) error {
+	return r.db.Save(policy).Error
+}
+
+func (r *CirculationPolicyRepository) Delete(id uint) error {
+	return r.db.Delete(&models.CirculationPolicy{}, id).Error
+}
+
+// Resolve picks the most specific policy for a member type and book
+// category: an exact match first, then member type only, then category
+// only, then a catch-all. Without any stored match it falls back to
+// models.DefaultCirculationPolicy.
+func (r *CirculationPolicyRepository) Resolve(memberType, category string) (*models.CirculationPolicy, error) {
+	var policy models.CirculationPolicy
+	err := r.db.
+		Where("member_type = '' OR LOWER(member_type) = LOWER(?)", memberType).
+		Where("category = '' OR LOWER(category) = LOWER(?)", category).
+		Order("CASE WHEN member_type = '' THEN 1 ELSE 0 END, CASE WHEN category = '' THEN 1 ELSE 0 END").
+		First(&policy).Error
+	if err == gorm.ErrRecordNotFound {
+		policy = models.DefaultCirculationPolicy()
+		return &policy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"errors"
+)
+
+var (
+	ErrBookNotAvailable    = errors.New("book is not available for loan")
+	ErrCopyNotAvailable    = errors.New("book copy is not available for loan")
+	ErrCopyOnLoan          = errors.New("book copy is on loan")
+	ErrCopyOnHold          = errors.New("book copy is set aside for a hold")
+	ErrCopyLoanOpen        = errors.New("book copy is still on an open loan")
+	ErrLoanAlreadyReturned = errors.New("loan is already returned")
+	ErrRenewalLimitReached = errors.New("loan has reached its renewal limit")
+	ErrLoanLimitReached    = errors.New("member has reached the maximum number of loans")
+	ErrLoanTooOverdue      = errors.New("loan is overdue beyond the grace period")
+	ErrMemberNotActive     = errors.New("member is not active")
+	ErrHoldNotActive       = errors.New("hold is no longer active")
+	ErrTitleOnHold         = errors.New("another member has a hold on this title")
+	ErrHoldNotNeeded       = errors.New("book has copies available")
+	ErrDuplicateHold       = errors.New("member already has a hold on this book")
+	ErrEmptySearchQuery    = errors.New("search query has no searchable words")
+	ErrOutstandingFines    = errors.New("member has outstanding fines above the limit")
+	ErrAmountExceedsFines  = errors.New("amount exceeds the outstanding balance")
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrInvalidResetToken   = errors.New("password reset token is invalid or expired")
+)
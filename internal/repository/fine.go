@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"fmt"
+
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type FineRepository struct {
+	db *gorm.DB
+}
+
+func NewFineRepository(db *gorm.DB) *FineRepository {
+	return &FineRepository{db: db}
+}
+
+func (r *FineRepository) WithTx(tx *Tx) FineStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &FineRepository{db: db}
+}
+
+func (r *FineRepository) Create(entry *models.FineEntry) error {
+	return r.db.Create(entry).Error
+}
+
+// CreatePayment records a payment and gives it a receipt number derived
+// from its ID and date.
+func (r *FineRepository) CreatePayment(entry *models.FineEntry) error {
+	if err := r.db.Create(entry).Error; err != nil {
+		return err
+	}
+	receipt := fmt.Sprintf("RCP-%s-%06d", entry.CreatedAt.Format("20060102"), entry.ID)
+	entry.ReceiptNumber = &receipt
+	return r.db.Model(entry).Update("receipt_number", receipt).Error
+}
+
+// ChargeLoan raises a loan's fine to total and charges the member the
+// difference, so a fine accrued while the loan was out is not charged
+// twice. Fines never go down. Callers must hold the loan's row lock and
+// save the loan afterwards.
+func (r *FineRepository) ChargeLoan(loan *models.Loan, total int64, reason string, recordedByID *uint) error {
+	if total <= loan.Fine {
+		return nil
+	}
+	err := r.Create(&models.FineEntry{
+		MemberID:     loan.MemberID,
+		LoanID:       &loan.ID,
+		Type:         models.FineEntryCharge,
+		Amount:       total - loan.Fine,
+		Reason:       reason,
+		RecordedByID: recordedByID,
+	})
+	if err != nil {
+		return err
+	}
+	loan.Fine = total
+	return nil
+}
+
+func (r *FineRepository) GetByID(id uint) (*models.FineEntry, error) {
+	var entry models.FineEntry
+	err := r.db.Preload("Member").Preload("Loan").First(&entry, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *FineRepository) GetByReceiptNumber(receipt string) (*models.FineEntry, error) {
+	var entry models.FineEntry
+	err := r.db.Preload("Member").Where("receipt_number = ?", receipt).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Balance sums a member's ledger. Callers that go on to record a payment
+// or waiver must hold the member's row lock so the balance cannot change
+// underneath them.
+func (r *FineRepository) Balance(memberID uint) (*models.FineBalance, error) {
+	balance := models.FineBalance{MemberID: memberID}
+	err := r.db.Model(&models.FineEntry{}).
+		Select(`COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE 0 END), 0) AS charged,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE 0 END), 0) AS paid,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE 0 END), 0) AS waived`,
+			models.FineEntryCharge, models.FineEntryPayment, models.FineEntryWaiver).
+		Where("member_id = ?", memberID).
+		Scan(&balance).Error
+	if err != nil {
+		return nil, err
+	}
+	balance.Balance = balance.Charged - balance.Paid - balance.Waived
+	return &balance, nil
+}
+
+// ChargeTotals counts the charges ever recorded and adds up their amounts.
+func (r *FineRepository) ChargeTotals() (count, amount int64, err error) {
+	var totals struct {
+		Count  int64
+		Amount int64
+	}
+	err = r.db.Model(&models.FineEntry{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("type = ?", models.FineEntryCharge).
+		Scan(&totals).Error
+	return totals.Count, totals.Amount, err
+}
+
+type FineFilter struct {
+	MemberID uint
+	LoanID   uint
+	Type     string
+}
+
+func (f FineFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.MemberID != 0 {
+		db = db.Where("member_id = ?", f.MemberID)
+	}
+	if f.LoanID != 0 {
+		db = db.Where("loan_id = ?", f.LoanID)
+	}
+	if f.Type != "" {
+		db = db.Where("type = ?", f.Type)
+	}
+	return db
+}
+
+func (r *FineRepository) List(filter FineFilter, page Page) ([]models.FineEntry, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.FineEntry{}).Scopes(filter.apply).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.FineEntry
+	err := r.db.Scopes(filter.apply, paginate(page)).Find(&entries).Error
+	return entries, total, err
+}
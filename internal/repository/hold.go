@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HoldRepository keeps the hold queues. A copy set aside for a hold waits
+// pickupDays on the pickup shelf before the hold expires.
+type HoldRepository struct {
+	db         *gorm.DB
+	pickupDays int
+}
+
+func NewHoldRepository(db *gorm.DB, pickupDays int) *HoldRepository {
+	return &HoldRepository{db: db, pickupDays: pickupDays}
+}
+
+func (r *HoldRepository) WithTx(tx *Tx) HoldStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &HoldRepository{db: db, pickupDays: r.pickupDays}
+}
+
+var activeHoldStatuses = []string{models.HoldStatusWaiting, models.HoldStatusReady}
+
+func (r *HoldRepository) Create(hold *models.Hold) error {
+	return r.db.Create(hold).Error
+}
+
+func (r *HoldRepository) GetByID(id uint) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.Preload("Book").Preload("Member").Preload("Copy").First(&hold, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *HoldRepository) GetByIDForUpdate(id uint) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hold, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *HoldRepository) Update(hold *models.Hold) error {
+	return r.db.Omit(clause.Associations).Save(hold).Error
+}
+
+// GetActiveByBookID returns the book's open holds in queue order: copies
+// waiting for pickup first, then the waiting queue, oldest first.
+func (r *HoldRepository) GetActiveByBookID(bookID uint) ([]models.Hold, error) {
+	var holds []models.Hold
+	err := r.db.Preload("Member").
+		Where("book_id = ? AND status IN ?", bookID, activeHoldStatuses).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN status = ? THEN 0 ELSE 1 END, id",
+			Vars: []interface{}{models.HoldStatusReady},
+		}}).
+		Find(&holds).Error
+	return holds, err
+}
+
+func (r *HoldRepository) GetByMemberID(memberID uint) ([]models.Hold, error) {
+	var holds []models.Hold
+	err := r.db.Preload("Book").Preload("Copy").
+		Where("member_id = ?", memberID).
+		Order("id DESC").
+		Find(&holds).Error
+	return holds, err
+}
+
+// GetActive returns the member's open hold on a book, if any.
+func (r *HoldRepository) GetActive(memberID, bookID uint) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("member_id = ? AND book_id = ? AND status IN ?", memberID, bookID, activeHoldStatuses).
+		First(&hold).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// QueuePosition is the 1-based place of a waiting hold in its book's queue.
+func (r *HoldRepository) QueuePosition(hold *models.Hold) (int, error) {
+	var ahead int64
+	err := r.db.Model(&models.Hold{}).
+		Where("book_id = ? AND status = ? AND id < ?", hold.BookID, models.HoldStatusWaiting, hold.ID).
+		Count(&ahead).Error
+	return int(ahead) + 1, err
+}
+
+// HasWaitingFromOthers reports whether someone other than memberID is
+// queued for the book.
+func (r *HoldRepository) HasWaitingFromOthers(bookID, memberID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Hold{}).
+		Where("book_id = ? AND member_id <> ? AND status = ?", bookID, memberID, models.HoldStatusWaiting).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// PassCopyOn hands a copy that just became free to the first hold in the
+// book's queue and returns that hold, or puts the copy back on the shelf
+// and returns nil when nobody is waiting. Callers must hold the book's row
+// lock and call BookRepository.SyncAvailability afterwards.
+func (r *HoldRepository) PassCopyOn(copyID, bookID uint, now time.Time) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id = ? AND status = ?", bookID, models.HoldStatusWaiting).
+		Order("id").First(&hold).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, NewBookCopyRepository(r.db).UpdateStatus(copyID, models.CopyStatusAvailable)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.AddDate(0, 0, r.pickupDays)
+	hold.CopyID = &copyID
+	hold.Status = models.HoldStatusReady
+	hold.ReadyAt = &now
+	hold.ExpiresAt = &expiresAt
+	if err := r.Update(&hold); err != nil {
+		return nil, err
+	}
+	if err := NewBookCopyRepository(r.db).UpdateStatus(copyID, models.CopyStatusOnHold); err != nil {
+		return nil, err
+	}
+	err = NewNotificationRepository(r.db).Enqueue(hold.MemberID, models.NotificationHoldReady,
+		fmt.Sprintf("hold_ready:%d", hold.ID),
+		models.NotificationData{HoldID: hold.ID, BookID: bookID, ExpiresAt: &expiresAt})
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// Close ends an active hold with the given status. A copy that was set
+// aside for it is passed on to the next hold in the queue.
+func (r *HoldRepository) Close(hold *models.Hold, status string, now time.Time) (*models.Hold, error) {
+	if !hold.IsActive() {
+		return nil, ErrHoldNotActive
+	}
+
+	wasReady := hold.Status == models.HoldStatusReady
+	hold.Status = status
+	hold.ClosedAt = &now
+	if err := r.Update(hold); err != nil {
+		return nil, err
+	}
+
+	if !wasReady || hold.CopyID == nil || status == models.HoldStatusFulfilled {
+		return nil, nil
+	}
+	return r.PassCopyOn(*hold.CopyID, hold.BookID, now)
+}
+
+// ExpireReady closes every hold whose pickup window has passed, each in its
+// own transaction, and returns the holds that received a copy as a result.
+func (r *HoldRepository) ExpireReady(now time.Time) ([]models.Hold, error) {
+	var stale []models.Hold
+	err := r.db.Where("status = ? AND expires_at < ?", models.HoldStatusReady, now).
+		Order("id").Find(&stale).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var promoted []models.Hold
+	for _, candidate := range stale {
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			if _, err := NewBookRepository(tx).GetByIDForUpdate(candidate.BookID); err != nil {
+				return err
+			}
+
+			holdRepo := NewHoldRepository(tx, r.pickupDays)
+			hold, err := holdRepo.GetByIDForUpdate(candidate.ID)
+			if err != nil {
+				return err
+			}
+			if hold.Status != models.HoldStatusReady || hold.ExpiresAt == nil || !hold.ExpiresAt.Before(now) {
+				return nil
+			}
+			before := *hold
+
+			next, err := holdRepo.Close(hold, models.HoldStatusExpired, now)
+			if err != nil {
+				return err
+			}
+			event := &models.AuditEvent{
+				Action:     models.AuditHoldExpire,
+				EntityType: models.AuditEntityHold,
+				EntityID:   fmt.Sprint(hold.ID),
+			}
+			if err := NewAuditRepository(tx).Record(event, before, hold, nil); err != nil {
+				return err
+			}
+			if next != nil {
+				promoted = append(promoted, *next)
+			}
+			return NewBookRepository(tx).SyncAvailability(hold.BookID)
+		})
+		if err != nil {
+			return promoted, err
+		}
+	}
+	return promoted, nil
+}
@@ -1,29 +1,56 @@
 package repository
 
 import (
-	"library-management-system/internal/config"
+	"time"
+
 	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type LoanRepository struct{}
+type LoanRepository struct {
+	db *gorm.DB
+}
 
-func NewLoanRepository() *LoanRepository {
-	return &LoanRepository{}
+func NewLoanRepository(db *gorm.DB) *LoanRepository {
+	return &LoanRepository{db: db}
 }
 
+func (r *LoanRepository) WithTx(tx *Tx) LoanStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &LoanRepository{db: db}
+}
+
+var openLoanStatuses = []string{models.LoanStatusBorrowed, models.LoanStatusOverdue}
+
 func (r *LoanRepository) Create(loan *models.Loan) error {
-	return config.GetDB().Create(loan).Error
+	return r.db.Create(loan).Error
 }
 
 func (r *LoanRepository) GetAll() ([]models.Loan, error) {
 	var loans []models.Loan
-	err := config.GetDB().Preload("Book").Preload("Member").Find(&loans).Error
+	err := r.db.Preload("Book").Preload("Member").Find(&loans).Error
 	return loans, err
 }
 
 func (r *LoanRepository) GetByID(id uint) (*models.Loan, error) {
 	var loan models.Loan
-	err := config.GetDB().Preload("Book").Preload("Member").First(&loan, id).Error
+	err := r.db.Preload("Book").Preload("Member").First(&loan, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+// GetByIDForUpdate loads a loan and locks its row until the surrounding
+// transaction ends.
+func (r *LoanRepository) GetByIDForUpdate(id uint) (*models.Loan, error) {
+	var loan models.Loan
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&loan, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -31,31 +58,145 @@ func (r *LoanRepository) GetByID(id uint) (*models.Loan, error) {
 }
 
 func (r *LoanRepository) Update(loan *models.Loan) error {
-	return config.GetDB().Save(loan).Error
+	return r.db.Save(loan).Error
 }
 
 func (r *LoanRepository) GetByMemberID(memberID uint) ([]models.Loan, error) {
 	var loans []models.Loan
-	err := config.GetDB().Preload("Book").Where("member_id = ?", memberID).Find(&loans).Error
+	err := r.db.Preload("Book").Where("member_id = ?", memberID).Find(&loans).Error
 	return loans, err
 }
 
 func (r *LoanRepository) GetByBookID(bookID uint) ([]models.Loan, error) {
 	var loans []models.Loan
-	err := config.GetDB().Preload("Member").Where("book_id = ?", bookID).Find(&loans).Error
+	err := r.db.Preload("Member").Where("book_id = ?", bookID).Find(&loans).Error
 	return loans, err
 }
 
 func (r *LoanRepository) GetActiveLoans() ([]models.Loan, error) {
 	var loans []models.Loan
-	err := config.GetDB().Preload("Book").Preload("Member").Where("status = ?", "borrowed").Find(&loans).Error
+	err := r.db.Preload("Book").Preload("Member").Where("status IN ?", openLoanStatuses).Find(&loans).Error
+	return loans, err
+}
+
+func (r *LoanRepository) CountActiveByMember(memberID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Loan{}).Where("member_id = ? AND status IN ?", memberID, openLoanStatuses).Count(&count).Error
+	return count, err
+}
+
+func (r *LoanRepository) CountActiveByCopy(copyID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Loan{}).Where("copy_id = ? AND status IN ?", copyID, openLoanStatuses).Count(&count).Error
+	return count, err
+}
+
+// CountOpenByStatus counts the loans still out, by status. Unlike
+// CountByStatus it leaves deleted loans out: they are no longer on loan.
+func (r *LoanRepository) CountOpenByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&models.Loan{}).
+		Select("status, COUNT(*) AS count").
+		Where("status IN ?", openLoanStatuses).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// CountByStatus counts loans by status, deleted ones included, so the
+// number of loans ever made or returned never goes down.
+func (r *LoanRepository) CountByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Unscoped().Model(&models.Loan{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// GetOverdueLoans returns the open loans that were due before now.
+func (r *LoanRepository) GetOverdueLoans(now time.Time) ([]models.Loan, error) {
+	var loans []models.Loan
+	err := r.db.Where("status IN ? AND due_date < ?", openLoanStatuses, now).
+		Order("id").
+		Find(&loans).Error
 	return loans, err
 }
 
-func (r *LoanRepository) GetOverdueLoans() ([]models.Loan, error) {
+// GetDueBetween returns the open loans due in [from, to).
+func (r *LoanRepository) GetDueBetween(from, to time.Time) ([]models.Loan, error) {
 	var loans []models.Loan
-	err := config.GetDB().Preload("Book").Preload("Member").
-		Where("status = ? AND due_date < NOW()", "borrowed").
+	err := r.db.Where("status IN ? AND due_date >= ? AND due_date < ?", openLoanStatuses, from, to).
+		Order("id").
 		Find(&loans).Error
 	return loans, err
 }
+
+// MarkOverdue moves borrowed loans past their due date to overdue and
+// returns how many changed.
+func (r *LoanRepository) MarkOverdue(now time.Time) (int64, error) {
+	result := r.db.Model(&models.Loan{}).
+		Where("status = ? AND due_date < ?", models.LoanStatusBorrowed, now).
+		Update("status", models.LoanStatusOverdue)
+	return result.RowsAffected, result.Error
+}
+
+type LoanFilter struct {
+	Status   string
+	MemberID uint
+	BookID   uint
+	DueFrom  time.Time
+	DueTo    time.Time
+}
+
+func (f LoanFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	if f.MemberID != 0 {
+		db = db.Where("member_id = ?", f.MemberID)
+	}
+	if f.BookID != 0 {
+		db = db.Where("book_id = ?", f.BookID)
+	}
+	if !f.DueFrom.IsZero() {
+		db = db.Where("due_date >= ?", f.DueFrom)
+	}
+	if !f.DueTo.IsZero() {
+		db = db.Where("due_date < ?", f.DueTo)
+	}
+	return db
+}
+
+func (r *LoanRepository) List(filter LoanFilter, page Page) ([]models.Loan, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Loan{}).Scopes(filter.apply).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var loans []models.Loan
+	err := r.db.Preload("Book").Preload("Member").
+		Scopes(filter.apply, paginate(page)).
+		Find(&loans).Error
+	return loans, total, err
+}
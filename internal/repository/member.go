@@ -1,29 +1,52 @@
 package repository
 
 import (
-	"library-management-system/internal/config"
+	"time"
+
 	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type MemberRepository struct{}
+type MemberRepository struct {
+	db *gorm.DB
+}
 
-func NewMemberRepository() *MemberRepository {
-	return &MemberRepository{}
+func NewMemberRepository(db *gorm.DB) *MemberRepository {
+	return &MemberRepository{db: db}
+}
+
+func (r *MemberRepository) WithTx(tx *Tx) MemberStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &MemberRepository{db: db}
 }
 
 func (r *MemberRepository) Create(member *models.Member) error {
-	return config.GetDB().Create(member).Error
+	return r.db.Create(member).Error
 }
 
 func (r *MemberRepository) GetAll() ([]models.Member, error) {
 	var members []models.Member
-	err := config.GetDB().Find(&members).Error
+	err := r.db.Find(&members).Error
 	return members, err
 }
 
 func (r *MemberRepository) GetByID(id uint) (*models.Member, error) {
 	var member models.Member
-	err := config.GetDB().First(&member, id).Error
+	err := r.db.First(&member, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *MemberRepository) GetByIDForUpdate(id uint) (*models.Member, error) {
+	var member models.Member
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&member, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -31,27 +54,60 @@ func (r *MemberRepository) GetByID(id uint) (*models.Member, error) {
 }
 
 func (r *MemberRepository) Update(member *models.Member) error {
-	return config.GetDB().Save(member).Error
+	return r.db.Save(member).Error
 }
 
 func (r *MemberRepository) Delete(id uint) error {
-	return config.GetDB().Delete(&models.Member{}, id).Error
+	return r.db.Delete(&models.Member{}, id).Error
 }
 
 func (r *MemberRepository) GetByEmail(email string) (*models.Member, error) {
 	var member models.Member
-	err := config.GetDB().Where("email = ?", email).First(&member).Error
+	err := r.db.Where("email = ?", email).First(&member).Error
 	if err != nil {
 		return nil, err
 	}
 	return &member, nil
 }
 
+// GetByMemberCode also finds deleted members: their codes stay taken in
+// the unique index, so they must not be handed out again.
 func (r *MemberRepository) GetByMemberCode(memberCode string) (*models.Member, error) {
 	var member models.Member
-	err := config.GetDB().Where("member_code = ?", memberCode).First(&member).Error
+	err := r.db.Unscoped().Where("member_code = ?", memberCode).First(&member).Error
 	if err != nil {
 		return nil, err
 	}
 	return &member, nil
 }
+
+// ExpireMemberships marks active members whose membership ran out before
+// now as expired and returns how many changed.
+func (r *MemberRepository) ExpireMemberships(now time.Time) (int64, error) {
+	result := r.db.Model(&models.Member{}).
+		Where("status = ? AND expires_at < ?", "active", now).
+		Update("status", "expired")
+	return result.RowsAffected, result.Error
+}
+
+type MemberFilter struct {
+	Status string
+}
+
+func (f MemberFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	return db
+}
+
+func (r *MemberRepository) List(filter MemberFilter, page Page) ([]models.Member, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Member{}).Scopes(filter.apply).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var members []models.Member
+	err := r.db.Scopes(filter.apply, paginate(page)).Find(&members).Error
+	return members, total, err
+}
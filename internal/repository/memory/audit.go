@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"sync"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type AuditStore struct {
+	mu     sync.Mutex
+	events []models.AuditEvent
+}
+
+func NewAuditStore() *AuditStore {
+	return &AuditStore{}
+}
+
+func (s *AuditStore) WithTx(tx *repository.Tx) repository.AuditStore {
+	return s
+}
+
+func (s *AuditStore) Record(event *models.AuditEvent, before, after, details interface{}) error {
+	if err := repository.FillAuditEvent(event, before, after, details); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = uint(len(s.events) + 1)
+	event.CreatedAt = now()
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *AuditStore) CountByAction(action string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, event := range s.events {
+		if event.Action == action {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *AuditStore) GetByID(id uint) (*models.AuditEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id == 0 || int(id) > len(s.events) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	event := s.events[id-1]
+	return &event, nil
+}
+
+func (s *AuditStore) List(filter repository.AuditFilter, page repository.Page) ([]models.AuditEvent, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []models.AuditEvent
+	for _, event := range s.events {
+		if filter.ActorID != 0 && (event.ActorID == nil || *event.ActorID != filter.ActorID) {
+			continue
+		}
+		if filter.Action != "" && event.Action != filter.Action {
+			continue
+		}
+		if filter.EntityType != "" && event.EntityType != filter.EntityType {
+			continue
+		}
+		if filter.EntityID != "" && event.EntityID != filter.EntityID {
+			continue
+		}
+		if filter.RequestID != "" && event.RequestID != filter.RequestID {
+			continue
+		}
+		if !filter.From.IsZero() && event.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !event.CreatedAt.Before(filter.To) {
+			continue
+		}
+		events = append(events, event)
+	}
+	total := int64(len(events))
+	return sortByID(events, func(e models.AuditEvent) uint { return e.ID }, page), total, nil
+}
+
+// Events returns every recorded event, oldest first, for tests to inspect.
+func (s *AuditStore) Events() []models.AuditEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.AuditEvent(nil), s.events...)
+}
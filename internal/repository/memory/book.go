@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type BookStore struct {
+	mu     sync.Mutex
+	books  map[uint]models.Book
+	copies *BookCopyStore
+	nextID uint
+}
+
+// NewBookStore returns an empty store whose SyncAvailability counts the
+// given copies. With nil copies it leaves stock and availability alone.
+func NewBookStore(copies *BookCopyStore) *BookStore {
+	return &BookStore{books: make(map[uint]models.Book), copies: copies}
+}
+
+func (s *BookStore) WithTx(tx *repository.Tx) repository.BookStore {
+	return s
+}
+
+func (s *BookStore) Create(book *models.Book) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	book.ID = s.nextID
+	book.CreatedAt = now()
+	book.UpdatedAt = book.CreatedAt
+	s.books[book.ID] = *book
+	return nil
+}
+
+func (s *BookStore) GetByID(id uint) (*models.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	book, ok := s.books[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &book, nil
+}
+
+func (s *BookStore) GetByIDForUpdate(id uint) (*models.Book, error) {
+	return s.GetByID(id)
+}
+
+func (s *BookStore) GetByISBN(isbn string) (*models.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, book := range s.books {
+		if book.ISBN == isbn {
+			return &book, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// Update keeps stock and available as they were, like the Postgres store.
+func (s *BookStore) Update(book *models.Book) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.books[book.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	book.Stock = stored.Stock
+	book.Available = stored.Available
+	book.UpdatedAt = now()
+	s.books[book.ID] = *book
+	return nil
+}
+
+func (s *BookStore) Delete(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.books, id)
+	return nil
+}
+
+func (s *BookStore) SyncAvailability(id uint) error {
+	if s.copies == nil {
+		return nil
+	}
+	copies, err := s.copies.GetByBookID(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	book, ok := s.books[id]
+	if !ok {
+		return nil
+	}
+	book.Stock, book.Available = 0, 0
+	for _, bookCopy := range copies {
+		if bookCopy.Status != models.CopyStatusLost && bookCopy.Status != models.CopyStatusWithdrawn {
+			book.Stock++
+		}
+		if bookCopy.Status == models.CopyStatusAvailable {
+			book.Available++
+		}
+	}
+	s.books[id] = book
+	return nil
+}
+
+func (s *BookStore) List(filter repository.BookFilter, page repository.Page) ([]models.Book, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var books []models.Book
+	for _, book := range s.books {
+		if filter.Category != "" && !strings.EqualFold(book.Category, filter.Category) {
+			continue
+		}
+		if filter.Author != "" && !strings.Contains(strings.ToLower(book.Author), strings.ToLower(filter.Author)) {
+			continue
+		}
+		if filter.YearFrom > 0 && book.Year < filter.YearFrom {
+			continue
+		}
+		if filter.YearTo > 0 && book.Year > filter.YearTo {
+			continue
+		}
+		books = append(books, book)
+	}
+	total := int64(len(books))
+	return sortByID(books, func(b models.Book) uint { return b.ID }, page), total, nil
+}
+
+// Search matches books whose title, author or description contains every
+// word of q, ignoring case. Results are not ranked or highlighted.
+func (s *BookStore) Search(q string, page repository.Page) ([]repository.BookSearchResult, int64, error) {
+	words := strings.Fields(strings.ToLower(q))
+	if len(words) == 0 {
+		return nil, 0, repository.ErrEmptySearchQuery
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []repository.BookSearchResult
+	for _, book := range s.books {
+		text := strings.ToLower(book.Title + " " + book.Author + " " + book.Description)
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			results = append(results, repository.BookSearchResult{Book: book})
+		}
+	}
+	total := int64(len(results))
+	return sortByID(results, func(r repository.BookSearchResult) uint { return r.ID }, page), total, nil
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"sync"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// BookCopyStore keeps copies without their book. It reads loans to find
+// copies that no open loan points at.
+type BookCopyStore struct {
+	mu     sync.Mutex
+	copies map[uint]models.BookCopy
+	loans  *LoanStore
+	nextID uint
+}
+
+func NewBookCopyStore(loans *LoanStore) *BookCopyStore {
+	return &BookCopyStore{copies: make(map[uint]models.BookCopy), loans: loans}
+}
+
+func (s *BookCopyStore) WithTx(tx *repository.Tx) repository.BookCopyStore {
+	return s
+}
+
+func (s *BookCopyStore) Create(bookCopy *models.BookCopy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bookCopy.Status == "" {
+		bookCopy.Status = models.CopyStatusAvailable
+	}
+	if bookCopy.Condition == "" {
+		bookCopy.Condition = models.CopyConditionGood
+	}
+	s.nextID++
+	bookCopy.ID = s.nextID
+	bookCopy.CreatedAt = now()
+	bookCopy.UpdatedAt = bookCopy.CreatedAt
+	s.copies[bookCopy.ID] = *bookCopy
+	return nil
+}
+
+func (s *BookCopyStore) GetByID(id uint) (*models.BookCopy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bookCopy, ok := s.copies[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &bookCopy, nil
+}
+
+func (s *BookCopyStore) GetByIDForUpdate(id uint) (*models.BookCopy, error) {
+	return s.GetByID(id)
+}
+
+func (s *BookCopyStore) GetByBarcode(barcode string) (*models.BookCopy, error) {
+	copies := s.filter(func(c models.BookCopy) bool { return c.Barcode == barcode })
+	if len(copies) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &copies[0], nil
+}
+
+func (s *BookCopyStore) GetByBookID(bookID uint) ([]models.BookCopy, error) {
+	return s.filter(func(c models.BookCopy) bool { return c.BookID == bookID }), nil
+}
+
+func (s *BookCopyStore) Update(bookCopy *models.BookCopy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.copies[bookCopy.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	bookCopy.UpdatedAt = now()
+	s.copies[bookCopy.ID] = *bookCopy
+	return nil
+}
+
+func (s *BookCopyStore) UpdateStatus(id uint, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bookCopy, ok := s.copies[id]
+	if !ok {
+		return nil
+	}
+	bookCopy.Status = status
+	bookCopy.UpdatedAt = now()
+	s.copies[id] = bookCopy
+	return nil
+}
+
+// NextSequence counts every copy the book ever had; the store never
+// deletes any.
+func (s *BookCopyStore) NextSequence(bookID uint) (int, error) {
+	copies, _ := s.GetByBookID(bookID)
+	return len(copies) + 1, nil
+}
+
+func (s *BookCopyStore) FirstAvailable(bookID uint) (*models.BookCopy, error) {
+	copies := s.filter(func(c models.BookCopy) bool {
+		return c.BookID == bookID && c.Status == models.CopyStatusAvailable
+	})
+	if len(copies) == 0 {
+		return nil, repository.ErrBookNotAvailable
+	}
+	return &copies[0], nil
+}
+
+func (s *BookCopyStore) FirstUntrackedOnLoan(bookID uint) (*models.BookCopy, error) {
+	tracked := make(map[uint]bool)
+	if s.loans != nil {
+		s.loans.mu.Lock()
+		for _, loan := range s.loans.loans {
+			if loan.CopyID != nil && loan.Status != models.LoanStatusReturned {
+				tracked[*loan.CopyID] = true
+			}
+		}
+		s.loans.mu.Unlock()
+	}
+
+	copies := s.filter(func(c models.BookCopy) bool {
+		return c.BookID == bookID && c.Status == models.CopyStatusOnLoan && !tracked[c.ID]
+	})
+	if len(copies) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &copies[0], nil
+}
+
+// filter returns the matching copies in id order.
+func (s *BookCopyStore) filter(match func(models.BookCopy) bool) []models.BookCopy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var copies []models.BookCopy
+	for _, bookCopy := range s.copies {
+		if match(bookCopy) {
+			copies = append(copies, bookCopy)
+		}
+	}
+	return sortByID(copies, func(c models.BookCopy) uint { return c.ID }, repository.Page{})
+}
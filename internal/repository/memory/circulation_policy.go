@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type CirculationPolicyStore struct {
+	mu       sync.Mutex
+	policies map[uint]models.CirculationPolicy
+	nextID   uint
+}
+
+func NewCirculationPolicyStore() *CirculationPolicyStore {
+	return &CirculationPolicyStore{policies: make(map[uint]models.CirculationPolicy)}
+}
+
+func (s *CirculationPolicyStore) WithTx(tx *repository.Tx) repository.CirculationPolicyStore {
+	return s
+}
+
+func (s *CirculationPolicyStore) Create(policy *models.CirculationPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	policy.ID = s.nextID
+	policy.CreatedAt = now()
+	policy.UpdatedAt = policy.CreatedAt
+	s.policies[policy.ID] = *policy
+	return nil
+}
+
+// GetAll orders the policies by member type and category.
+func (s *CirculationPolicyStore) GetAll() ([]models.CirculationPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policies := make([]models.CirculationPolicy, 0, len(s.policies))
+	for _, policy := range s.policies {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].MemberType != policies[j].MemberType {
+			return policies[i].MemberType < policies[j].MemberType
+		}
+		return policies[i].Category < policies[j].Category
+	})
+	return policies, nil
+}
+
+func (s *CirculationPolicyStore) GetByID(id uint) (*models.CirculationPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy, ok := s.policies[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &policy, nil
+}
+
+func (s *CirculationPolicyStore) GetByScope(memberType, category string) (*models.CirculationPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, policy := range s.policies {
+		if policy.MemberType == memberType && policy.Category == category {
+			return &policy, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *CirculationPolicyStore) Update(policy *models.CirculationPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.policies[policy.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	policy.UpdatedAt = now()
+	s.policies[policy.ID] = *policy
+	return nil
+}
+
+func (s *CirculationPolicyStore) Delete(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.policies, id)
+	return nil
+}
+
+// Resolve ranks the matching policies the way the database query does:
+// an exact match first, then member type only, then category only, then a
+// catch-all.
+func (s *CirculationPolicyStore) Resolve(memberType, category string) (*models.CirculationPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var best *models.CirculationPolicy
+	bestRank := 4
+	for _, policy := range s.policies {
+		if policy.MemberType != "" && !strings.EqualFold(policy.MemberType, memberType) {
+			continue
+		}
+		if policy.Category != "" && !strings.EqualFold(policy.Category, category) {
+			continue
+		}
+		rank := 0
+		if policy.MemberType == "" {
+			rank += 2
+		}
+		if policy.Category == "" {
+			rank++
+		}
+		if rank < bestRank {
+			policy := policy
+			best, bestRank = &policy, rank
+		}
+	}
+	if best == nil {
+		policy := models.DefaultCirculationPolicy()
+		return &policy, nil
+	}
+	return best, nil
+}
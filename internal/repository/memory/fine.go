@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// FineStore keeps the fine ledger without members or loans.
+type FineStore struct {
+	mu      sync.Mutex
+	entries map[uint]models.FineEntry
+	nextID  uint
+}
+
+func NewFineStore() *FineStore {
+	return &FineStore{entries: make(map[uint]models.FineEntry)}
+}
+
+func (s *FineStore) WithTx(tx *repository.Tx) repository.FineStore {
+	return s
+}
+
+func (s *FineStore) Create(entry *models.FineEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	entry.ID = s.nextID
+	entry.CreatedAt = now()
+	s.entries[entry.ID] = *entry
+	return nil
+}
+
+func (s *FineStore) CreatePayment(entry *models.FineEntry) error {
+	if err := s.Create(entry); err != nil {
+		return err
+	}
+	receipt := fmt.Sprintf("RCP-%s-%06d", entry.CreatedAt.Format("20060102"), entry.ID)
+	entry.ReceiptNumber = &receipt
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.ID] = *entry
+	return nil
+}
+
+func (s *FineStore) ChargeLoan(loan *models.Loan, total int64, reason string, recordedByID *uint) error {
+	if total <= loan.Fine {
+		return nil
+	}
+	err := s.Create(&models.FineEntry{
+		MemberID:     loan.MemberID,
+		LoanID:       &loan.ID,
+		Type:         models.FineEntryCharge,
+		Amount:       total - loan.Fine,
+		Reason:       reason,
+		RecordedByID: recordedByID,
+	})
+	if err != nil {
+		return err
+	}
+	loan.Fine = total
+	return nil
+}
+
+func (s *FineStore) GetByID(id uint) (*models.FineEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &entry, nil
+}
+
+func (s *FineStore) GetByReceiptNumber(receipt string) (*models.FineEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries {
+		if entry.ReceiptNumber != nil && *entry.ReceiptNumber == receipt {
+			return &entry, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *FineStore) Balance(memberID uint) (*models.FineBalance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	balance := models.FineBalance{MemberID: memberID}
+	for _, entry := range s.entries {
+		if entry.MemberID != memberID {
+			continue
+		}
+		switch entry.Type {
+		case models.FineEntryCharge:
+			balance.Charged += entry.Amount
+		case models.FineEntryPayment:
+			balance.Paid += entry.Amount
+		case models.FineEntryWaiver:
+			balance.Waived += entry.Amount
+		}
+	}
+	balance.Balance = balance.Charged - balance.Paid - balance.Waived
+	return &balance, nil
+}
+
+func (s *FineStore) ChargeTotals() (count, amount int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries {
+		if entry.Type == models.FineEntryCharge {
+			count++
+			amount += entry.Amount
+		}
+	}
+	return count, amount, nil
+}
+
+func (s *FineStore) List(filter repository.FineFilter, page repository.Page) ([]models.FineEntry, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []models.FineEntry
+	for _, entry := range s.entries {
+		if filter.MemberID != 0 && entry.MemberID != filter.MemberID {
+			continue
+		}
+		if filter.LoanID != 0 && (entry.LoanID == nil || *entry.LoanID != filter.LoanID) {
+			continue
+		}
+		if filter.Type != "" && entry.Type != filter.Type {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	total := int64(len(entries))
+	return sortByID(entries, func(e models.FineEntry) uint { return e.ID }, page), total, nil
+}
@@ -0,0 +1,209 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// HoldStore keeps the hold queues without their book, member or copy. Like
+// the database it sets copies aside, queues hold_ready notices and, when
+// holds expire, records the audit event and updates the book's
+// availability, so it needs those stores.
+type HoldStore struct {
+	mu            sync.Mutex
+	holds         map[uint]models.Hold
+	books         *BookStore
+	copies        *BookCopyStore
+	notifications *NotificationStore
+	audit         *AuditStore
+	pickupDays    int
+	nextID        uint
+}
+
+func NewHoldStore(books *BookStore, copies *BookCopyStore, notifications *NotificationStore, audit *AuditStore, pickupDays int) *HoldStore {
+	return &HoldStore{
+		holds:         make(map[uint]models.Hold),
+		books:         books,
+		copies:        copies,
+		notifications: notifications,
+		audit:         audit,
+		pickupDays:    pickupDays,
+	}
+}
+
+func (s *HoldStore) WithTx(tx *repository.Tx) repository.HoldStore {
+	return s
+}
+
+func (s *HoldStore) Create(hold *models.Hold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hold.Status == "" {
+		hold.Status = models.HoldStatusWaiting
+	}
+	s.nextID++
+	hold.ID = s.nextID
+	hold.CreatedAt = now()
+	hold.UpdatedAt = hold.CreatedAt
+	s.holds[hold.ID] = *hold
+	return nil
+}
+
+func (s *HoldStore) GetByID(id uint) (*models.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hold, ok := s.holds[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &hold, nil
+}
+
+func (s *HoldStore) GetByIDForUpdate(id uint) (*models.Hold, error) {
+	return s.GetByID(id)
+}
+
+func (s *HoldStore) Update(hold *models.Hold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.holds[hold.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	hold.UpdatedAt = now()
+	s.holds[hold.ID] = *hold
+	return nil
+}
+
+// GetActiveByBookID returns ready holds first, then the waiting queue.
+func (s *HoldStore) GetActiveByBookID(bookID uint) ([]models.Hold, error) {
+	ready := s.filter(func(h models.Hold) bool { return h.BookID == bookID && h.Status == models.HoldStatusReady })
+	waiting := s.filter(func(h models.Hold) bool { return h.BookID == bookID && h.Status == models.HoldStatusWaiting })
+	return append(ready, waiting...), nil
+}
+
+func (s *HoldStore) GetByMemberID(memberID uint) ([]models.Hold, error) {
+	holds := s.filter(func(h models.Hold) bool { return h.MemberID == memberID })
+	return sortByID(holds, func(h models.Hold) uint { return h.ID }, repository.Page{Sort: []repository.SortField{{Column: "id", Desc: true}}}), nil
+}
+
+func (s *HoldStore) GetActive(memberID, bookID uint) (*models.Hold, error) {
+	holds := s.filter(func(h models.Hold) bool {
+		return h.MemberID == memberID && h.BookID == bookID && h.IsActive()
+	})
+	if len(holds) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &holds[0], nil
+}
+
+func (s *HoldStore) QueuePosition(hold *models.Hold) (int, error) {
+	ahead := s.filter(func(h models.Hold) bool {
+		return h.BookID == hold.BookID && h.Status == models.HoldStatusWaiting && h.ID < hold.ID
+	})
+	return len(ahead) + 1, nil
+}
+
+func (s *HoldStore) HasWaitingFromOthers(bookID, memberID uint) (bool, error) {
+	waiting := s.filter(func(h models.Hold) bool {
+		return h.BookID == bookID && h.MemberID != memberID && h.Status == models.HoldStatusWaiting
+	})
+	return len(waiting) > 0, nil
+}
+
+func (s *HoldStore) PassCopyOn(copyID, bookID uint, now time.Time) (*models.Hold, error) {
+	waiting := s.filter(func(h models.Hold) bool { return h.BookID == bookID && h.Status == models.HoldStatusWaiting })
+	if len(waiting) == 0 {
+		return nil, s.copies.UpdateStatus(copyID, models.CopyStatusAvailable)
+	}
+
+	hold := waiting[0]
+	expiresAt := now.AddDate(0, 0, s.pickupDays)
+	hold.CopyID = &copyID
+	hold.Status = models.HoldStatusReady
+	hold.ReadyAt = &now
+	hold.ExpiresAt = &expiresAt
+	if err := s.Update(&hold); err != nil {
+		return nil, err
+	}
+	if err := s.copies.UpdateStatus(copyID, models.CopyStatusOnHold); err != nil {
+		return nil, err
+	}
+	err := s.notifications.Enqueue(hold.MemberID, models.NotificationHoldReady,
+		fmt.Sprintf("hold_ready:%d", hold.ID),
+		models.NotificationData{HoldID: hold.ID, BookID: bookID, ExpiresAt: &expiresAt})
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (s *HoldStore) Close(hold *models.Hold, status string, now time.Time) (*models.Hold, error) {
+	if !hold.IsActive() {
+		return nil, repository.ErrHoldNotActive
+	}
+
+	wasReady := hold.Status == models.HoldStatusReady
+	hold.Status = status
+	hold.ClosedAt = &now
+	if err := s.Update(hold); err != nil {
+		return nil, err
+	}
+
+	if !wasReady || hold.CopyID == nil || status == models.HoldStatusFulfilled {
+		return nil, nil
+	}
+	return s.PassCopyOn(*hold.CopyID, hold.BookID, now)
+}
+
+func (s *HoldStore) ExpireReady(now time.Time) ([]models.Hold, error) {
+	stale := s.filter(func(h models.Hold) bool {
+		return h.Status == models.HoldStatusReady && h.ExpiresAt != nil && h.ExpiresAt.Before(now)
+	})
+
+	var promoted []models.Hold
+	for _, hold := range stale {
+		before := hold
+		next, err := s.Close(&hold, models.HoldStatusExpired, now)
+		if err != nil {
+			return promoted, err
+		}
+		event := &models.AuditEvent{
+			Action:     models.AuditHoldExpire,
+			EntityType: models.AuditEntityHold,
+			EntityID:   fmt.Sprint(hold.ID),
+		}
+		if err := s.audit.Record(event, before, hold, nil); err != nil {
+			return promoted, err
+		}
+		if next != nil {
+			promoted = append(promoted, *next)
+		}
+		if err := s.books.SyncAvailability(hold.BookID); err != nil {
+			return promoted, err
+		}
+	}
+	return promoted, nil
+}
+
+// filter returns the matching holds in id order.
+func (s *HoldStore) filter(match func(models.Hold) bool) []models.Hold {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var holds []models.Hold
+	for _, hold := range s.holds {
+		if match(hold) {
+			holds = append(holds, hold)
+		}
+	}
+	return sortByID(holds, func(h models.Hold) uint { return h.ID }, repository.Page{})
+}
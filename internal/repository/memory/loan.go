@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// LoanStore keeps loans without their book and member; List does not
+// preload them.
+type LoanStore struct {
+	mu     sync.Mutex
+	loans  map[uint]models.Loan
+	nextID uint
+}
+
+func NewLoanStore() *LoanStore {
+	return &LoanStore{loans: make(map[uint]models.Loan)}
+}
+
+func (s *LoanStore) WithTx(tx *repository.Tx) repository.LoanStore {
+	return s
+}
+
+func (s *LoanStore) Create(loan *models.Loan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if loan.Status == "" {
+		loan.Status = models.LoanStatusBorrowed
+	}
+	s.nextID++
+	loan.ID = s.nextID
+	loan.CreatedAt = now()
+	loan.UpdatedAt = loan.CreatedAt
+	s.loans[loan.ID] = *loan
+	return nil
+}
+
+func (s *LoanStore) GetByID(id uint) (*models.Loan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loan, ok := s.loans[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &loan, nil
+}
+
+func (s *LoanStore) GetByIDForUpdate(id uint) (*models.Loan, error) {
+	return s.GetByID(id)
+}
+
+func (s *LoanStore) Update(loan *models.Loan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.loans[loan.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	loan.UpdatedAt = now()
+	s.loans[loan.ID] = *loan
+	return nil
+}
+
+func (s *LoanStore) CountActiveByMember(memberID uint) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, loan := range s.loans {
+		if loan.MemberID == memberID && loan.IsOpen() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *LoanStore) CountActiveByCopy(copyID uint) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, loan := range s.loans {
+		if loan.CopyID != nil && *loan.CopyID == copyID && loan.IsOpen() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *LoanStore) CountByStatus() (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, loan := range s.loans {
+		counts[loan.Status]++
+	}
+	return counts, nil
+}
+
+func (s *LoanStore) CountOpenByStatus() (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, loan := range s.loans {
+		if loan.IsOpen() {
+			counts[loan.Status]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *LoanStore) List(filter repository.LoanFilter, page repository.Page) ([]models.Loan, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var loans []models.Loan
+	for _, loan := range s.loans {
+		if filter.Status != "" && loan.Status != filter.Status {
+			continue
+		}
+		if filter.MemberID != 0 && loan.MemberID != filter.MemberID {
+			continue
+		}
+		if filter.BookID != 0 && loan.BookID != filter.BookID {
+			continue
+		}
+		if !filter.DueFrom.IsZero() && loan.DueDate.Before(filter.DueFrom) {
+			continue
+		}
+		if !filter.DueTo.IsZero() && !loan.DueDate.Before(filter.DueTo) {
+			continue
+		}
+		loans = append(loans, loan)
+	}
+	total := int64(len(loans))
+	return sortByID(loans, func(l models.Loan) uint { return l.ID }, page), total, nil
+}
+
+func (s *LoanStore) GetOverdueLoans(now time.Time) ([]models.Loan, error) {
+	return s.open(func(loan models.Loan) bool { return loan.DueDate.Before(now) }), nil
+}
+
+func (s *LoanStore) GetDueBetween(from, to time.Time) ([]models.Loan, error) {
+	return s.open(func(loan models.Loan) bool { return !loan.DueDate.Before(from) && loan.DueDate.Before(to) }), nil
+}
+
+// open returns the open loans that match, by id.
+func (s *LoanStore) open(match func(models.Loan) bool) []models.Loan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var loans []models.Loan
+	for _, loan := range s.loans {
+		if loan.IsOpen() && match(loan) {
+			loans = append(loans, loan)
+		}
+	}
+	return sortByID(loans, func(l models.Loan) uint { return l.ID }, repository.Page{})
+}
+
+func (s *LoanStore) MarkOverdue(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for id, loan := range s.loans {
+		if loan.Status == models.LoanStatusBorrowed && loan.DueDate.Before(now) {
+			loan.Status = models.LoanStatusOverdue
+			s.loans[id] = loan
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type MemberStore struct {
+	mu      sync.Mutex
+	members map[uint]models.Member
+	nextID  uint
+}
+
+func NewMemberStore() *MemberStore {
+	return &MemberStore{members: make(map[uint]models.Member)}
+}
+
+func (s *MemberStore) WithTx(tx *repository.Tx) repository.MemberStore {
+	return s
+}
+
+// Create fills in the column defaults the database would.
+func (s *MemberStore) Create(member *models.Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if member.MemberType == "" {
+		member.MemberType = "general"
+	}
+	if member.Status == "" {
+		member.Status = "active"
+	}
+	if member.NotificationPreference == "" {
+		member.NotificationPreference = models.NotifyByEmail
+	}
+	if member.Language == "" {
+		member.Language = models.LanguageIndonesian
+	}
+
+	s.nextID++
+	member.ID = s.nextID
+	member.CreatedAt = now()
+	member.UpdatedAt = member.CreatedAt
+	s.members[member.ID] = *member
+	return nil
+}
+
+func (s *MemberStore) GetByID(id uint) (*models.Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.members[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &member, nil
+}
+
+func (s *MemberStore) GetByIDForUpdate(id uint) (*models.Member, error) {
+	return s.GetByID(id)
+}
+
+func (s *MemberStore) GetByEmail(email string) (*models.Member, error) {
+	return s.find(func(m models.Member) bool { return m.Email == email })
+}
+
+func (s *MemberStore) GetByMemberCode(memberCode string) (*models.Member, error) {
+	return s.find(func(m models.Member) bool { return m.MemberCode == memberCode })
+}
+
+func (s *MemberStore) find(match func(models.Member) bool) (*models.Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, member := range s.members {
+		if match(member) {
+			return &member, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *MemberStore) Update(member *models.Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.members[member.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	member.UpdatedAt = now()
+	s.members[member.ID] = *member
+	return nil
+}
+
+func (s *MemberStore) Delete(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.members, id)
+	return nil
+}
+
+func (s *MemberStore) List(filter repository.MemberFilter, page repository.Page) ([]models.Member, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var members []models.Member
+	for _, member := range s.members {
+		if filter.Status == "" || member.Status == filter.Status {
+			members = append(members, member)
+		}
+	}
+	total := int64(len(members))
+	return sortByID(members, func(m models.Member) uint { return m.ID }, page), total, nil
+}
+
+func (s *MemberStore) ExpireMemberships(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for id, member := range s.members {
+		if member.Status == "active" && member.ExpiresAt != nil && member.ExpiresAt.Before(now) {
+			member.Status = "expired"
+			s.members[id] = member
+			count++
+		}
+	}
+	return count, nil
+}
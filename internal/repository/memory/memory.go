@@ -0,0 +1,78 @@
+// Package memory holds in-memory stores for testing handlers without a
+// database. They keep their rows in maps, ignore transactions and only
+// sort by id; everything else behaves like the Postgres repositories,
+// including gorm.ErrRecordNotFound for missing rows. Related rows, such as
+// the book of a copy, are not preloaded.
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/repository"
+)
+
+// Transactor runs fn straight away with a nil transaction, which the
+// in-memory stores ignore. An error from fn is returned but nothing is
+// rolled back.
+type Transactor struct{}
+
+func (Transactor) Transaction(fn func(tx *repository.Tx) error) error {
+	return fn(nil)
+}
+
+// NewStores returns Stores backed by empty in-memory stores. The stores
+// that touch each other's rows, such as holds passing copies on, are
+// wired together as the database would have them.
+func NewStores(circulation config.CirculationConfig) *repository.Stores {
+	loans := NewLoanStore()
+	copies := NewBookCopyStore(loans)
+	books := NewBookStore(copies)
+	notifications := NewNotificationStore()
+	audit := NewAuditStore()
+	return &repository.Stores{
+		DB:            Transactor{},
+		Books:         books,
+		Members:       NewMemberStore(),
+		Loans:         loans,
+		Users:         NewUserStore(),
+		Audit:         audit,
+		Copies:        copies,
+		Holds:         NewHoldStore(books, copies, notifications, audit, circulation.HoldPickupDays),
+		Fines:         NewFineStore(),
+		Policies:      NewCirculationPolicyStore(),
+		Notifications: notifications,
+		Tokens:        NewTokenStore(),
+		RecoveryCodes: NewRecoveryCodeStore(),
+		LoginAttempts: NewLoginAttemptStore(),
+	}
+}
+
+// now is the time stamped on created and updated rows.
+var now = time.Now
+
+// sortByID orders rows by id, descending when the page asks for
+// "id DESC", and returns the rows of the requested page.
+func sortByID[T any](rows []T, id func(T) uint, page repository.Page) []T {
+	desc := len(page.Sort) > 0 && page.Sort[0].Column == "id" && page.Sort[0].Desc
+	sort.Slice(rows, func(i, j int) bool {
+		if desc {
+			return id(rows[i]) > id(rows[j])
+		}
+		return id(rows[i]) < id(rows[j])
+	})
+
+	if page.PerPage <= 0 {
+		return rows
+	}
+	start := page.Offset()
+	if start >= len(rows) {
+		return nil
+	}
+	end := start + page.PerPage
+	if end > len(rows) {
+		end = len(rows)
+	}
+	return rows[start:end]
+}
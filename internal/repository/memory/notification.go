@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type NotificationStore struct {
+	mu            sync.Mutex
+	notifications map[uint]models.Notification
+	nextID        uint
+}
+
+func NewNotificationStore() *NotificationStore {
+	return &NotificationStore{notifications: make(map[uint]models.Notification)}
+}
+
+func (s *NotificationStore) WithTx(tx *repository.Tx) repository.NotificationStore {
+	return s
+}
+
+// Enqueue skips a message whose dedup key is already in the outbox.
+func (s *NotificationStore) Enqueue(memberID uint, event, dedupKey string, data models.NotificationData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, notification := range s.notifications {
+		if notification.DedupKey == dedupKey {
+			return nil
+		}
+	}
+	stamp := now()
+	s.nextID++
+	s.notifications[s.nextID] = models.Notification{
+		ID:            s.nextID,
+		MemberID:      memberID,
+		Event:         event,
+		DedupKey:      dedupKey,
+		Data:          string(payload),
+		Status:        models.NotificationStatusPending,
+		NextAttemptAt: stamp,
+		CreatedAt:     stamp,
+		UpdatedAt:     stamp,
+	}
+	return nil
+}
+
+func (s *NotificationStore) GetDue(now time.Time, limit int) ([]models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []models.Notification
+	for _, notification := range s.notifications {
+		if notification.Status == models.NotificationStatusPending && !notification.NextAttemptAt.After(now) {
+			due = append(due, notification)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *NotificationStore) Update(notification *models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.notifications[notification.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	notification.UpdatedAt = now()
+	s.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (s *NotificationStore) List(filter repository.NotificationFilter, page repository.Page) ([]models.Notification, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notifications []models.Notification
+	for _, notification := range s.notifications {
+		if filter.MemberID != 0 && notification.MemberID != filter.MemberID {
+			continue
+		}
+		if filter.Event != "" && notification.Event != filter.Event {
+			continue
+		}
+		if filter.Status != "" && notification.Status != filter.Status {
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+	total := int64(len(notifications))
+	return sortByID(notifications, func(n models.Notification) uint { return n.ID }, page), total, nil
+}
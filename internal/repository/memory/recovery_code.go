@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+)
+
+type RecoveryCodeStore struct {
+	mu     sync.Mutex
+	codes  map[uint][]models.RecoveryCode
+	nextID uint
+}
+
+func NewRecoveryCodeStore() *RecoveryCodeStore {
+	return &RecoveryCodeStore{codes: make(map[uint][]models.RecoveryCode)}
+}
+
+func (s *RecoveryCodeStore) WithTx(tx *repository.Tx) repository.RecoveryCodeStore {
+	return s
+}
+
+func (s *RecoveryCodeStore) Replace(userID uint, hashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := make([]models.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		s.nextID++
+		codes[i] = models.RecoveryCode{ID: s.nextID, UserID: userID, CodeHash: hash, CreatedAt: now()}
+	}
+	s.codes[userID] = codes
+	return nil
+}
+
+func (s *RecoveryCodeStore) DeleteAll(userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.codes, userID)
+	return nil
+}
+
+func (s *RecoveryCodeStore) Use(userID uint, hash string, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, code := range s.codes[userID] {
+		if code.CodeHash == hash && code.UsedAt == nil {
+			s.codes[userID][i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// TokenStore keeps refresh tokens, revoked access tokens and password
+// reset tokens.
+type TokenStore struct {
+	mu          sync.Mutex
+	refresh     map[uint]models.RefreshToken
+	revoked     map[string]models.RevokedToken
+	resets      map[uint]models.PasswordResetToken
+	nextRefresh uint
+	nextReset   uint
+}
+
+func NewTokenStore() *TokenStore {
+	return &TokenStore{
+		refresh: make(map[uint]models.RefreshToken),
+		revoked: make(map[string]models.RevokedToken),
+		resets:  make(map[uint]models.PasswordResetToken),
+	}
+}
+
+func (s *TokenStore) WithTx(tx *repository.Tx) repository.TokenStore {
+	return s
+}
+
+func (s *TokenStore) CreateRefreshToken(token *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextRefresh++
+	token.ID = s.nextRefresh
+	token.CreatedAt = now()
+	s.refresh[token.ID] = *token
+	return nil
+}
+
+func (s *TokenStore) GetRefreshTokenForUpdate(tokenHash string) (*models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.refresh {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *TokenStore) UpdateRefreshToken(token *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh[token.ID] = *token
+	return nil
+}
+
+func (s *TokenStore) RevokeFamily(familyID string, now time.Time) error {
+	s.revokeRefresh(func(t models.RefreshToken) bool { return t.FamilyID == familyID }, now)
+	return nil
+}
+
+func (s *TokenStore) RevokeAllForUser(userID uint, now time.Time) error {
+	s.revokeRefresh(func(t models.RefreshToken) bool { return t.UserID == userID }, now)
+	return nil
+}
+
+func (s *TokenStore) revokeRefresh(match func(models.RefreshToken) bool, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, token := range s.refresh {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &now
+			s.refresh[id] = token
+		}
+	}
+}
+
+func (s *TokenStore) RevokeAccessToken(jti string, userID uint, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.revoked[jti]; !ok {
+		s.revoked[jti] = models.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt, CreatedAt: now()}
+	}
+	return nil
+}
+
+func (s *TokenStore) IsAccessTokenRevoked(jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.revoked[jti]
+	return ok, nil
+}
+
+func (s *TokenStore) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextReset++
+	token.ID = s.nextReset
+	token.CreatedAt = now()
+	s.resets[token.ID] = *token
+	return nil
+}
+
+func (s *TokenStore) GetPasswordResetTokenForUpdate(tokenHash string) (*models.PasswordResetToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.resets {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *TokenStore) UsePasswordResetTokens(userID uint, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, token := range s.resets {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &now
+			s.resets[id] = token
+		}
+	}
+	return nil
+}
+
+func (s *TokenStore) PurgeExpired(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for jti, token := range s.revoked {
+		if token.ExpiresAt.Before(now) {
+			delete(s.revoked, jti)
+			purged++
+		}
+	}
+	for id, token := range s.refresh {
+		if token.ExpiresAt.Before(now) {
+			delete(s.refresh, id)
+			purged++
+		}
+	}
+	for id, token := range s.resets {
+		if token.ExpiresAt.Before(now) {
+			delete(s.resets, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type UserStore struct {
+	mu          sync.Mutex
+	users       map[uint]models.User
+	roleChanges []models.RoleChange
+	nextID      uint
+}
+
+func NewUserStore() *UserStore {
+	return &UserStore{users: make(map[uint]models.User)}
+}
+
+func (s *UserStore) WithTx(tx *repository.Tx) repository.UserStore {
+	return s
+}
+
+// Create hashes the password and fills in the column defaults the
+// database would. As there, a new account is always active.
+func (s *UserStore) Create(user *models.User) error {
+	if err := user.BeforeSave(nil); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user.Role == "" {
+		user.Role = models.RoleMember
+	}
+	user.IsActive = true
+
+	s.nextID++
+	user.ID = s.nextID
+	user.CreatedAt = now()
+	user.UpdatedAt = user.CreatedAt
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (s *UserStore) GetByID(id uint) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &user, nil
+}
+
+func (s *UserStore) GetByUsername(username string) (*models.User, error) {
+	return s.find(func(u models.User) bool { return u.Username == username })
+}
+
+func (s *UserStore) GetByEmail(email string) (*models.User, error) {
+	return s.find(func(u models.User) bool { return u.Email == email })
+}
+
+func (s *UserStore) GetByMemberID(memberID uint) (*models.User, error) {
+	return s.find(func(u models.User) bool { return u.MemberID != nil && *u.MemberID == memberID })
+}
+
+func (s *UserStore) find(match func(models.User) bool) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *UserStore) GetAll() ([]models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	return sortByID(users, func(u models.User) uint { return u.ID }, repository.Page{}), nil
+}
+
+// update applies change to the stored user with the given id. Like an
+// UPDATE with a WHERE clause, it does nothing when there is none.
+func (s *UserStore) update(id uint, change func(*models.User)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		change(&user)
+		user.UpdatedAt = now()
+		s.users[id] = user
+	}
+}
+
+func (s *UserStore) UpdateRole(id uint, role string) error {
+	s.update(id, func(u *models.User) { u.Role = role })
+	return nil
+}
+
+func (s *UserStore) SetActive(id uint, active bool) error {
+	s.update(id, func(u *models.User) { u.IsActive = active })
+	return nil
+}
+
+func (s *UserStore) SetMember(id uint, memberID *uint) error {
+	s.update(id, func(u *models.User) { u.MemberID = memberID })
+	return nil
+}
+
+func (s *UserStore) UpdatePassword(user *models.User, password string) error {
+	user.Password = password
+	if err := user.BeforeSave(nil); err != nil {
+		return err
+	}
+	s.update(user.ID, func(u *models.User) { u.Password = user.Password })
+	return nil
+}
+
+func (s *UserStore) UpdateTOTP(user *models.User) error {
+	s.update(user.ID, func(u *models.User) {
+		u.TOTPEnabled = user.TOTPEnabled
+		u.TOTPSecret = user.TOTPSecret
+		u.TOTPLastStep = user.TOTPLastStep
+	})
+	return nil
+}
+
+func (s *UserStore) UseTOTPStep(id uint, step int64) (bool, error) {
+	used := false
+	s.update(id, func(u *models.User) {
+		if u.TOTPLastStep < step {
+			u.TOTPLastStep = step
+			used = true
+		}
+	})
+	return used, nil
+}
+
+func (s *UserStore) RevokeSessions(id uint, at time.Time) error {
+	at = at.Truncate(time.Second)
+	s.update(id, func(u *models.User) { u.SessionsRevokedAt = &at })
+	return nil
+}
+
+func (s *UserStore) CreateRoleChange(change *models.RoleChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change.ID = uint(len(s.roleChanges) + 1)
+	change.CreatedAt = now()
+	s.roleChanges = append(s.roleChanges, *change)
+	return nil
+}
+
+// GetRoleChanges returns the user's role changes, newest first.
+func (s *UserStore) GetRoleChanges(userID uint) ([]models.RoleChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changes []models.RoleChange
+	for _, change := range s.roleChanges {
+		if change.UserID == userID {
+			changes = append(changes, change)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ID > changes[j].ID
+	})
+	return changes, nil
+}
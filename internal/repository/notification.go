@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) WithTx(tx *Tx) NotificationStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &NotificationRepository{db: db}
+}
+
+// Enqueue adds a message to the outbox unless one with the same dedup key
+// is already there.
+func (r *NotificationRepository) Enqueue(memberID uint, event, dedupKey string, data models.NotificationData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	notification := &models.Notification{
+		MemberID:      memberID,
+		Event:         event,
+		DedupKey:      dedupKey,
+		Data:          string(payload),
+		Status:        models.NotificationStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dedup_key"}},
+		DoNothing: true,
+	}).Create(notification).Error
+}
+
+// GetDue returns pending messages whose next attempt is due, oldest first.
+func (r *NotificationRepository) GetDue(now time.Time, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", models.NotificationStatusPending, now).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *NotificationRepository) Update(notification *models.Notification) error {
+	return r.db.Save(notification).Error
+}
+
+type NotificationFilter struct {
+	MemberID uint
+	Event    string
+	Status   string
+}
+
+func (f NotificationFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.MemberID != 0 {
+		db = db.Where("member_id = ?", f.MemberID)
+	}
+	if f.Event != "" {
+		db = db.Where("event = ?", f.Event)
+	}
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	return db
+}
+
+func (r *NotificationRepository) List(filter NotificationFilter, page Page) ([]models.Notification, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Notification{}).Scopes(filter.apply).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	err := r.db.Scopes(filter.apply, paginate(page)).Find(&notifications).Error
+	return notifications, total, err
+}
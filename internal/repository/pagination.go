@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+type Page struct {
+	Number  int
+	PerPage int
+	Sort    []SortField
+}
+
+func (p Page) Offset() int {
+	if p.Number < 1 {
+		return 0
+	}
+	return (p.Number - 1) * p.PerPage
+}
+
+// paginate applies ordering, limit and offset. Sort columns are expected to
+// come from a whitelist; they are interpolated into the ORDER BY clause.
+// id always comes last, so rows that tie on the sort columns keep the same
+// order from one page to the next instead of repeating or going missing.
+func paginate(p Page) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		byID := false
+		for _, field := range p.Sort {
+			if field.Desc {
+				db = db.Order(field.Column + " DESC")
+			} else {
+				db = db.Order(field.Column)
+			}
+			byID = byID || field.Column == "id"
+		}
+		if !byID {
+			db = db.Order("id")
+		}
+		if p.PerPage > 0 {
+			db = db.Limit(p.PerPage).Offset(p.Offset())
+		}
+		return db
+	}
+}
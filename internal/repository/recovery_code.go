@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"time"
+
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{db: db}
+}
+
+func (r *RecoveryCodeRepository) WithTx(tx *Tx) RecoveryCodeStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &RecoveryCodeRepository{db: db}
+}
+
+// Replace drops the user's recovery codes and stores the given hashes in
+// their place.
+func (r *RecoveryCodeRepository) Replace(userID uint, hashes []string) error {
+	if err := r.DeleteAll(userID); err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return r.db.Create(&codes).Error
+}
+
+func (r *RecoveryCodeRepository) DeleteAll(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+// Use marks an unused code as used and reports whether there was one.
+func (r *RecoveryCodeRepository) Use(userID uint, hash string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
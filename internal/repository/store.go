@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Tx is an open transaction. Stores bound to it with WithTx take part in
+// it. WithTx(nil) leaves a store as it is, so code that may or may not run
+// in a transaction can always call it.
+type Tx struct {
+	db *gorm.DB
+}
+
+// Transactor runs fn inside a single transaction; any error rolls
+// everything back.
+type Transactor interface {
+	Transaction(fn func(tx *Tx) error) error
+}
+
+// NewTransactor returns a Transactor that runs transactions on db.
+func NewTransactor(db *gorm.DB) Transactor {
+	return gormTransactor{db: db}
+}
+
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func (t gormTransactor) Transaction(fn func(tx *Tx) error) error {
+	return t.db.Transaction(func(db *gorm.DB) error {
+		return fn(&Tx{db: db})
+	})
+}
+
+// bind returns the database a store bound to tx works on, or nil when tx
+// is nil and the store stays as it is.
+func (tx *Tx) bind() *gorm.DB {
+	if tx == nil {
+		return nil
+	}
+	return tx.db
+}
+
+// BookStore is what the handlers need of the book catalog.
+type BookStore interface {
+	WithTx(tx *Tx) BookStore
+	Create(book *models.Book) error
+	GetByID(id uint) (*models.Book, error)
+	GetByIDForUpdate(id uint) (*models.Book, error)
+	GetByISBN(isbn string) (*models.Book, error)
+	Update(book *models.Book) error
+	Delete(id uint) error
+	SyncAvailability(id uint) error
+	List(filter BookFilter, page Page) ([]models.Book, int64, error)
+	Search(q string, page Page) ([]BookSearchResult, int64, error)
+}
+
+// MemberStore is what the handlers and the scheduler need of library
+// members.
+type MemberStore interface {
+	WithTx(tx *Tx) MemberStore
+	Create(member *models.Member) error
+	GetByID(id uint) (*models.Member, error)
+	GetByIDForUpdate(id uint) (*models.Member, error)
+	GetByEmail(email string) (*models.Member, error)
+	GetByMemberCode(memberCode string) (*models.Member, error)
+	Update(member *models.Member) error
+	Delete(id uint) error
+	List(filter MemberFilter, page Page) ([]models.Member, int64, error)
+	ExpireMemberships(now time.Time) (int64, error)
+}
+
+// LoanStore is what the handlers and the scheduler need of loans.
+type LoanStore interface {
+	WithTx(tx *Tx) LoanStore
+	Create(loan *models.Loan) error
+	GetByID(id uint) (*models.Loan, error)
+	GetByIDForUpdate(id uint) (*models.Loan, error)
+	Update(loan *models.Loan) error
+	CountActiveByMember(memberID uint) (int64, error)
+	CountActiveByCopy(copyID uint) (int64, error)
+	CountByStatus() (map[string]int64, error)
+	CountOpenByStatus() (map[string]int64, error)
+	List(filter LoanFilter, page Page) ([]models.Loan, int64, error)
+	GetOverdueLoans(now time.Time) ([]models.Loan, error)
+	GetDueBetween(from, to time.Time) ([]models.Loan, error)
+	MarkOverdue(now time.Time) (int64, error)
+}
+
+// UserStore is what the handlers and the auth middleware need of user
+// accounts.
+type UserStore interface {
+	WithTx(tx *Tx) UserStore
+	Create(user *models.User) error
+	GetByID(id uint) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	GetByEmail(email string) (*models.User, error)
+	GetByMemberID(memberID uint) (*models.User, error)
+	GetAll() ([]models.User, error)
+	UpdateRole(id uint, role string) error
+	SetActive(id uint, active bool) error
+	SetMember(id uint, memberID *uint) error
+	UpdatePassword(user *models.User, password string) error
+	UpdateTOTP(user *models.User) error
+	UseTOTPStep(id uint, step int64) (bool, error)
+	RevokeSessions(id uint, at time.Time) error
+	CreateRoleChange(change *models.RoleChange) error
+	GetRoleChanges(userID uint) ([]models.RoleChange, error)
+}
+
+// AuditStore records and looks up audit events.
+type AuditStore interface {
+	WithTx(tx *Tx) AuditStore
+	Record(event *models.AuditEvent, before, after, details interface{}) error
+	GetByID(id uint) (*models.AuditEvent, error)
+	List(filter AuditFilter, page Page) ([]models.AuditEvent, int64, error)
+	CountByAction(action string) (int64, error)
+}
+
+// BookCopyStore is what the handlers need of the physical copies of books.
+type BookCopyStore interface {
+	WithTx(tx *Tx) BookCopyStore
+	Create(bookCopy *models.BookCopy) error
+	GetByID(id uint) (*models.BookCopy, error)
+	GetByIDForUpdate(id uint) (*models.BookCopy, error)
+	GetByBarcode(barcode string) (*models.BookCopy, error)
+	GetByBookID(bookID uint) ([]models.BookCopy, error)
+	Update(bookCopy *models.BookCopy) error
+	UpdateStatus(id uint, status string) error
+	NextSequence(bookID uint) (int, error)
+	FirstAvailable(bookID uint) (*models.BookCopy, error)
+	FirstUntrackedOnLoan(bookID uint) (*models.BookCopy, error)
+}
+
+// HoldStore is what the handlers and the scheduler need of the hold queues.
+type HoldStore interface {
+	WithTx(tx *Tx) HoldStore
+	Create(hold *models.Hold) error
+	GetByID(id uint) (*models.Hold, error)
+	GetByIDForUpdate(id uint) (*models.Hold, error)
+	GetActiveByBookID(bookID uint) ([]models.Hold, error)
+	GetByMemberID(memberID uint) ([]models.Hold, error)
+	GetActive(memberID, bookID uint) (*models.Hold, error)
+	QueuePosition(hold *models.Hold) (int, error)
+	HasWaitingFromOthers(bookID, memberID uint) (bool, error)
+	PassCopyOn(copyID, bookID uint, now time.Time) (*models.Hold, error)
+	Close(hold *models.Hold, status string, now time.Time) (*models.Hold, error)
+	ExpireReady(now time.Time) ([]models.Hold, error)
+}
+
+// FineStore is what the handlers, the scheduler and the metrics need of
+// the fine ledger.
+type FineStore interface {
+	WithTx(tx *Tx) FineStore
+	Create(entry *models.FineEntry) error
+	CreatePayment(entry *models.FineEntry) error
+	ChargeLoan(loan *models.Loan, total int64, reason string, recordedByID *uint) error
+	GetByID(id uint) (*models.FineEntry, error)
+	GetByReceiptNumber(receipt string) (*models.FineEntry, error)
+	Balance(memberID uint) (*models.FineBalance, error)
+	ChargeTotals() (count, amount int64, err error)
+	List(filter FineFilter, page Page) ([]models.FineEntry, int64, error)
+}
+
+// CirculationPolicyStore is what the handlers and the scheduler need of
+// circulation policies.
+type CirculationPolicyStore interface {
+	WithTx(tx *Tx) CirculationPolicyStore
+	Create(policy *models.CirculationPolicy) error
+	GetAll() ([]models.CirculationPolicy, error)
+	GetByID(id uint) (*models.CirculationPolicy, error)
+	GetByScope(memberType, category string) (*models.CirculationPolicy, error)
+	Update(policy *models.CirculationPolicy) error
+	Delete(id uint) error
+	Resolve(memberType, category string) (*models.CirculationPolicy, error)
+}
+
+// NotificationStore is what the handlers, the scheduler and the
+// dispatcher need of the notification outbox.
+type NotificationStore interface {
+	WithTx(tx *Tx) NotificationStore
+	Enqueue(memberID uint, event, dedupKey string, data models.NotificationData) error
+	GetDue(now time.Time, limit int) ([]models.Notification, error)
+	Update(notification *models.Notification) error
+	List(filter NotificationFilter, page Page) ([]models.Notification, int64, error)
+}
+
+// TokenStore is what the handlers, the auth middleware and the scheduler
+// need of refresh tokens, revoked access tokens and password reset tokens.
+type TokenStore interface {
+	WithTx(tx *Tx) TokenStore
+	CreateRefreshToken(token *models.RefreshToken) error
+	GetRefreshTokenForUpdate(tokenHash string) (*models.RefreshToken, error)
+	UpdateRefreshToken(token *models.RefreshToken) error
+	RevokeFamily(familyID string, now time.Time) error
+	RevokeAllForUser(userID uint, now time.Time) error
+	RevokeAccessToken(jti string, userID uint, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	CreatePasswordResetToken(token *models.PasswordResetToken) error
+	GetPasswordResetTokenForUpdate(tokenHash string) (*models.PasswordResetToken, error)
+	UsePasswordResetTokens(userID uint, now time.Time) error
+	PurgeExpired(now time.Time) (int64, error)
+}
+
+// RecoveryCodeStore is what the handlers need of two-factor recovery codes.
+type RecoveryCodeStore interface {
+	WithTx(tx *Tx) RecoveryCodeStore
+	Replace(userID uint, hashes []string) error
+	DeleteAll(userID uint) error
+	Use(userID uint, hash string, now time.Time) (bool, error)
+}
+
+// LoginAttemptStore is what the login guard and the scheduler need of
+// failed login counts.
+type LoginAttemptStore interface {
+	WithTx(tx *Tx) LoginAttemptStore
+	Get(key string) (*models.LoginAttempt, error)
+	RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error)
+	Lock(key string, until time.Time) error
+	Delete(key string) error
+	PurgeStale(before, now time.Time) (int64, error)
+}
+
+// Stores holds one of every store, all on the same database. It is built
+// once at startup and handed to whatever needs data access.
+type Stores struct {
+	DB            Transactor
+	Books         BookStore
+	Members       MemberStore
+	Loans         LoanStore
+	Users         UserStore
+	Audit         AuditStore
+	Copies        BookCopyStore
+	Holds         HoldStore
+	Fines         FineStore
+	Policies      CirculationPolicyStore
+	Notifications NotificationStore
+	Tokens        TokenStore
+	RecoveryCodes RecoveryCodeStore
+	LoginAttempts LoginAttemptStore
+
+	withContext func(ctx context.Context) *Stores
+}
+
+// NewStores builds every store on db. circulation sets how long holds
+// wait for pickup.
+func NewStores(db *gorm.DB, circulation config.CirculationConfig) *Stores {
+	return &Stores{
+		withContext: func(ctx context.Context) *Stores {
+			return NewStores(db.WithContext(ctx), circulation)
+		},
+		DB:            NewTransactor(db),
+		Books:         NewBookRepository(db),
+		Members:       NewMemberRepository(db),
+		Loans:         NewLoanRepository(db),
+		Users:         NewUserRepository(db),
+		Audit:         NewAuditRepository(db),
+		Copies:        NewBookCopyRepository(db),
+		Holds:         NewHoldRepository(db, circulation.HoldPickupDays),
+		Fines:         NewFineRepository(db),
+		Policies:      NewCirculationPolicyRepository(db),
+		Notifications: NewNotificationRepository(db),
+		Tokens:        NewTokenRepository(db),
+		RecoveryCodes: NewRecoveryCodeRepository(db),
+		LoginAttempts: NewLoginAttemptRepository(db),
+	}
+}
+
+// WithContext returns stores whose queries give up when ctx is done.
+// Stores built without a database, such as the in-memory ones, are
+// returned as they are.
+func (s *Stores) WithContext(ctx context.Context) *Stores {
+	if s.withContext == nil {
+		return s
+	}
+	return s.withContext(ctx)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"time"
+
+	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) WithTx(tx *Tx) TokenStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetRefreshTokenForUpdate looks a refresh token up by hash and locks it,
+// so two refreshes with the same token cannot both succeed.
+func (r *TokenRepository) GetRefreshTokenForUpdate(tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *TokenRepository) UpdateRefreshToken(token *models.RefreshToken) error {
+	return r.db.Save(token).Error
+}
+
+// RevokeFamily ends every session descended from the same login.
+func (r *TokenRepository) RevokeFamily(familyID string, now time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+func (r *TokenRepository) RevokeAllForUser(userID uint, now time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// RevokeAccessToken puts an access token on the revocation list until it
+// expires.
+func (r *TokenRepository) RevokeAccessToken(jti string, userID uint, expiresAt time.Time) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (r *TokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *TokenRepository) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetPasswordResetTokenForUpdate looks a reset token up by hash and locks
+// it, so it cannot be used twice at the same time.
+func (r *TokenRepository) GetPasswordResetTokenForUpdate(tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// UsePasswordResetTokens marks every unused reset token of a user as used,
+// so only the newest request, or none after a reset, can be redeemed.
+func (r *TokenRepository) UsePasswordResetTokens(userID uint, now time.Time) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", now).Error
+}
+
+// PurgeExpired deletes revocation entries, refresh tokens and password
+// reset tokens that can no longer be used and returns how many rows went.
+func (r *TokenRepository) PurgeExpired(now time.Time) (int64, error) {
+	var purged int64
+	for _, model := range []interface{}{&models.RevokedToken{}, &models.RefreshToken{}, &models.PasswordResetToken{}} {
+		result := r.db.Where("expires_at < ?", now).Delete(model)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+	}
+	return purged, nil
+}
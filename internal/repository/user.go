@@ -1,23 +1,36 @@
 package repository
 
 import (
-	"library-management-system/internal/config"
+	"time"
+
 	"library-management-system/internal/models"
+
+	"gorm.io/gorm"
 )
 
-type UserRepository struct{}
+type UserRepository struct {
+	db *gorm.DB
+}
 
-func NewUserRepository() *UserRepository {
-	return &UserRepository{}
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) WithTx(tx *Tx) UserStore {
+	db := tx.bind()
+	if db == nil {
+		return r
+	}
+	return &UserRepository{db: db}
 }
 
 func (r *UserRepository) Create(user *models.User) error {
-	return config.GetDB().Create(user).Error
+	return r.db.Create(user).Error
 }
 
 func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 	var user models.User
-	err := config.GetDB().Where("username = ?", username).First(&user).Error
+	err := r.db.Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -26,7 +39,17 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := config.GetDB().Where("email = ?", email).First(&user).Error
+	err := r.db.Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByMemberID returns the account linked to a library member.
+func (r *UserRepository) GetByMemberID(memberID uint) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("member_id = ?", memberID).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -35,9 +58,66 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 
 func (r *UserRepository) GetByID(id uint) (*models.User, error) {
 	var user models.User
-	err := config.GetDB().First(&user, id).Error
+	err := r.db.First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+func (r *UserRepository) GetAll() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Order("id").Find(&users).Error
+	return users, err
+}
+
+func (r *UserRepository) UpdateRole(id uint, role string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// SetMember links the account to a library member, or unlinks it when
+// memberID is nil.
+func (r *UserRepository) SetMember(id uint, memberID *uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("member_id", memberID).Error
+}
+
+func (r *UserRepository) SetActive(id uint, active bool) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("is_active", active).Error
+}
+
+// UpdatePassword stores a new password. The model's save hook hashes it.
+func (r *UserRepository) UpdatePassword(user *models.User, password string) error {
+	user.Password = password
+	return r.db.Model(user).Select("password").Updates(user).Error
+}
+
+// UpdateTOTP stores the user's two-factor settings.
+func (r *UserRepository) UpdateTOTP(user *models.User) error {
+	return r.db.Model(user).Select("totp_enabled", "totp_secret", "totp_last_step").Updates(user).Error
+}
+
+// UseTOTPStep records that the code of a time step was used. It reports
+// false when that step or a later one was already used, which means the
+// code was replayed.
+func (r *UserRepository) UseTOTPStep(id uint, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeSessions rejects every access token issued before at. Token issue
+// times have one-second resolution, so at is truncated to match.
+func (r *UserRepository) RevokeSessions(id uint, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("sessions_revoked_at", at.Truncate(time.Second)).Error
+}
+
+func (r *UserRepository) CreateRoleChange(change *models.RoleChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *UserRepository) GetRoleChanges(userID uint) ([]models.RoleChange, error) {
+	var changes []models.RoleChange
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&changes).Error
+	return changes, err
+}
@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/models"
+	"library-management-system/internal/notification"
+	"library-management-system/internal/repository"
+)
+
+// RegisterLibraryJobs adds the circulation housekeeping jobs. Loans are
+// marked overdue before fines accrue on them, and reminders are queued
+// before the outbox is sent. The jobs work on stores, so they run on the
+// in-memory ones as well as on a database, and bind them to the run's
+// context so a stopping scheduler cancels their queries.
+func RegisterLibraryJobs(s *Scheduler, stores *repository.Stores, dispatcher *notification.Dispatcher, cfg *config.Config) {
+	s.Register("mark_overdue_loans", MarkOverdueLoans(stores))
+	s.Register("accrue_fines", AccrueFines(stores))
+	s.Register("expire_holds", ExpireHolds(stores))
+	s.Register("expire_memberships", ExpireMemberships(stores))
+	s.Register("purge_expired_tokens", PurgeExpiredTokens(stores))
+	s.Register("purge_login_attempts", PurgeLoginAttempts(stores, cfg.LoginGuard.Window))
+	s.Register("notify_due_soon", NotifyDueSoon(stores, cfg.Notification.DueSoonDays))
+	s.Register("notify_overdue", NotifyOverdue(stores))
+	s.Register("send_notifications", SendNotifications(dispatcher))
+}
+
+func MarkOverdueLoans(stores *repository.Stores) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		stores := stores.WithContext(ctx)
+		count, err := stores.Loans.MarkOverdue(now)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d loans marked overdue", count), nil
+	}
+}
+
+// AccrueFines brings the fine of every overdue loan up to date with its
+// circulation policy. Only the increase since the last run is charged, so
+// running it more than once a day is harmless. The first charge on a loan
+// queues a fine_assessed notice.
+func AccrueFines(stores *repository.Stores) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		stores := stores.WithContext(ctx)
+		loans, err := stores.Loans.GetOverdueLoans(now)
+		if err != nil {
+			return "", err
+		}
+
+		charged := 0
+		var total int64
+		summary := func() string {
+			return fmt.Sprintf("%d loans charged %d in fines", charged, total)
+		}
+		for _, overdue := range loans {
+			if err := ctx.Err(); err != nil {
+				return summary(), err
+			}
+
+			policy, err := loanPolicy(stores, &overdue)
+			if err != nil {
+				return summary(), err
+			}
+			fine := policy.FineFor(overdue.DueDate, now)
+			if fine <= overdue.Fine {
+				continue
+			}
+
+			err = stores.DB.Transaction(func(tx *repository.Tx) error {
+				loanRepo := stores.Loans.WithTx(tx)
+				loan, err := loanRepo.GetByIDForUpdate(overdue.ID)
+				if err != nil {
+					return err
+				}
+				if !loan.IsOpen() {
+					return nil
+				}
+
+				before := loan.Fine
+				if err := stores.Fines.WithTx(tx).ChargeLoan(loan, fine, "Overdue fine", nil); err != nil {
+					return err
+				}
+				if loan.Fine == before {
+					return nil
+				}
+				charged++
+				total += loan.Fine - before
+				if err := loanRepo.Update(loan); err != nil {
+					return err
+				}
+				// Same key as the return desk uses, so the member hears
+				// about the fine once whichever charges it first.
+				return stores.Notifications.WithTx(tx).Enqueue(loan.MemberID, models.NotificationFineAssessed,
+					fmt.Sprintf("fine_assessed:loan:%d", loan.ID),
+					models.NotificationData{LoanID: loan.ID, BookID: loan.BookID, DueDate: &loan.DueDate, Amount: loan.Fine})
+			})
+			if err != nil {
+				return summary(), err
+			}
+		}
+		return summary(), nil
+	}
+}
+
+// loanPolicy resolves the circulation policy of a loan from its member's
+// type and its book's category.
+func loanPolicy(stores *repository.Stores, loan *models.Loan) (*models.CirculationPolicy, error) {
+	member, err := stores.Members.GetByID(loan.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	book, err := stores.Books.GetByID(loan.BookID)
+	if err != nil {
+		return nil, err
+	}
+	return stores.Policies.Resolve(member.MemberType, book.Category)
+}
+
+// ExpireHolds closes holds left on the pickup shelf too long. Copies passed
+// on to the next hold wait as long as the hold store is configured for.
+func ExpireHolds(stores *repository.Stores) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		stores := stores.WithContext(ctx)
+		promoted, err := stores.Holds.ExpireReady(now)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d copies passed to the next hold", len(promoted)), nil
+	}
+}
+
+func ExpireMemberships(stores *repository.Stores) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		stores := stores.WithContext(ctx)
+		count, err := stores.Members.ExpireMemberships(now)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d memberships expired", count), nil
+	}
+}
+
+func PurgeExpiredTokens(stores *repository.Stores) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		stores := stores.WithContext(ctx)
+		count, err := stores.Tokens.PurgeExpired(now)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d expired tokens removed", count), nil
+	}
+}
+
+// PurgeLoginAttempts clears failed login counts whose last failure is
+// older than window. Only the database login guard store keeps them there.
+func PurgeLoginAttempts(stores *repository.Stores, window time.Duration) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		stores := stores.WithContext(ctx)
+		count, err := stores.LoginAttempts.PurgeStale(now.Add(-window), now)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d login attempt counts removed", count), nil
+	}
+}
+
+// NotifyDueSoon reminds members of loans due within days. Each due date
+// is reminded once, so a renewed loan is reminded again.
+func NotifyDueSoon(stores *repository.Stores, days int) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		stores := stores.WithContext(ctx)
+		loans, err := stores.Loans.GetDueBetween(now, now.AddDate(0, 0, days))
+		if err != nil {
+			return "", err
+		}
+		return queueLoanNotices(ctx, stores, loans, models.NotificationDueSoon)
+	}
+}
+
+// NotifyOverdue tells members once per due date that a loan is overdue.
+func NotifyOverdue(stores *repository.Stores) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		stores := stores.WithContext(ctx)
+		loans, err := stores.Loans.GetOverdueLoans(now)
+		if err != nil {
+			return "", err
+		}
+		return queueLoanNotices(ctx, stores, loans, models.NotificationOverdue)
+	}
+}
+
+func queueLoanNotices(ctx context.Context, stores *repository.Stores, loans []models.Loan, event string) (string, error) {
+	for i, loan := range loans {
+		if err := ctx.Err(); err != nil {
+			return fmt.Sprintf("%d of %d loans queued", i, len(loans)), err
+		}
+		dueDate := loan.DueDate
+		err := stores.Notifications.Enqueue(loan.MemberID, event,
+			fmt.Sprintf("%s:loan:%d:%d", event, loan.ID, dueDate.Unix()),
+			models.NotificationData{LoanID: loan.ID, BookID: loan.BookID, DueDate: &dueDate})
+		if err != nil {
+			return fmt.Sprintf("%d of %d loans queued", i, len(loans)), err
+		}
+	}
+	return fmt.Sprintf("%d loans checked", len(loans)), nil
+}
+
+func SendNotifications(dispatcher *notification.Dispatcher) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		sent, failed, err := dispatcher.Deliver(ctx, now)
+		return fmt.Sprintf("%d sent, %d failed", sent, failed), err
+	}
+}
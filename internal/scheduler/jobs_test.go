@@ -0,0 +1,168 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/models"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/repository/memory"
+	"library-management-system/internal/scheduler"
+)
+
+var start = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+const day = 24 * time.Hour
+
+// forEachStores runs test on the in-memory stores and on the database
+// stores of each driver.
+func forEachStores(t *testing.T, test func(t *testing.T, stores *repository.Stores)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, memory.NewStores(config.Default().Circulation))
+	})
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run("database/"+driver, func(t *testing.T) {
+			test(t, e2e.NewTestHarness(t, driver).Stores)
+		})
+	}
+}
+
+func run(t *testing.T, job scheduler.JobFunc, now time.Time) {
+	t.Helper()
+	if _, err := job(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLoanJobs marks a student's loan overdue and charges it under the
+// student policy, once however often the job runs, and reminds the member
+// of a loan coming due.
+func TestLoanJobs(t *testing.T) {
+	forEachStores(t, func(t *testing.T, stores *repository.Stores) {
+		policy := &models.CirculationPolicy{Name: "Students", MemberType: "student", LoanPeriodDays: 7, MaxLoans: 3, MaxRenewals: 1, DailyFine: 500}
+		if err := stores.Policies.Create(policy); err != nil {
+			t.Fatal(err)
+		}
+		book := &models.Book{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", ISBN: "978-979-97312-3-4", Stock: 2, Available: 0}
+		if err := stores.Books.Create(book); err != nil {
+			t.Fatal(err)
+		}
+		member := &models.Member{Name: "Budi Santoso", Email: "budi@example.com", MemberCode: "M-0001", MemberType: "student", Status: "active"}
+		if err := stores.Members.Create(member); err != nil {
+			t.Fatal(err)
+		}
+		overdue := &models.Loan{BookID: book.ID, MemberID: member.ID, LoanDate: start, DueDate: start.Add(7 * day), Status: models.LoanStatusBorrowed}
+		dueSoon := &models.Loan{BookID: book.ID, MemberID: member.ID, LoanDate: start.Add(8 * day), DueDate: start.Add(11 * day), Status: models.LoanStatusBorrowed}
+		for _, loan := range []*models.Loan{overdue, dueSoon} {
+			if err := stores.Loans.Create(loan); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		now := start.Add(10 * day)
+		run(t, scheduler.MarkOverdueLoans(stores), now)
+		run(t, scheduler.AccrueFines(stores), now)
+		run(t, scheduler.AccrueFines(stores), now)
+		loan, err := stores.Loans.GetByID(overdue.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loan.Status != models.LoanStatusOverdue || loan.Fine != 1500 {
+			t.Errorf("three days overdue the loan is %s with fine %d, want overdue with 1500", loan.Status, loan.Fine)
+		}
+		if loan, err := stores.Loans.GetByID(dueSoon.ID); err != nil || loan.Status != models.LoanStatusBorrowed {
+			t.Errorf("loan not yet due is %+v (%v), want borrowed", loan, err)
+		}
+		balance, err := stores.Fines.Balance(member.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance.Balance != 1500 {
+			t.Errorf("member owes %d, want 1500", balance.Balance)
+		}
+
+		run(t, scheduler.NotifyDueSoon(stores, 2), now)
+		run(t, scheduler.NotifyOverdue(stores), now)
+		for event, loanID := range map[string]uint{
+			models.NotificationFineAssessed: overdue.ID,
+			models.NotificationOverdue:      overdue.ID,
+			models.NotificationDueSoon:      dueSoon.ID,
+		} {
+			notifications, _, err := stores.Notifications.List(repository.NotificationFilter{MemberID: member.ID, Event: event}, repository.Page{Number: 1, PerPage: 20})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(notifications) != 1 {
+				t.Errorf("member has %d %s notifications, want 1 for loan %d", len(notifications), event, loanID)
+			}
+		}
+	})
+}
+
+// TestHousekeepingJobs expires memberships and purges login failures past
+// their window, leaving current ones alone.
+func TestHousekeepingJobs(t *testing.T) {
+	forEachStores(t, func(t *testing.T, stores *repository.Stores) {
+		expired, current := start.Add(-day), start.Add(day)
+		for i, expiresAt := range []*time.Time{&expired, &current, nil} {
+			member := &models.Member{Name: "Member", Email: fmt.Sprintf("member%d@example.com", i), MemberCode: fmt.Sprintf("M-%04d", i), Status: "active", ExpiresAt: expiresAt}
+			if err := stores.Members.Create(member); err != nil {
+				t.Fatal(err)
+			}
+		}
+		run(t, scheduler.ExpireMemberships(stores), start)
+		members, _, err := stores.Members.List(repository.MemberFilter{Status: "expired"}, repository.Page{Number: 1, PerPage: 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(members) != 1 || members[0].ExpiresAt == nil || !members[0].ExpiresAt.Equal(expired) {
+			t.Errorf("expired members: %+v, want only the one that expired yesterday", members)
+		}
+
+		for key, at := range map[string]time.Time{"account:stale": start.Add(-2 * time.Hour), "account:recent": start.Add(-time.Minute)} {
+			if _, err := stores.LoginAttempts.RecordFailure(key, at, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+		}
+		run(t, scheduler.PurgeLoginAttempts(stores, time.Hour), start)
+		if _, err := stores.LoginAttempts.Get("account:recent"); err != nil {
+			t.Errorf("recent failures were purged: %v", err)
+		}
+		if attempt, err := stores.LoginAttempts.Get("account:stale"); err == nil {
+			t.Errorf("stale failures were kept: %+v", attempt)
+		}
+	})
+}
+
+// TestJobsUseContext cancels a run before it starts: the jobs' queries
+// must give up rather than go ahead on the database.
+func TestJobsUseContext(t *testing.T) {
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			stores := e2e.NewTestHarness(t, driver).Stores
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			for name, job := range map[string]scheduler.JobFunc{
+				"mark_overdue_loans":   scheduler.MarkOverdueLoans(stores),
+				"accrue_fines":         scheduler.AccrueFines(stores),
+				"expire_holds":         scheduler.ExpireHolds(stores),
+				"expire_memberships":   scheduler.ExpireMemberships(stores),
+				"purge_expired_tokens": scheduler.PurgeExpiredTokens(stores),
+				"purge_login_attempts": scheduler.PurgeLoginAttempts(stores, time.Hour),
+				"notify_due_soon":      scheduler.NotifyDueSoon(stores, 2),
+				"notify_overdue":       scheduler.NotifyOverdue(stores),
+			} {
+				if _, err := job(ctx, start); !errors.Is(err, context.Canceled) {
+					t.Errorf("%s in a cancelled run returned %v, want %v", name, err, context.Canceled)
+				}
+			}
+		})
+	}
+}
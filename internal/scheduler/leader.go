@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// lockKey identifies the scheduler's Postgres advisory lock. Every replica
+// uses the same key, so only one of them runs jobs at a time.
+const lockKey int64 = 0x6c696272617279
+
+// leaderLock holds a session-level advisory lock on a dedicated connection.
+// The lock is released when that connection closes, so a replica that dies
+// or loses its database connection hands leadership over automatically.
+type leaderLock struct {
+	db   *gorm.DB
+	conn *sql.Conn
+}
+
+// acquire reports whether this replica is the leader, taking the lock if it
+// is free. On SQLite, which has no advisory locks and serves a single
+// instance, that instance always leads.
+func (l *leaderLock) acquire(ctx context.Context) (bool, error) {
+	if l.db.Dialector.Name() != "postgres" {
+		return true, nil
+	}
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&locked); err != nil {
+		conn.Close()
+		return false, err
+	}
+	if !locked {
+		conn.Close()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+func (l *leaderLock) release() {
+	if l.conn == nil {
+		return
+	}
+	l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+	l.conn.Close()
+	l.conn = nil
+}
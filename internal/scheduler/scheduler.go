@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobFunc does one run of a job and returns a short summary of what it did.
+type JobFunc func(ctx context.Context, now time.Time) (string, error)
+
+type JobStatus struct {
+	Name          string     `json:"name"`
+	Running       bool       `json:"running"`
+	Runs          int        `json:"runs"`
+	Failures      int        `json:"failures"`
+	LastRunAt     *time.Time `json:"last_run_at"`
+	LastSuccessAt *time.Time `json:"last_success_at"`
+	LastDuration  string     `json:"last_duration,omitempty"`
+	LastResult    string     `json:"last_result,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+type Status struct {
+	Enabled   bool        `json:"enabled"`
+	Leader    bool        `json:"leader"`
+	Interval  string      `json:"interval"`
+	NextRunAt *time.Time  `json:"next_run_at"`
+	Jobs      []JobStatus `json:"jobs"`
+}
+
+type job struct {
+	fn     JobFunc
+	status JobStatus
+}
+
+// Scheduler runs its jobs one after another every interval. When several
+// replicas share a database, only the one holding the advisory lock runs
+// them.
+type Scheduler struct {
+	interval time.Duration
+	lock     *leaderLock
+
+	mu        sync.Mutex
+	jobs      []*job
+	enabled   bool
+	leader    bool
+	nextRunAt *time.Time
+}
+
+func New(db *gorm.DB, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		interval: interval,
+		lock:     &leaderLock{db: db},
+	}
+}
+
+// Register adds a job. Jobs run in the order they were registered.
+func (s *Scheduler) Register(name string, fn JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &job{fn: fn, status: JobStatus{Name: name}})
+}
+
+// Run runs the jobs right away and then every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	// The first run is due now, so a job stuck in it shows as stalled too.
+	now := time.Now()
+	s.mu.Lock()
+	s.enabled = true
+	s.nextRunAt = &now
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	defer s.lock.release()
+
+	for {
+		s.tick(ctx)
+
+		next := time.Now().Add(s.interval)
+		s.mu.Lock()
+		s.nextRunAt = &next
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	leader, err := s.lock.acquire(ctx)
+	if err != nil {
+		log.Println("scheduler: leader election failed:", err)
+	}
+
+	s.mu.Lock()
+	s.leader = leader
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	if !leader {
+		return
+	}
+	for _, j := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		s.runJob(ctx, j)
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, j *job) {
+	start := time.Now()
+	s.mu.Lock()
+	j.status.Running = true
+	j.status.LastRunAt = &start
+	s.mu.Unlock()
+
+	result, err := s.call(ctx, j.fn, start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j.status.Running = false
+	j.status.Runs++
+	j.status.LastDuration = time.Since(start).String()
+	j.status.LastResult = result
+	if err != nil {
+		j.status.Failures++
+		j.status.LastError = err.Error()
+		log.Printf("scheduler: job %s failed: %v", j.status.Name, err)
+		return
+	}
+	j.status.LastError = ""
+	j.status.LastSuccessAt = &start
+}
+
+// call runs a job, turning a panic into an error so one bad job cannot
+// take the scheduler down.
+func (s *Scheduler) call(ctx context.Context, fn JobFunc, now time.Time) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, now)
+}
+
+// Stalled reports whether the scheduler is running but is more than an
+// interval late for its next run, which means a job is stuck.
+func (s *Scheduler) Stalled(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enabled && s.nextRunAt != nil && now.Sub(*s.nextRunAt) > s.interval
+}
+
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{
+		Enabled:   s.enabled,
+		Leader:    s.leader,
+		Interval:  s.interval.String(),
+		NextRunAt: s.nextRunAt,
+		Jobs:      make([]JobStatus, 0, len(s.jobs)),
+	}
+	for _, j := range s.jobs {
+		status.Jobs = append(status.Jobs, j.status)
+	}
+	return status
+}
@@ -0,0 +1,182 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/scheduler"
+)
+
+const interval = 20 * time.Millisecond
+
+// runScheduler runs s until the test ends or the returned stop is called, and
+// waits for it to return on stop.
+func runScheduler(t *testing.T, s *scheduler.Scheduler) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	var done sync.WaitGroup
+	done.Add(1)
+	go func() {
+		defer done.Done()
+		s.Run(ctx)
+	}()
+	stop = func() {
+		cancel()
+		done.Wait()
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+// eventually fails t unless cond holds within a few seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting until %s", what)
+}
+
+func jobStatus(s *scheduler.Scheduler, name string) scheduler.JobStatus {
+	for _, job := range s.Status().Jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return scheduler.JobStatus{}
+}
+
+// TestLeaderElection starts two schedulers on one database. On Postgres
+// only one runs the jobs until it stops and the other takes over; SQLite
+// serves a single instance, which always leads.
+func TestLeaderElection(t *testing.T) {
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			db := e2e.NewTestDatabase(t, driver).DB
+			var runs [2]atomic.Int64
+			schedulers := make([]*scheduler.Scheduler, 2)
+			for i := range schedulers {
+				i := i
+				schedulers[i] = scheduler.New(db, interval)
+				schedulers[i].Register("count", func(ctx context.Context, now time.Time) (string, error) {
+					runs[i].Add(1)
+					return "", nil
+				})
+			}
+
+			stopFirst := runScheduler(t, schedulers[0])
+			eventually(t, "the first scheduler runs its job", func() bool { return runs[0].Load() > 0 })
+			runScheduler(t, schedulers[1])
+			if driver == config.DriverSQLite {
+				eventually(t, "the second scheduler runs its job", func() bool { return runs[1].Load() > 0 })
+				if !schedulers[1].Status().Leader {
+					t.Error("second scheduler on SQLite does not report itself leader")
+				}
+				return
+			}
+
+			time.Sleep(5 * interval)
+			if runs[1].Load() != 0 || schedulers[1].Status().Leader {
+				t.Fatalf("second scheduler ran %d times while the first held the lock", runs[1].Load())
+			}
+			if !schedulers[0].Status().Leader {
+				t.Error("first scheduler does not report itself leader")
+			}
+			stopFirst()
+			eventually(t, "the second scheduler takes over", func() bool { return runs[1].Load() > 0 })
+			if !schedulers[1].Status().Leader {
+				t.Error("second scheduler took over but does not report itself leader")
+			}
+		})
+	}
+}
+
+// TestJobResults checks what the scheduler records of a job that
+// succeeds, one that fails once and then succeeds, and one that panics
+// without taking the others down.
+func TestJobResults(t *testing.T) {
+	// Long enough between runs to look at the first before the second.
+	s := scheduler.New(e2e.NewTestDatabase(t, config.DriverSQLite).DB, 5*interval)
+	s.Register("ok", func(ctx context.Context, now time.Time) (string, error) {
+		return "did it", nil
+	})
+	var flakyRuns atomic.Int64
+	s.Register("flaky", func(ctx context.Context, now time.Time) (string, error) {
+		if flakyRuns.Add(1) == 1 {
+			return "half done", errors.New("database went away")
+		}
+		return "done", nil
+	})
+	s.Register("panics", func(ctx context.Context, now time.Time) (string, error) {
+		panic("nil map")
+	})
+
+	stop := runScheduler(t, s)
+	eventually(t, "the first run has finished", func() bool { return jobStatus(s, "panics").Runs >= 1 })
+
+	flaky := jobStatus(s, "flaky")
+	if flaky.Failures != 1 || flaky.LastError != "database went away" || flaky.LastResult != "half done" || flaky.LastSuccessAt != nil {
+		t.Errorf("after failing once the flaky job has status %+v", flaky)
+	}
+	eventually(t, "the second run has finished", func() bool { return jobStatus(s, "panics").Runs >= 2 })
+	stop()
+
+	status := s.Status()
+	if !status.Enabled || !status.Leader || len(status.Jobs) != 3 {
+		t.Fatalf("scheduler status is %+v", status)
+	}
+	ok, flaky, panics := status.Jobs[0], status.Jobs[1], status.Jobs[2]
+	if ok.Failures != 0 || ok.LastResult != "did it" || ok.LastSuccessAt == nil || ok.Running {
+		t.Errorf("ok job has status %+v", ok)
+	}
+	if flaky.Failures != 1 || flaky.LastError != "" || flaky.LastResult != "done" || flaky.LastSuccessAt == nil {
+		t.Errorf("after succeeding the flaky job has status %+v", flaky)
+	}
+	if panics.Failures != panics.Runs || panics.LastError != "panic: nil map" || panics.LastSuccessAt != nil {
+		t.Errorf("panicking job has status %+v", panics)
+	}
+}
+
+// TestStalled blocks a job and checks the scheduler reports itself stalled
+// once it is more than an interval late, and recovers when the job ends.
+func TestStalled(t *testing.T) {
+	s := scheduler.New(e2e.NewTestDatabase(t, config.DriverSQLite).DB, interval)
+	if s.Stalled(time.Now().Add(time.Hour)) {
+		t.Error("scheduler that was never started is stalled")
+	}
+
+	var blocks atomic.Int64
+	release := make(chan struct{})
+	s.Register("blocks", func(ctx context.Context, now time.Time) (string, error) {
+		if blocks.Add(1) == 1 {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+		}
+		return "", nil
+	})
+
+	runScheduler(t, s)
+	eventually(t, "the stuck first run is noticed", func() bool { return s.Stalled(time.Now()) })
+	if !jobStatus(s, "blocks").Running {
+		t.Error("stuck job is not running")
+	}
+	close(release)
+	eventually(t, "the scheduler catches up", func() bool { return blocks.Load() > 1 && !s.Stalled(time.Now()) })
+	if s.Stalled(time.Now().Add(interval)) {
+		t.Error("scheduler a little late for its next run is stalled")
+	}
+	if !s.Stalled(time.Now().Add(3 * interval)) {
+		t.Error("scheduler two intervals late is not stalled")
+	}
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"library-management-system/internal/clock"
+	"library-management-system/internal/config"
+	"library-management-system/internal/handlers"
+	"library-management-system/internal/health"
+	"library-management-system/internal/loginguard"
+	"library-management-system/internal/metrics"
+	"library-management-system/internal/middleware"
+	"library-management-system/internal/notification"
+	"library-management-system/internal/repository"
+	"library-management-system/internal/scheduler"
+	"library-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Dependencies are what the handlers are built from. cmd/main.go wires
+// them to the real database and clock; the end-to-end suite wires them to
+// a throwaway database and a fake clock.
+type Dependencies struct {
+	Config  *config.Config
+	Stores  *repository.Stores
+	Guard   *loginguard.Guard
+	Sender  notification.Sender
+	Jobs    *scheduler.Scheduler
+	Health  *health.Checker
+	Metrics *metrics.Metrics
+	Clock   clock.Clock
+}
+
+// NewRouter builds every handler once and registers the API routes.
+func NewRouter(deps Dependencies) (*gin.Engine, error) {
+	auth := deps.Config.Auth
+	issuer := utils.NewTokenIssuer(auth.JWTSecret, auth.AccessTokenTTL, auth.RefreshTokenTTL)
+	totpSecrets, err := utils.NewSecretBox(auth.MFAEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	authHandler := handlers.NewAuthHandler(deps.Stores, auth, issuer, deps.Guard, deps.Sender)
+	mfaHandler := handlers.NewMFAHandler(deps.Stores, auth, issuer, totpSecrets, deps.Guard)
+	userHandler := handlers.NewUserHandler(deps.Stores, auth, deps.Guard)
+	bookHandler := handlers.NewBookHandler(deps.Stores)
+	copyHandler := handlers.NewBookCopyHandler(deps.Stores, deps.Clock)
+	memberHandler := handlers.NewMemberHandler(deps.Stores)
+	loanHandler := handlers.NewLoanHandler(deps.Stores, deps.Config.Circulation, deps.Clock)
+	holdHandler := handlers.NewHoldHandler(deps.Stores, deps.Clock)
+	fineHandler := handlers.NewFineHandler(deps.Stores)
+	policyHandler := handlers.NewCirculationPolicyHandler(deps.Stores)
+	notificationHandler := handlers.NewNotificationHandler(deps.Stores)
+	auditHandler := handlers.NewAuditHandler(deps.Stores)
+	jobHandler := handlers.NewJobHandler(deps.Jobs)
+	healthHandler := handlers.NewHealthHandler(deps.Health)
+
+	r := gin.Default()
+	// c.ClientIP feeds login throttling and the audit log, so it may only
+	// come from a forwarding header when a trusted proxy set it. nil
+	// trusts none.
+	if err := r.SetTrustedProxies(deps.Config.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
+	r.Use(deps.Metrics.Middleware())
+	r.Use(middleware.CORS())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.BodyLimit(deps.Config.Server.MaxBodyBytes))
+
+	api := r.Group("/api")
+	{
+		auth := api.Group("/auth")
+		{
+			auth.POST("/register", authHandler.Register)
+			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/2fa/verify", mfaHandler.VerifyMFA)
+		}
+
+		enrollment := api.Group("/auth/2fa")
+		enrollment.Use(middleware.MFAEnrollmentMiddleware(issuer, deps.Stores.Users, deps.Stores.Tokens))
+		{
+			enrollment.POST("/enroll", mfaHandler.EnrollMFA)
+			enrollment.POST("/confirm", mfaHandler.ConfirmMFA)
+		}
+
+		protected := api.Group("/")
+		protected.Use(middleware.AuthMiddleware(issuer, deps.Stores.Users, deps.Stores.Tokens))
+		{
+			protected.POST("/auth/logout", authHandler.Logout)
+			protected.POST("/auth/logout-all", authHandler.LogoutAll)
+			protected.POST("/auth/change-password", authHandler.ChangePassword)
+			protected.POST("/auth/2fa/disable", mfaHandler.DisableMFA)
+			protected.POST("/auth/2fa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+
+			books := protected.Group("/books")
+			{
+				books.GET("/", middleware.RequirePermission(middleware.PermBooksRead), bookHandler.GetAllBooks)
+				books.GET("/search", middleware.RequirePermission(middleware.PermBooksRead), bookHandler.SearchBooks)
+				books.GET("/:id", middleware.RequirePermission(middleware.PermBooksRead), bookHandler.GetBookByID)
+				books.POST("/", middleware.RequirePermission(middleware.PermBooksWrite), bookHandler.CreateBook)
+				books.PUT("/:id", middleware.RequirePermission(middleware.PermBooksWrite), bookHandler.UpdateBook)
+				books.DELETE("/:id", middleware.RequirePermission(middleware.PermBooksDelete), bookHandler.DeleteBook)
+				books.GET("/:id/copies", middleware.RequirePermission(middleware.PermBooksRead), copyHandler.GetBookCopies)
+				books.POST("/:id/copies", middleware.RequirePermission(middleware.PermBooksWrite), copyHandler.CreateBookCopy)
+				books.GET("/:id/holds", middleware.RequirePermission(middleware.PermLoansRead), holdHandler.GetBookHolds)
+			}
+
+			copies := protected.Group("/copies")
+			{
+				copies.GET("/:id", middleware.RequirePermission(middleware.PermBooksRead), copyHandler.GetBookCopyByID)
+				copies.GET("/barcode/:barcode", middleware.RequirePermission(middleware.PermBooksRead), copyHandler.GetBookCopyByBarcode)
+				copies.PUT("/:id", middleware.RequirePermission(middleware.PermBooksWrite), copyHandler.UpdateBookCopy)
+			}
+
+			members := protected.Group("/members")
+			{
+				members.GET("/", middleware.RequirePermission(middleware.PermMembersRead), memberHandler.GetAllMembers)
+				members.GET("/:id", middleware.RequirePermission(middleware.PermMembersRead), memberHandler.GetMemberByID)
+				members.POST("/", middleware.RequirePermission(middleware.PermMembersWrite), memberHandler.CreateMember)
+				members.PUT("/:id", middleware.RequirePermission(middleware.PermMembersWrite), memberHandler.UpdateMember)
+				members.DELETE("/:id", middleware.RequirePermission(middleware.PermMembersDelete), memberHandler.DeleteMember)
+				members.GET("/:id/holds", middleware.RequirePermission(middleware.PermLoansRead), holdHandler.GetMemberHolds)
+				members.GET("/:id/balance", middleware.RequirePermission(middleware.PermFinesRead), fineHandler.GetMemberFineBalance)
+			}
+
+			loans := protected.Group("/loans")
+			{
+				loans.GET("/", middleware.RequirePermission(middleware.PermLoansRead), loanHandler.GetAllLoans)
+				loans.GET("/:id", middleware.RequirePermission(middleware.PermLoansRead), loanHandler.GetLoanByID)
+				loans.POST("/", middleware.RequirePermission(middleware.PermLoansWrite), loanHandler.CreateLoan)
+				loans.PUT("/:id/return", middleware.RequirePermission(middleware.PermLoansWrite), loanHandler.ReturnBook)
+				loans.PUT("/:id/renew", middleware.RequirePermission(middleware.PermLoansWrite), loanHandler.RenewLoan)
+			}
+
+			me := protected.Group("/me")
+			{
+				me.GET("/holds", middleware.RequirePermission(middleware.PermHoldsOwn), holdHandler.GetOwnHolds)
+				me.POST("/holds", middleware.RequirePermission(middleware.PermHoldsOwn), holdHandler.CreateOwnHold)
+				me.PUT("/holds/:id/cancel", middleware.RequirePermission(middleware.PermHoldsOwn), holdHandler.CancelOwnHold)
+			}
+
+			holds := protected.Group("/holds")
+			{
+				holds.POST("/", middleware.RequirePermission(middleware.PermLoansWrite), holdHandler.CreateHold)
+				holds.POST("/expire", middleware.RequirePermission(middleware.PermLoansWrite), holdHandler.ExpireHolds)
+				holds.GET("/:id", middleware.RequirePermission(middleware.PermLoansRead), holdHandler.GetHoldByID)
+				holds.PUT("/:id/cancel", middleware.RequirePermission(middleware.PermLoansWrite), holdHandler.CancelHold)
+			}
+
+			fines := protected.Group("/fines")
+			{
+				fines.GET("/", middleware.RequirePermission(middleware.PermFinesRead), fineHandler.GetAllFines)
+				fines.GET("/receipts/:number", middleware.RequirePermission(middleware.PermFinesRead), fineHandler.GetFineReceipt)
+				fines.GET("/:id", middleware.RequirePermission(middleware.PermFinesRead), fineHandler.GetFineByID)
+				fines.POST("/charges", middleware.RequirePermission(middleware.PermFinesWrite), fineHandler.CreateFineCharge)
+				fines.POST("/payments", middleware.RequirePermission(middleware.PermFinesWrite), fineHandler.RecordFinePayment)
+				fines.POST("/waivers", middleware.RequirePermission(middleware.PermFinesWaive), fineHandler.WaiveFine)
+			}
+
+			policies := protected.Group("/policies")
+			{
+				policies.GET("/", middleware.RequirePermission(middleware.PermLoansRead), policyHandler.GetAllCirculationPolicies)
+				policies.GET("/resolve", middleware.RequirePermission(middleware.PermLoansRead), policyHandler.ResolveCirculationPolicy)
+				policies.GET("/:id", middleware.RequirePermission(middleware.PermLoansRead), policyHandler.GetCirculationPolicyByID)
+				policies.POST("/", middleware.RequirePermission(middleware.PermPoliciesManage), policyHandler.CreateCirculationPolicy)
+				policies.PUT("/:id", middleware.RequirePermission(middleware.PermPoliciesManage), policyHandler.UpdateCirculationPolicy)
+				policies.DELETE("/:id", middleware.RequirePermission(middleware.PermPoliciesManage), policyHandler.DeleteCirculationPolicy)
+			}
+
+			protected.GET("/jobs", middleware.RequirePermission(middleware.PermJobsRead), jobHandler.GetJobStatuses)
+			protected.GET("/health", middleware.RequirePermission(middleware.PermJobsRead), healthHandler.GetHealthDetails)
+			protected.GET("/notifications", middleware.RequirePermission(middleware.PermJobsRead), notificationHandler.GetAllNotifications)
+			protected.GET("/audit-events", middleware.RequirePermission(middleware.PermAuditRead), auditHandler.GetAuditEvents)
+			protected.GET("/audit-events/:id", middleware.RequirePermission(middleware.PermAuditRead), auditHandler.GetAuditEventByID)
+
+			users := protected.Group("/users")
+			users.Use(middleware.RequirePermission(middleware.PermUsersManage))
+			{
+				users.GET("/", userHandler.GetAllUsers)
+				users.POST("/", userHandler.CreateUser)
+				users.PUT("/:id/role", userHandler.ChangeUserRole)
+				users.PUT("/:id/deactivate", userHandler.DeactivateUser)
+				users.PUT("/:id/member", userHandler.LinkMember)
+				users.POST("/:id/logout", userHandler.LogoutUser)
+				users.POST("/:id/unlock", userHandler.UnlockUser)
+				users.POST("/:id/2fa/reset", mfaHandler.ResetUserMFA)
+				users.POST("/unlock-ip", userHandler.UnlockIP)
+				users.GET("/:id/role-changes", userHandler.GetUserRoleChanges)
+			}
+		}
+	}
+
+	r.GET("/livez", healthHandler.Livez)
+	r.GET("/readyz", healthHandler.Readyz)
+	// /health predates the probes and stays for existing monitors.
+	r.GET("/health", healthHandler.Livez)
+	if token := deps.Config.Server.MetricsToken; token != "" {
+		r.GET("/metrics", middleware.StaticToken(token), gin.WrapH(deps.Metrics.Handler()))
+	}
+
+	return r, nil
+}
@@ -0,0 +1,159 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"library-management-system/internal/config"
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/server"
+)
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// serve runs Serve with handler on a free port until the returned cancel
+// is called. Serve's result arrives on the channel.
+func serve(t *testing.T, shutdownTimeout time.Duration, handler http.Handler) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+	cfg := config.Default().Server
+	cfg.Port = freePort(t)
+	cfg.ShutdownTimeout = shutdownTimeout
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.Serve(ctx, cfg, handler)
+	}()
+
+	url := "http://localhost:" + cfg.Port
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		resp, err := http.Get(url + "/ping")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server never came up: %v", err)
+		}
+	}
+	return url, cancel, errc
+}
+
+// slowHandler answers /slow once release is closed, and /ping at once.
+func slowHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "finished")
+	})
+	return mux
+}
+
+type result struct {
+	body string
+	err  error
+}
+
+func get(url string) <-chan result {
+	done := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		done <- result{body: string(body), err: err}
+	}()
+	return done
+}
+
+// TestGracefulShutdown stops the server while a request is in flight. It
+// stops taking connections at once but lets the request finish.
+func TestGracefulShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	url, cancel, errc := serve(t, 5*time.Second, slowHandler(started, release))
+
+	slow := get(url + "/slow")
+	<-started
+	cancel()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("server still takes connections after shutdown began")
+		}
+	}
+
+	select {
+	case err := <-errc:
+		t.Fatalf("Serve returned %v with a request in flight", err)
+	default:
+	}
+	close(release)
+	if r := <-slow; r.err != nil || r.body != "finished" {
+		t.Errorf("request in flight got %q, %v", r.body, r.err)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Serve returned %v after a clean shutdown", err)
+	}
+}
+
+// TestShutdownTimeout keeps a request running past ShutdownTimeout: Serve
+// gives up on it, closes its connection and reports the timeout.
+func TestShutdownTimeout(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	url, cancel, errc := serve(t, 50*time.Millisecond, slowHandler(started, release))
+
+	slow := get(url + "/slow")
+	<-started
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Serve returned %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not give up on the request in flight")
+	}
+	if r := <-slow; r.err == nil {
+		t.Errorf("request cut off by the shutdown got %q", r.body)
+	}
+}
+
+// TestRouterBodyLimit sends the real router a login larger than
+// SERVER_MAX_BODY_BYTES.
+func TestRouterBodyLimit(t *testing.T) {
+	h := e2e.NewTestHarness(t, config.DriverSQLite)
+	body := `{"username":"` + strings.Repeat("a", int(h.Config.Server.MaxBodyBytes)) + `","password":"x"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized login: status %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -1,40 +1,87 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token purposes. Access tokens have none; the others only get a user
+// through the second step of a login and are refused everywhere else.
+const (
+	TokenPurposeMFA       = "mfa"
+	TokenPurposeMFAEnroll = "mfa_enroll"
+)
+
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	Purpose  string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, username, role string) (string, error) {
-	claims := Claims{
+// TokenIssuer signs and checks the tokens of one deployment, and knows how
+// long the sessions it starts last.
+type TokenIssuer struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenIssuer(secret string, accessTTL, refreshTTL time.Duration) *TokenIssuer {
+	return &TokenIssuer{secret: []byte(secret), accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+// RefreshTTL is how long a session lasts without being refreshed.
+func (i *TokenIssuer) RefreshTTL() time.Duration {
+	return i.refreshTTL
+}
+
+// GenerateToken issues a short-lived access token. Its ID (jti) is what
+// logout puts on the revocation list.
+func (i *TokenIssuer) GenerateToken(userID uint, username, role string) (string, *Claims, error) {
+	return i.GeneratePurposeToken(userID, username, role, "", i.accessTTL)
+}
+
+// GeneratePurposeToken issues a token for one step of a login, such as
+// the "mfa pending" token handed out between password and code.
+func (i *TokenIssuer) GeneratePurposeToken(userID uint, username, role, purpose string, ttl time.Duration) (string, *Claims, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
+		Purpose:  purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	signed, err := token.SignedString(i.secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
-func ValidateToken(tokenString string) (*Claims, error) {
+func (i *TokenIssuer) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+		return i.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -46,3 +93,19 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// RandomToken returns n random bytes, hex encoded.
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashToken is how opaque tokens such as refresh tokens are stored, so a
+// database leak does not hand out live sessions.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
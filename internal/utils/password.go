@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxPasswordBytes is where bcrypt stops reading; anything longer would be
+// silently truncated.
+const maxPasswordBytes = 72
+
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "passw0rd": true,
+	"123456789": true, "1234567890": true, "12345678": true, "qwerty123": true,
+	"qwertyuiop": true, "iloveyou": true, "admin123": true, "welcome1": true,
+	"letmein123": true, "perpustakaan": true, "perpustakaan1": true, "library123": true,
+}
+
+// ValidatePassword checks a new password against the strength policy: at
+// least minLength characters, letters and digits, not a well-known
+// password, and not built from the account's own details such as username
+// or email.
+func ValidatePassword(password string, minLength int, personal ...string) error {
+	if len([]rune(password)) < minLength {
+		return fmt.Errorf("password must be at least %d characters", minLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordBytes)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("password must contain both letters and digits")
+	}
+
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return errors.New("password is too common")
+	}
+	for _, value := range personal {
+		value = strings.ToLower(value)
+		if at := strings.Index(value, "@"); at >= 0 {
+			value = value[:at]
+		}
+		if len(value) >= 3 && strings.Contains(lower, value) {
+			return errors.New("password must not contain your username or email")
+		}
+	}
+	return nil
+}
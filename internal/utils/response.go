@@ -10,9 +10,30 @@ type Response struct {
 	Status  string      `json:"status"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	Meta    *Pagination `json:"meta,omitempty"`
 	Error   string      `json:"error,omitempty"`
 }
 
+type Pagination struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+func NewPagination(page, perPage int, total int64) *Pagination {
+	totalPages := 0
+	if perPage > 0 {
+		totalPages = int((total + int64(perPage) - 1) / int64(perPage))
+	}
+	return &Pagination{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
+
 func SuccessResponse(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusOK, Response{
 		Status:  "success",
@@ -21,6 +42,15 @@ func SuccessResponse(c *gin.Context, message string, data interface{}) {
 	})
 }
 
+func PaginatedResponse(c *gin.Context, message string, data interface{}, meta *Pagination) {
+	c.JSON(http.StatusOK, Response{
+		Status:  "success",
+		Message: message,
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
 func ErrorResponse(c *gin.Context, statusCode int, message string) {
 	c.JSON(statusCode, Response{
 		Status:  "error",
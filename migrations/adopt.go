@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// baselineTable is a table as AutoMigrate built it before versioned
+// migrations took over, with the columns it had then.
+type baselineTable struct {
+	name    string
+	columns []string
+}
+
+// baselineTables are in the order their rows must be copied: parents
+// before the loans that reference them.
+var baselineTables = []baselineTable{
+	{"users", []string{"id", "username", "email", "password", "role", "created_at", "updated_at", "deleted_at"}},
+	{"books", []string{"id", "title", "author", "isbn", "publisher", "year", "category", "description", "stock", "available", "created_at", "updated_at", "deleted_at"}},
+	{"members", []string{"id", "name", "email", "phone", "address", "member_code", "status", "created_at", "updated_at", "deleted_at"}},
+	{"loans", []string{"id", "book_id", "member_id", "loan_date", "due_date", "return_date", "status", "fine", "notes", "created_at", "updated_at", "deleted_at"}},
+}
+
+// baselineExpr converts a baseline column on the way into its new table.
+// Fines were fractional under AutoMigrate; they are whole rupiah now.
+var baselineExpr = map[string]string{
+	"loans.fine": "CAST(ROUND(fine) AS INTEGER)",
+}
+
+// setAsideBaseline prepares a SQLite database that AutoMigrate built for
+// the first migration. SQLite cannot add a column only when it is missing
+// or change a column's type, so rather than altering the old tables it
+// renames them out of the way, for the migration to create them afresh and
+// copyBaseline to fill them. Their indexes are dropped, as they would keep
+// the migration from creating its own under the same names. It reports
+// whether there was anything to set aside.
+func setAsideBaseline(tx *gorm.DB) (bool, error) {
+	if !tx.Migrator().HasTable("users") {
+		return false, nil
+	}
+	for _, table := range baselineTables {
+		if !tx.Migrator().HasTable(table.name) {
+			return false, fmt.Errorf("database has a users table but no %s table; it was not built by AutoMigrate", table.name)
+		}
+		var indexes []string
+		err := tx.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", table.name).
+			Scan(&indexes).Error
+		if err != nil {
+			return false, err
+		}
+		for _, index := range indexes {
+			if err := tx.Exec(`DROP INDEX "` + index + `"`).Error; err != nil {
+				return false, err
+			}
+		}
+		if err := tx.Exec(`ALTER TABLE "` + table.name + `" RENAME TO "` + baselineName(table.name) + `"`).Error; err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// copyBaseline moves the rows of the tables setAsideBaseline renamed into
+// the ones the first migration created, then drops the old tables.
+func copyBaseline(tx *gorm.DB) error {
+	for _, table := range baselineTables {
+		exprs := make([]string, len(table.columns))
+		for i, column := range table.columns {
+			exprs[i] = column
+			if expr, ok := baselineExpr[table.name+"."+column]; ok {
+				exprs[i] = expr
+			}
+		}
+		insert := fmt.Sprintf(`INSERT INTO "%s" (%s) SELECT %s FROM "%s"`,
+			table.name, strings.Join(table.columns, ", "), strings.Join(exprs, ", "), baselineName(table.name))
+		if err := tx.Exec(insert).Error; err != nil {
+			return fmt.Errorf("failed to copy %s: %w", table.name, err)
+		}
+	}
+	for i := len(baselineTables) - 1; i >= 0; i-- {
+		if err := tx.Exec(`DROP TABLE "` + baselineName(baselineTables[i].name) + `"`).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func baselineName(table string) string {
+	return "baseline_" + table
+}
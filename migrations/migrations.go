@@ -0,0 +1,441 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dir is where the migration files live in the source tree, in one
+// directory per dialect. `migrate create` adds new ones there; they are
+// embedded at build time.
+const Dir = "migrations"
+
+// Dialects are the databases with migrations, named as gorm names their
+// dialectors. Each has its own directory under Dir, and every migration
+// exists in all of them under the same version.
+var Dialects = []string{"postgres", "sqlite"}
+
+//go:embed postgres/*.sql sqlite/*.sql seed/*.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock that keeps two servers or `migrate`
+// runs from applying the same migration at once on Postgres. SQLite needs
+// no lock; it lets only one transaction write at a time.
+const lockKey = 4721500318
+
+var (
+	fileName      = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// Migration is one versioned schema change. Up applies it and Down
+// reverts it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status is a migration and when it was applied. AppliedAt is nil for a
+// pending migration. Unknown marks a version recorded in the database
+// that this build has no file for, e.g. after deploying an older release.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	Unknown   bool
+}
+
+// schemaMigration is a row of schema_migrations, one per applied version.
+type schemaMigration struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies and reverts the embedded migrations.
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New loads the migrations for the dialect of db.
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	if !isDialect(dialect) {
+		return nil, fmt.Errorf("there are no migrations for %s databases", dialect)
+	}
+	migrations, err := load(files, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func isDialect(name string) bool {
+	for _, dialect := range Dialects {
+		if dialect == name {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the migrations in dir, which must come in up/down pairs.
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_name.up.sql", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		seen[fmt.Sprintf("%d.%s", version, match[3])] = true
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		for _, direction := range []string{"up", "down"} {
+			if !seen[fmt.Sprintf("%d.%s", migration.Version, direction)] {
+				return nil, fmt.Errorf("migration %s has no %s file", migration, direction)
+			}
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	timeType := "TIMESTAMPTZ"
+	if m.dialect == "sqlite" {
+		timeType = "DATETIME"
+	}
+	return m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at ` + timeType + ` NOT NULL
+	)`).Error
+}
+
+// applied returns the versions recorded in the database. A database that
+// was never migrated has none.
+func (m *Migrator) applied() (map[int64]schemaMigration, error) {
+	applied := map[int64]schemaMigration{}
+	if !m.db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+	var rows []schemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status lists every migration, known or only recorded, by version.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, Status{
+			Migration: Migration{Version: row.Version, Name: row.Name},
+			AppliedAt: &appliedAt,
+			Unknown:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Pending returns the migrations not yet applied, oldest first.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Version returns the newest applied version, or 0 for a database that was
+// never migrated.
+func (m *Migrator) Version() (int64, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Latest returns the newest version this build knows, the one Up brings a
+// database to.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// WithContext returns a Migrator whose queries give up when ctx is done.
+func (m *Migrator) WithContext(ctx context.Context) *Migrator {
+	clone := *m
+	clone.db = m.db.WithContext(ctx)
+	return &clone
+}
+
+// Up applies up to limit pending migrations, all of them when limit is 0,
+// and returns those it applied.
+func (m *Migrator) Up(limit int) ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && limit < len(pending) {
+		pending = pending[:limit]
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		ran, err := m.run(migration, true)
+		if err != nil {
+			return done, fmt.Errorf("migration %s: %w", migration, err)
+		}
+		if ran {
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// Down reverts the newest steps applied migrations, newest first, and
+// returns those it reverted.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		status := statuses[i]
+		if status.AppliedAt == nil {
+			continue
+		}
+		if status.Unknown {
+			return done, fmt.Errorf("migration %s is not known to this build and cannot be reverted", status.Migration)
+		}
+		ran, err := m.run(status.Migration, false)
+		if err != nil {
+			return done, fmt.Errorf("migration %s: %w", status.Migration, err)
+		}
+		if ran {
+			done = append(done, status.Migration)
+		}
+	}
+	return done, nil
+}
+
+// run applies or reverts one migration in a transaction. It reports false
+// when another runner got there first.
+func (m *Migrator) run(migration Migration, up bool) (bool, error) {
+	ran := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if m.dialect == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+		}
+		var count int64
+		if err := tx.Model(&schemaMigration{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if (count > 0) == up {
+			return nil
+		}
+
+		// The Postgres schema adopts a database that AutoMigrate built in
+		// SQL; the SQLite one needs its tables set aside first.
+		adopting := false
+		if up && migration.Version == 1 && m.dialect == "sqlite" {
+			var err error
+			if adopting, err = setAsideBaseline(tx); err != nil {
+				return err
+			}
+		}
+
+		script := migration.Down
+		if up {
+			script = migration.Up
+		}
+		if !isBlank(script) {
+			if err := tx.Exec(script).Error; err != nil {
+				return err
+			}
+		}
+		if adopting {
+			if err := copyBaseline(tx); err != nil {
+				return err
+			}
+		}
+
+		if up {
+			err := tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+			if err != nil {
+				return err
+			}
+		} else if err := tx.Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error; err != nil {
+			return err
+		}
+		ran = true
+		return nil
+	})
+	return ran, err
+}
+
+// Seed loads the sample data for development from seed/<dialect>.sql in
+// one transaction. Rows that already exist are skipped, so it can be run
+// again. The schema must be up to date.
+func (m *Migrator) Seed() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database has %d pending migrations; run `migrate up` first", len(pending))
+	}
+	script, err := fs.ReadFile(files, path.Join("seed", m.dialect+".sql"))
+	if err != nil {
+		return err
+	}
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Exec(string(script)).Error
+	})
+}
+
+// isBlank reports whether a script holds nothing but comments, as the down
+// files of data backfills do.
+func isBlank(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// Create adds an empty up/down pair to the directory of every dialect
+// under dir, numbered after the newest migration there, and returns the
+// paths of the files.
+func Create(dir, name string) ([]string, error) {
+	name = strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(strings.TrimSpace(name)))
+	if !migrationName.MatchString(name) {
+		return nil, fmt.Errorf("migration name %q may only hold letters, digits and underscores", name)
+	}
+
+	var version int64 = 1
+	for _, dialect := range Dialects {
+		migrations, err := load(os.DirFS(filepath.Join(dir, dialect)), ".")
+		if err != nil {
+			return nil, err
+		}
+		if n := len(migrations); n > 0 && migrations[n-1].Version >= version {
+			version = migrations[n-1].Version + 1
+		}
+	}
+
+	var created []string
+	for _, dialect := range Dialects {
+		base := filepath.Join(dir, dialect, Migration{Version: version, Name: name}.String())
+		for _, file := range []string{base + ".up.sql", base + ".down.sql"} {
+			if err := os.WriteFile(file, nil, 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, file)
+		}
+	}
+	return created, nil
+}
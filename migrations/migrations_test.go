@@ -0,0 +1,157 @@
+package migrations_test
+
+import (
+	"testing"
+	"time"
+
+	"library-management-system/internal/e2e"
+	"library-management-system/internal/models"
+	"library-management-system/migrations"
+
+	"gorm.io/gorm"
+)
+
+// The models as they were when AutoMigrate built the schema, before
+// versioned migrations took over.
+type (
+	baselineUser struct {
+		ID        uint   `gorm:"primaryKey"`
+		Username  string `gorm:"unique;not null"`
+		Email     string `gorm:"unique;not null"`
+		Password  string `gorm:"not null"`
+		Role      string `gorm:"default:'user'"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+	}
+	baselineBook struct {
+		ID          uint   `gorm:"primaryKey"`
+		Title       string `gorm:"not null"`
+		Author      string `gorm:"not null"`
+		ISBN        string `gorm:"unique;not null"`
+		Publisher   string
+		Year        int
+		Category    string
+		Description string
+		Stock       int `gorm:"default:0"`
+		Available   int `gorm:"default:0"`
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+		DeletedAt   gorm.DeletedAt `gorm:"index"`
+		Loans       []baselineLoan `gorm:"foreignKey:BookID"`
+	}
+	baselineMember struct {
+		ID         uint   `gorm:"primaryKey"`
+		Name       string `gorm:"not null"`
+		Email      string `gorm:"unique;not null"`
+		Phone      string
+		Address    string
+		MemberCode string `gorm:"unique;not null"`
+		Status     string `gorm:"default:'active'"`
+		CreatedAt  time.Time
+		UpdatedAt  time.Time
+		DeletedAt  gorm.DeletedAt `gorm:"index"`
+		Loans      []baselineLoan `gorm:"foreignKey:MemberID"`
+	}
+	baselineLoan struct {
+		ID         uint      `gorm:"primaryKey"`
+		BookID     uint      `gorm:"not null"`
+		MemberID   uint      `gorm:"not null"`
+		LoanDate   time.Time `gorm:"not null"`
+		DueDate    time.Time `gorm:"not null"`
+		ReturnDate *time.Time
+		Status     string  `gorm:"default:'borrowed'"`
+		Fine       float64 `gorm:"default:0"`
+		Notes      string
+		CreatedAt  time.Time
+		UpdatedAt  time.Time
+		DeletedAt  gorm.DeletedAt `gorm:"index"`
+	}
+)
+
+func (baselineUser) TableName() string   { return "users" }
+func (baselineBook) TableName() string   { return "books" }
+func (baselineMember) TableName() string { return "members" }
+func (baselineLoan) TableName() string   { return "loans" }
+
+// TestUpAdoptsAutoMigrateSchema migrates a database that AutoMigrate built
+// from the baseline models and checks that its rows come through with the
+// columns added since.
+func TestUpAdoptsAutoMigrateSchema(t *testing.T) {
+	for _, driver := range e2e.Drivers {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			db := e2e.NewTestDatabase(t, driver).DB
+			if err := db.AutoMigrate(&baselineUser{}, &baselineBook{}, &baselineMember{}, &baselineLoan{}); err != nil {
+				t.Fatalf("failed to build the baseline schema: %v", err)
+			}
+
+			loanDate := time.Date(2023, time.May, 2, 10, 0, 0, 0, time.UTC)
+			returned := loanDate.AddDate(0, 0, 17)
+			book := baselineBook{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", ISBN: "978-979-97312-3-4", Stock: 2, Available: 2}
+			member := baselineMember{Name: "Budi Santoso", Email: "budi@example.com", MemberCode: "MBR-0001"}
+			for _, row := range []interface{}{
+				&baselineUser{Username: "pustakawan", Email: "pustakawan@example.com", Password: "hash", Role: "user"},
+				&book,
+				&member,
+			} {
+				if err := db.Create(row).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			loan := baselineLoan{
+				BookID: book.ID, MemberID: member.ID, LoanDate: loanDate, DueDate: loanDate.AddDate(0, 0, 14),
+				ReturnDate: &returned, Status: "returned", Fine: 1500.4,
+			}
+			if err := db.Create(&loan).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			migrator, err := migrations.New(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := migrator.Up(0); err != nil {
+				t.Fatalf("failed to migrate the baseline schema: %v", err)
+			}
+
+			var user models.User
+			if err := db.First(&user).Error; err != nil {
+				t.Fatal(err)
+			}
+			if user.Username != "pustakawan" || !user.IsActive || user.TOTPEnabled {
+				t.Errorf("adopted user is %q, active %t, two-factor %t", user.Username, user.IsActive, user.TOTPEnabled)
+			}
+			var adopted models.Member
+			if err := db.First(&adopted, member.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if adopted.MemberCode != "MBR-0001" || adopted.MemberType != "general" || adopted.Language != "id" || adopted.ExpiresAt != nil {
+				t.Errorf("adopted member is %+v", adopted)
+			}
+			var adoptedLoan models.Loan
+			if err := db.First(&adoptedLoan, loan.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if adoptedLoan.Fine != 1500 || adoptedLoan.RenewalCount != 0 || !adoptedLoan.ReturnDate.Equal(returned) {
+				t.Errorf("adopted loan has fine %d, %d renewals and return date %v", adoptedLoan.Fine, adoptedLoan.RenewalCount, adoptedLoan.ReturnDate)
+			}
+
+			var copies, charges int64
+			db.Model(&models.BookCopy{}).Where("book_id = ?", book.ID).Count(&copies)
+			db.Model(&models.FineEntry{}).Where("loan_id = ? AND type = ?", loan.ID, models.FineEntryCharge).Count(&charges)
+			if copies != 2 || charges != 1 {
+				t.Errorf("adopted book has %d copies and loan %d charges, want 2 and 1", copies, charges)
+			}
+
+			// New rows must not collide with the ids of adopted ones.
+			next := models.Member{Name: "Siti Rahma", Email: "siti@example.com", MemberCode: "MBR-0002"}
+			if err := db.Create(&next).Error; err != nil {
+				t.Fatal(err)
+			}
+			if next.ID <= member.ID {
+				t.Errorf("new member has id %d after adopted member %d", next.ID, member.ID)
+			}
+		})
+	}
+}
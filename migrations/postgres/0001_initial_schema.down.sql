@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS role_changes;
+DROP TABLE IF EXISTS circulation_policies;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS fine_entries;
+DROP TABLE IF EXISTS holds;
+DROP TABLE IF EXISTS loans;
+DROP TABLE IF EXISTS members;
+DROP TABLE IF EXISTS book_copies;
+DROP TABLE IF EXISTS books;
+DROP TABLE IF EXISTS users;
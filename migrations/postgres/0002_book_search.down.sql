@@ -0,0 +1,6 @@
+-- The unaccent extension is left installed; other database objects may
+-- rely on it.
+
+DROP INDEX IF EXISTS idx_books_search_vector;
+ALTER TABLE books DROP COLUMN IF EXISTS search_vector;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS library_search;
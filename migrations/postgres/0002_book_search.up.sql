@@ -0,0 +1,28 @@
+-- Full-text search over the book catalog.
+--
+-- The library_search configuration strips accents before indexing and
+-- querying, so "jose" finds "José Rizal". It uses the simple dictionary
+-- rather than a stemmer because the catalog mixes Indonesian and English.
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'library_search') THEN
+        CREATE TEXT SEARCH CONFIGURATION library_search (COPY = simple);
+        ALTER TEXT SEARCH CONFIGURATION library_search
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;
+    END IF;
+END
+$$;
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('library_search', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('library_search', coalesce(author, '')), 'A') ||
+        setweight(to_tsvector('library_search', coalesce(category, '')), 'B') ||
+        setweight(to_tsvector('library_search', coalesce(publisher, '')), 'C') ||
+        setweight(to_tsvector('library_search', coalesce(description, '')), 'D')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_books_search_vector ON books USING GIN (search_vector);
@@ -0,0 +1,2 @@
+-- Backfilled copies cannot be told apart from copies added since, so
+-- they are kept.
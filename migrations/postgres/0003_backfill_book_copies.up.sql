@@ -0,0 +1,17 @@
+-- Backfill physical copies for books created before copies were tracked.
+-- Each book without copies gets `stock` copies; the first `available` of
+-- them are on the shelf and the rest are out on loan. Books that already
+-- have copies are left alone, so the script is safe to run repeatedly.
+
+INSERT INTO book_copies (book_id, barcode, accession_number, condition, status, created_at, updated_at)
+SELECT b.id,
+       lpad(b.id::text, 8, '0') || lpad(n::text, 4, '0'),
+       'ACC-' || lpad(b.id::text, 6, '0') || '-' || lpad(n::text, 4, '0'),
+       'good',
+       CASE WHEN n <= b.available THEN 'available' ELSE 'on_loan' END,
+       NOW(),
+       NOW()
+FROM books b
+CROSS JOIN LATERAL generate_series(1, b.stock) AS n
+WHERE b.stock > 0
+  AND NOT EXISTS (SELECT 1 FROM book_copies c WHERE c.book_id = b.id);
@@ -0,0 +1,2 @@
+-- Backfilled charges cannot be told apart from charges recorded since, so
+-- they are kept.
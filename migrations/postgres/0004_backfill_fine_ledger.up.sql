@@ -0,0 +1,17 @@
+-- Record a ledger charge for every loan fine assessed before the fines
+-- ledger existed. Loans that already have a charge are skipped, so the
+-- script is safe to run repeatedly.
+
+INSERT INTO fine_entries (member_id, loan_id, type, amount, reason, created_at)
+SELECT l.member_id,
+       l.id,
+       'charge',
+       l.fine,
+       'Overdue return',
+       COALESCE(l.return_date, l.updated_at)
+FROM loans l
+WHERE l.fine > 0
+  AND l.deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM fine_entries f WHERE f.loan_id = l.id AND f.type = 'charge'
+  );
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_member_id;
+ALTER TABLE users DROP COLUMN IF EXISTS member_id;
@@ -0,0 +1,5 @@
+-- Links a login account to the library member it belongs to, so members
+-- can manage their own holds. Each member has at most one account.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS member_id BIGINT REFERENCES members(id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_member_id ON users(member_id);
@@ -0,0 +1,35 @@
+-- Sample accounts, books and members for development, loaded by
+-- `migrate seed`; rows that already exist are skipped. Both accounts have
+-- the password "password". sqlite.sql holds the same data.
+
+INSERT INTO users (username, email, password, role, created_at, updated_at) VALUES
+('admin', 'admin@library.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'admin', NOW(), NOW()),
+('librarian', 'librarian@library.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'librarian', NOW(), NOW())
+ON CONFLICT (username) DO NOTHING;
+
+INSERT INTO books (title, author, isbn, publisher, year, category, description, stock, available, created_at, updated_at) VALUES
+('The Great Gatsby', 'F. Scott Fitzgerald', '978-0743273565', 'Scribner', 1925, 'Fiction', 'A story of the fabulously wealthy Jay Gatsby and his love for the beautiful Daisy Buchanan.', 5, 5, NOW(), NOW()),
+('To Kill a Mockingbird', 'Harper Lee', '978-0446310789', 'Grand Central Publishing', 1960, 'Fiction', 'The story of young Scout Finch and her father Atticus in a racially divided Alabama town.', 3, 3, NOW(), NOW()),
+('1984', 'George Orwell', '978-0451524935', 'Signet Classic', 1949, 'Dystopian', 'A dystopian novel about totalitarianism and surveillance society.', 4, 4, NOW(), NOW()),
+('Pride and Prejudice', 'Jane Austen', '978-0141439518', 'Penguin Classics', 1813, 'Romance', 'The story of Elizabeth Bennet and Mr. Darcy in Georgian-era England.', 2, 2, NOW(), NOW())
+ON CONFLICT (isbn) DO NOTHING;
+
+INSERT INTO members (name, email, phone, address, member_code, status, created_at, updated_at) VALUES
+('John Doe', 'john.doe@email.com', '+6281234567890', 'Jl. Sudirman No. 123, Jakarta', 'MEM000001', 'active', NOW(), NOW()),
+('Jane Smith', 'jane.smith@email.com', '+6281234567891', 'Jl. Thamrin No. 456, Jakarta', 'MEM000002', 'active', NOW(), NOW()),
+('Bob Johnson', 'bob.johnson@email.com', '+6281234567892', 'Jl. Gatot Subroto No. 789, Jakarta', 'MEM000003', 'active', NOW(), NOW())
+ON CONFLICT (email) DO NOTHING;
+
+-- Shelve a copy for every unit of stock of the sample books.
+INSERT INTO book_copies (book_id, barcode, accession_number, condition, status, created_at, updated_at)
+SELECT b.id,
+       lpad(b.id::text, 8, '0') || lpad(n::text, 4, '0'),
+       'ACC-' || lpad(b.id::text, 6, '0') || '-' || lpad(n::text, 4, '0'),
+       'good',
+       CASE WHEN n <= b.available THEN 'available' ELSE 'on_loan' END,
+       NOW(),
+       NOW()
+FROM books b
+CROSS JOIN LATERAL generate_series(1, b.stock) AS n
+WHERE b.stock > 0
+  AND NOT EXISTS (SELECT 1 FROM book_copies c WHERE c.book_id = b.id);
@@ -0,0 +1,281 @@
+-- The SQLite schema, matching the Postgres one at the same version. Time
+-- columns are DATETIME because the driver only reads DATE, DATETIME and
+-- TIMESTAMP columns back as times. AUTOINCREMENT keeps ids of deleted rows
+-- from being handed out again, as Postgres sequences do. A database that
+-- AutoMigrate built has its tables set aside by the migrator before this
+-- runs and their rows copied in after; see adopt.go.
+
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE,
+    email TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    role TEXT DEFAULT 'member',
+    is_active BOOLEAN DEFAULT TRUE,
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_secret TEXT,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    sessions_revoked_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
+
+CREATE TABLE IF NOT EXISTS books (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    author TEXT NOT NULL,
+    isbn TEXT NOT NULL UNIQUE,
+    publisher TEXT,
+    year BIGINT,
+    category TEXT,
+    description TEXT,
+    stock BIGINT DEFAULT 0,
+    available BIGINT DEFAULT 0,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_books_title ON books(title);
+CREATE INDEX IF NOT EXISTS idx_books_category ON books(category);
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books(deleted_at);
+
+CREATE TABLE IF NOT EXISTS book_copies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id BIGINT NOT NULL REFERENCES books(id),
+    barcode TEXT NOT NULL UNIQUE,
+    accession_number TEXT NOT NULL UNIQUE,
+    shelf_location TEXT,
+    condition TEXT DEFAULT 'good',
+    status TEXT DEFAULT 'available',
+    notes TEXT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_copies_book_id ON book_copies(book_id);
+CREATE INDEX IF NOT EXISTS idx_book_copies_status ON book_copies(status);
+CREATE INDEX IF NOT EXISTS idx_book_copies_deleted_at ON book_copies(deleted_at);
+
+CREATE TABLE IF NOT EXISTS members (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    phone TEXT,
+    address TEXT,
+    member_code TEXT NOT NULL UNIQUE,
+    member_type TEXT DEFAULT 'general',
+    status TEXT DEFAULT 'active',
+    expires_at DATETIME,
+    notification_preference TEXT DEFAULT 'email',
+    language TEXT DEFAULT 'id',
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_members_status ON members(status);
+CREATE INDEX IF NOT EXISTS idx_members_expires_at ON members(expires_at);
+CREATE INDEX IF NOT EXISTS idx_members_deleted_at ON members(deleted_at);
+
+CREATE TABLE IF NOT EXISTS loans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id BIGINT NOT NULL REFERENCES books(id),
+    member_id BIGINT NOT NULL REFERENCES members(id),
+    copy_id BIGINT REFERENCES book_copies(id),
+    loan_date DATETIME NOT NULL,
+    due_date DATETIME NOT NULL,
+    return_date DATETIME,
+    status TEXT DEFAULT 'borrowed',
+    fine BIGINT DEFAULT 0,
+    renewal_count BIGINT DEFAULT 0,
+    notes TEXT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_loans_book_id ON loans(book_id);
+CREATE INDEX IF NOT EXISTS idx_loans_member_id ON loans(member_id);
+CREATE INDEX IF NOT EXISTS idx_loans_copy_id ON loans(copy_id);
+CREATE INDEX IF NOT EXISTS idx_loans_status ON loans(status);
+CREATE INDEX IF NOT EXISTS idx_loans_due_date ON loans(due_date);
+CREATE INDEX IF NOT EXISTS idx_loans_deleted_at ON loans(deleted_at);
+
+CREATE TABLE IF NOT EXISTS holds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id BIGINT NOT NULL REFERENCES books(id),
+    member_id BIGINT NOT NULL REFERENCES members(id),
+    copy_id BIGINT REFERENCES book_copies(id),
+    status TEXT DEFAULT 'waiting',
+    ready_at DATETIME,
+    expires_at DATETIME,
+    closed_at DATETIME,
+    notes TEXT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_holds_book_id ON holds(book_id);
+CREATE INDEX IF NOT EXISTS idx_holds_member_id ON holds(member_id);
+CREATE INDEX IF NOT EXISTS idx_holds_status ON holds(status);
+CREATE INDEX IF NOT EXISTS idx_holds_deleted_at ON holds(deleted_at);
+
+CREATE TABLE IF NOT EXISTS fine_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    member_id BIGINT NOT NULL REFERENCES members(id),
+    loan_id BIGINT REFERENCES loans(id),
+    type TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    method TEXT,
+    reference TEXT,
+    receipt_number TEXT,
+    reason TEXT,
+    recorded_by_id BIGINT REFERENCES users(id),
+    approved_by_id BIGINT REFERENCES users(id),
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_fine_entries_member_id ON fine_entries(member_id);
+CREATE INDEX IF NOT EXISTS idx_fine_entries_loan_id ON fine_entries(loan_id);
+CREATE INDEX IF NOT EXISTS idx_fine_entries_type ON fine_entries(type);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fine_entries_receipt_number ON fine_entries(receipt_number);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    member_id BIGINT NOT NULL REFERENCES members(id),
+    event TEXT NOT NULL,
+    dedup_key TEXT NOT NULL,
+    data TEXT,
+    status TEXT DEFAULT 'pending',
+    recipient TEXT,
+    subject TEXT,
+    attempts BIGINT DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at DATETIME,
+    sent_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_member_id ON notifications(member_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_dedup_key ON notifications(dedup_key);
+CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications(status);
+CREATE INDEX IF NOT EXISTS idx_notifications_next_attempt_at ON notifications(next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS circulation_policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    member_type TEXT,
+    category TEXT,
+    loan_period_days BIGINT NOT NULL,
+    max_loans BIGINT NOT NULL,
+    max_renewals BIGINT NOT NULL,
+    daily_fine BIGINT NOT NULL,
+    grace_days BIGINT NOT NULL DEFAULT 0,
+    max_fine BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_circulation_policies_scope ON circulation_policies(member_type, category);
+
+CREATE TABLE IF NOT EXISTS role_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    old_role TEXT,
+    new_role TEXT NOT NULL,
+    changed_by_id BIGINT NOT NULL REFERENCES users(id),
+    reason TEXT,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_changes_user_id ON role_changes(user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    token_hash TEXT NOT NULL,
+    family_id TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    replaced_by_id BIGINT REFERENCES refresh_tokens(id),
+    ip_address TEXT,
+    user_agent TEXT,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    token_hash TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    ip_address TEXT,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_tokens_token_hash ON password_reset_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_expires_at ON password_reset_tokens(expires_at);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    code_hash TEXT NOT NULL,
+    used_at DATETIME,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes(code_hash);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(255) PRIMARY KEY,
+    failures BIGINT NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure_at ON login_attempts(last_failure_at);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id BIGINT REFERENCES users(id),
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT,
+    "before" TEXT,
+    "after" TEXT,
+    changes TEXT,
+    details TEXT,
+    ip_address TEXT,
+    request_id TEXT,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action);
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_request_id ON audit_events(request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
@@ -0,0 +1,33 @@
+-- Full-text search over the book catalog.
+--
+-- books_fts indexes the searchable columns of books without storing a
+-- second copy of them, and the triggers keep it in step. remove_diacritics
+-- makes "jose" find "José Rizal", like the unaccent mapping on Postgres,
+-- and there is no stemming because the catalog mixes Indonesian and
+-- English.
+
+CREATE VIRTUAL TABLE IF NOT EXISTS books_fts USING fts5(
+    title, author, category, publisher, description,
+    content='books',
+    content_rowid='id',
+    tokenize='unicode61 remove_diacritics 2'
+);
+
+INSERT INTO books_fts(books_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS books_fts_insert AFTER INSERT ON books BEGIN
+    INSERT INTO books_fts(rowid, title, author, category, publisher, description)
+    VALUES (new.id, new.title, new.author, new.category, new.publisher, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS books_fts_delete AFTER DELETE ON books BEGIN
+    INSERT INTO books_fts(books_fts, rowid, title, author, category, publisher, description)
+    VALUES ('delete', old.id, old.title, old.author, old.category, old.publisher, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS books_fts_update AFTER UPDATE ON books BEGIN
+    INSERT INTO books_fts(books_fts, rowid, title, author, category, publisher, description)
+    VALUES ('delete', old.id, old.title, old.author, old.category, old.publisher, old.description);
+    INSERT INTO books_fts(rowid, title, author, category, publisher, description)
+    VALUES (new.id, new.title, new.author, new.category, new.publisher, new.description);
+END;
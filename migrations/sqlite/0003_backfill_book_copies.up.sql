@@ -0,0 +1,24 @@
+-- Backfill physical copies for books created before copies were tracked,
+-- as the Postgres migration does, for SQLite databases that AutoMigrate
+-- built. Each book without copies gets `stock` copies; the first
+-- `available` of them are on the shelf and the rest are out on loan. SQLite
+-- has no generate_series, so a recursive query counts up to the largest
+-- stock.
+
+WITH RECURSIVE n(n) AS (
+    SELECT 1
+    UNION ALL
+    SELECT n + 1 FROM n WHERE n < (SELECT MAX(stock) FROM books)
+)
+INSERT INTO book_copies (book_id, barcode, accession_number, condition, status, created_at, updated_at)
+SELECT b.id,
+       printf('%08d%04d', b.id, n.n),
+       printf('ACC-%06d-%04d', b.id, n.n),
+       'good',
+       CASE WHEN n.n <= b.available THEN 'available' ELSE 'on_loan' END,
+       CURRENT_TIMESTAMP,
+       CURRENT_TIMESTAMP
+FROM books b
+JOIN n ON n.n <= b.stock
+WHERE b.stock > 0
+  AND NOT EXISTS (SELECT 1 FROM book_copies c WHERE c.book_id = b.id);
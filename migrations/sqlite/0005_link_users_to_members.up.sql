@@ -0,0 +1,8 @@
+-- Links a login account to the library member it belongs to, so members
+-- can manage their own holds. Each member has at most one account.
+--
+-- SQLite cannot drop a column that takes part in a foreign key, so the
+-- reference to members is left to the application here.
+
+ALTER TABLE users ADD COLUMN member_id INTEGER;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_member_id ON users(member_id);